@@ -0,0 +1,152 @@
+// Package account implements the account lifecycle around login: email
+// verification, password resets and locking accounts after repeated failed
+// logins.
+package account
+
+import (
+	"errors"
+	"fmt"
+	"task-management-api/models"
+	"task-management-api/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Errors caused by requests that cannot be carried out
+var (
+	ErrInvalidToken = errors.New("invalid or expired token")
+	ErrWeakPassword = errors.New("password does not meet the password policy")
+)
+
+// ResendInterval is how long after sending a token another one for the same
+// purpose is not sent, so that the endpoints cannot be used to flood inboxes
+const ResendInterval = time.Minute
+
+// IssueToken creates a token for the user and returns it; only its hash is
+// stored. Unused tokens issued earlier for the same purpose stop working.
+func IssueToken(tx *gorm.DB, userID uint, purpose models.AccountTokenPurpose, ttl time.Duration, now time.Time) (string, error) {
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Delete(&models.AccountToken{}).Error; err != nil {
+		return "", fmt.Errorf("failed to replace account tokens: %w", err)
+	}
+
+	record := models.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(ttl).UTC(),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return "", fmt.Errorf("failed to create account token: %w", err)
+	}
+	return token, nil
+}
+
+// RecentlyIssued reports whether a token for the purpose was issued to the
+// user within the ResendInterval
+func RecentlyIssued(db *gorm.DB, userID uint, purpose models.AccountTokenPurpose, now time.Time) (bool, error) {
+	var latest []models.AccountToken
+	if err := db.Where("user_id = ? AND purpose = ?", userID, purpose).
+		Order("created_at desc").Limit(1).
+		Find(&latest).Error; err != nil {
+		return false, fmt.Errorf("failed to load account tokens: %w", err)
+	}
+	return len(latest) > 0 && now.Sub(latest[0].CreatedAt) < ResendInterval, nil
+}
+
+// VerifyEmail marks the email address of the token's user as verified
+func VerifyEmail(tx *gorm.DB, token string, now time.Time) (*models.User, error) {
+	user, err := consume(tx, models.TokenVerifyEmail, token, now)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsVerified() {
+		return user, nil
+	}
+
+	verifiedAt := now.UTC()
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).
+		Update("email_verified_at", verifiedAt).Error; err != nil {
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+	user.EmailVerifiedAt = &verifiedAt
+	return user, nil
+}
+
+// ResetPassword sets a new password for the token's user. It also unlocks the
+// account, verifies its email address, since the token arrived there, and
+// revokes all sessions, which may belong to whoever knew the old password.
+func ResetPassword(tx *gorm.DB, token, password string, now time.Time) (*models.User, error) {
+	user, err := consume(tx, models.TokenResetPassword, token, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.ValidatePassword(password, user.Username, user.Email); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWeakPassword, err)
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"password": hashedPassword,
+	}
+	if !user.IsVerified() {
+		verifiedAt := now.UTC()
+		updates["email_verified_at"] = verifiedAt
+		user.EmailVerifiedAt = &verifiedAt
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if err := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", user.ID).
+		Update("revoked_at", now).Error; err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return user, nil
+}
+
+// consume marks an unexpired token as used and returns its user. The
+// condition on used_at makes concurrent uses of the same token fail.
+func consume(tx *gorm.DB, purpose models.AccountTokenPurpose, token string, now time.Time) (*models.User, error) {
+	var record models.AccountToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", utils.HashToken(token), purpose).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to load account token: %w", err)
+	}
+	if record.UsedAt != nil || !now.Before(record.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	result := tx.Model(&models.AccountToken{}).
+		Where("id = ? AND used_at IS NULL", record.ID).
+		Update("used_at", now.UTC())
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to use account token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	var user models.User
+	if err := tx.First(&user, record.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	return &user, nil
+}
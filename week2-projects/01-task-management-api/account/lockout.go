@@ -0,0 +1,137 @@
+package account
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginLimiter counts failed logins and locks out further attempts. Failures
+// are counted per email and client address, so that guessing from one address
+// never locks the owner out from theirs, and per client address, so that one
+// address cannot keep guessing across accounts. Emails without an account are
+// counted like any other, so the responses do not reveal which accounts exist.
+//
+// Counts are kept in memory and a failure is forgotten once lockout has
+// passed without another one.
+type LoginLimiter struct {
+	maxPerLogin int
+	maxPerIP    int
+	lockout     time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*failures
+	swept   time.Time
+}
+
+// failures is the failed login count of one key
+type failures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// NewLoginLimiter creates a LoginLimiter that locks an email for lockout after
+// maxPerLogin failures from one address, and an address after maxPerIP
+// failures across all emails
+func NewLoginLimiter(maxPerLogin, maxPerIP int, lockout time.Duration) *LoginLimiter {
+	return &LoginLimiter{
+		maxPerLogin: maxPerLogin,
+		maxPerIP:    maxPerIP,
+		lockout:     lockout,
+		entries:     make(map[string]*failures),
+	}
+}
+
+// LockedFor returns how much longer logins for email from ip are locked out,
+// or zero if they may be tried
+func (l *LoginLimiter) LockedFor(email, ip string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	for _, key := range []string{loginKey(email, ip), ipKey(ip)} {
+		if entry, ok := l.entries[key]; ok && now.Before(entry.lockedUntil) {
+			if d := entry.lockedUntil.Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+	return wait
+}
+
+// RecordFailure counts a failed login for email from ip. It returns how long
+// logins are now locked out, or zero if this failure locked nothing.
+func (l *LoginLimiter) RecordFailure(email, ip string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	var wait time.Duration
+	for _, limit := range []struct {
+		key string
+		max int
+	}{
+		{loginKey(email, ip), l.maxPerLogin},
+		{ipKey(ip), l.maxPerIP},
+	} {
+		entry, ok := l.entries[limit.key]
+		if !ok || now.Sub(entry.last) >= l.lockout {
+			entry = &failures{}
+			l.entries[limit.key] = entry
+		}
+		entry.count++
+		entry.last = now
+		if limit.max > 0 && entry.count >= limit.max {
+			entry.count = 0
+			entry.lockedUntil = now.Add(l.lockout)
+			wait = l.lockout
+		}
+	}
+	return wait
+}
+
+// RecordSuccess clears the failures of email from ip after a successful
+// login. The address keeps its count, so logging in to an account of one's
+// own does not allow more guesses at others.
+func (l *LoginLimiter) RecordSuccess(email, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, loginKey(email, ip))
+}
+
+// Forget clears the failures of email from every address, after its password
+// was reset
+func (l *LoginLimiter) Forget(email string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prefix := loginKey(email, "")
+	for key := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// sweep drops the counts that have expired, at most once per lockout
+func (l *LoginLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.lockout {
+		return
+	}
+	l.swept = now
+	for key, entry := range l.entries {
+		if now.Sub(entry.last) >= l.lockout && !now.Before(entry.lockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+func loginKey(email, ip string) string {
+	return "login\x00" + strings.ToLower(strings.TrimSpace(email)) + "\x00" + ip
+}
+
+func ipKey(ip string) string {
+	return "ip\x00" + ip
+}
@@ -0,0 +1,39 @@
+package account
+
+import (
+	"fmt"
+	"net/url"
+	"task-management-api/mailer"
+	"task-management-api/models"
+	"time"
+)
+
+// VerificationMessage is the email asking a new user to verify their address.
+// With an app URL the token is sent as a link into the app, otherwise as is.
+func VerificationMessage(user *models.User, token, appURL string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease verify your email address to activate your account.\n\n%s\n\nThis expires in %s. If you did not sign up, you can ignore this email.\n",
+			user.Username, action(appURL, "/verify-email", token, "POST /api/v1/auth/verify-email"), ttl),
+	}
+}
+
+// PasswordResetMessage is the email with a link to set a new password
+func PasswordResetMessage(user *models.User, token, appURL string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account.\n\n%s\n\nThis expires in %s. If it was not you, you can ignore this email; your password stays unchanged.\n",
+			user.Username, action(appURL, "/reset-password", token, "POST /api/v1/auth/reset-password"), ttl),
+	}
+}
+
+// action tells the user how to use a token: a link into the app, or the API
+// endpoint to send it to when no app URL is configured
+func action(appURL, path, token, endpoint string) string {
+	if appURL == "" {
+		return fmt.Sprintf("Your token: %s\n(send it to %s)", token, endpoint)
+	}
+	return fmt.Sprintf("Open this link: %s%s?token=%s", appURL, path, url.QueryEscape(token))
+}
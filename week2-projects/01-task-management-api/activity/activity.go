@@ -0,0 +1,142 @@
+package activity
+
+import (
+	"fmt"
+	"sort"
+	"task-management-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Record appends an entry to the activity log. Pass nil for before when a
+// task is created and nil for after when it is deleted. A nil actor marks a
+// change made by the system, such as the recurrence scheduler. Updates that
+// do not change any field are not recorded.
+func Record(tx *gorm.DB, action models.ActivityAction, actorID *uint, before, after *models.Task) error {
+	changes := Diff(before, after)
+	if action == models.ActionUpdated && len(changes) == 0 {
+		return nil
+	}
+
+	task := after
+	if task == nil {
+		task = before
+	}
+
+	entry := models.Activity{
+		TaskID:  task.ID,
+		UserID:  task.UserID,
+		ActorID: actorID,
+		Action:  action,
+		Changes: changes,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	return nil
+}
+
+// Diff returns the user-visible fields that differ between two versions of a
+// task. A nil version counts as having no values.
+func Diff(before, after *models.Task) models.ActivityChanges {
+	changes := models.ActivityChanges{}
+	add := func(field string, old, new interface{}) {
+		changes = append(changes, models.FieldChange{Field: field, Old: old, New: new})
+	}
+
+	var oldValues, newValues taskValues
+	if before != nil {
+		oldValues = valuesOf(before)
+	}
+	if after != nil {
+		newValues = valuesOf(after)
+	}
+
+	if oldValues.title != newValues.title {
+		add("title", oldValues.title, newValues.title)
+	}
+	if oldValues.description != newValues.description {
+		add("description", oldValues.description, newValues.description)
+	}
+	if oldValues.priority != newValues.priority {
+		add("priority", oldValues.priority, newValues.priority)
+	}
+	if oldValues.status != newValues.status {
+		add("status", oldValues.status, newValues.status)
+	}
+	if !sameTime(oldValues.dueDate, newValues.dueDate) {
+		add("due_date", oldValues.dueDate, newValues.dueDate)
+	}
+	if !sameIDs(oldValues.labelIDs, newValues.labelIDs) {
+		add("label_ids", oldValues.labelIDs, newValues.labelIDs)
+	}
+	if oldValues.columnID != newValues.columnID {
+		add("column_id", oldValues.columnID, newValues.columnID)
+	}
+	if oldValues.estimateMinutes != newValues.estimateMinutes {
+		add("estimate_minutes", oldValues.estimateMinutes, newValues.estimateMinutes)
+	}
+
+	return changes
+}
+
+// taskValues holds the diffable fields of a task; nil means "no value"
+type taskValues struct {
+	title       interface{}
+	description interface{}
+	priority    interface{}
+	status      interface{}
+	dueDate     *time.Time
+	labelIDs    []uint
+	columnID    interface{}
+
+	estimateMinutes interface{}
+}
+
+// valuesOf extracts the diffable fields of a task
+func valuesOf(task *models.Task) taskValues {
+	labelIDs := make([]uint, 0, len(task.Labels))
+	for _, label := range task.Labels {
+		labelIDs = append(labelIDs, label.ID)
+	}
+	sort.Slice(labelIDs, func(i, j int) bool { return labelIDs[i] < labelIDs[j] })
+
+	values := taskValues{
+		title:       task.Title,
+		description: task.Description,
+		priority:    string(task.Priority),
+		status:      string(task.Status),
+		dueDate:     task.DueDate,
+		labelIDs:    labelIDs,
+	}
+	if task.ColumnID != nil {
+		values.columnID = *task.ColumnID
+	}
+	if task.EstimateMinutes != nil {
+		values.estimateMinutes = *task.EstimateMinutes
+	}
+	return values
+}
+
+// sameTime compares two optional timestamps
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// sameIDs compares two sorted ID lists, treating nil and empty as different
+// so that a created task with no labels still records an empty label list
+func sameIDs(a, b []uint) bool {
+	if (a == nil) != (b == nil) || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
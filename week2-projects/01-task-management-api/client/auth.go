@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"task-management-api/models"
+)
+
+// Register creates an account. When the server does not require email
+// verification the response carries tokens, which the client keeps.
+func (c *Client) Register(ctx context.Context, req models.UserRegisterRequest) (*models.AuthResponse, error) {
+	var resp models.AuthResponse
+	if err := c.do(ctx, http.MethodPost, "/auth/register", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Token != "" {
+		c.SetTokens(resp.Token, resp.RefreshToken)
+	}
+	return &resp, nil
+}
+
+// Login starts a session and keeps its tokens. A locked account fails with
+// an *APIError whose RetryAfter tells when to try again.
+func (c *Client) Login(ctx context.Context, req models.UserLoginRequest) (*models.AuthResponse, error) {
+	var resp models.AuthResponse
+	if err := c.do(ctx, http.MethodPost, "/auth/login", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	c.SetTokens(resp.Token, resp.RefreshToken)
+	return &resp, nil
+}
+
+// Refresh exchanges the kept refresh token for new tokens and keeps them
+func (c *Client) Refresh(ctx context.Context) (*models.TokenResponse, error) {
+	var resp models.TokenResponse
+	req := models.RefreshTokenRequest{RefreshToken: c.RefreshToken()}
+	if err := c.do(ctx, http.MethodPost, "/auth/refresh", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	c.SetTokens(resp.Token, resp.RefreshToken)
+	return &resp, nil
+}
+
+// VerifyEmail verifies an email address with the emailed token
+func (c *Client) VerifyEmail(ctx context.Context, token string) (*models.AuthResponse, error) {
+	var resp models.AuthResponse
+	if err := c.do(ctx, http.MethodPost, "/auth/verify-email", nil, models.VerifyEmailRequest{Token: token}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ResendVerification asks for a new verification email
+func (c *Client) ResendVerification(ctx context.Context, email string) error {
+	return c.do(ctx, http.MethodPost, "/auth/resend-verification", nil, models.EmailRequest{Email: email}, nil)
+}
+
+// ForgotPassword asks for a password reset email
+func (c *Client) ForgotPassword(ctx context.Context, email string) error {
+	return c.do(ctx, http.MethodPost, "/auth/forgot-password", nil, models.EmailRequest{Email: email}, nil)
+}
+
+// ResetPassword sets a new password with the emailed reset token
+func (c *Client) ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error {
+	return c.do(ctx, http.MethodPost, "/auth/reset-password", nil, req, nil)
+}
+
+// Profile returns the authenticated user
+func (c *Client) Profile(ctx context.Context) (*models.UserResponse, error) {
+	var resp models.UserResponse
+	if err := c.do(ctx, http.MethodGet, "/auth/profile", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Logout revokes the current session and forgets its tokens
+func (c *Client) Logout(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodPost, "/auth/logout", nil, nil, nil); err != nil {
+		return err
+	}
+	c.SetTokens("", "")
+	return nil
+}
+
+// LogoutAll revokes every session of the user and forgets the tokens
+func (c *Client) LogoutAll(ctx context.Context) (*models.LogoutAllResponse, error) {
+	var resp models.LogoutAllResponse
+	if err := c.do(ctx, http.MethodPost, "/auth/logout-all", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	c.SetTokens("", "")
+	return &resp, nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"task-management-api/models"
+)
+
+// CreateTask creates a task
+func (c *Client) CreateTask(ctx context.Context, req models.CreateTaskRequest) (*models.Task, error) {
+	var task models.Task
+	if err := c.do(ctx, http.MethodPost, "/tasks", nil, req, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// ListTasks returns the tasks matching the filter; a zero filter returns all
+func (c *Client) ListTasks(ctx context.Context, filter models.TaskFilterParams) ([]models.Task, error) {
+	var tasks []models.Task
+	if err := c.do(ctx, http.MethodGet, "/tasks", queryValues(filter), nil, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetTask returns a task
+func (c *Client) GetTask(ctx context.Context, id uint) (*models.Task, error) {
+	var task models.Task
+	if err := c.do(ctx, http.MethodGet, taskPath(id), nil, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTask changes the fields of a task that are set in req
+func (c *Client) UpdateTask(ctx context.Context, id uint, req models.UpdateTaskRequest) (*models.Task, error) {
+	var task models.Task
+	if err := c.do(ctx, http.MethodPut, taskPath(id), nil, req, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// DeleteTask deletes a task
+func (c *Client) DeleteTask(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, taskPath(id), nil, nil, nil)
+}
+
+// MoveTask moves a task on the board
+func (c *Client) MoveTask(ctx context.Context, id uint, req models.MoveTaskRequest) (*models.Task, error) {
+	var task models.Task
+	if err := c.do(ctx, http.MethodPost, taskPath(id, "move"), nil, req, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// TaskStats returns the task statistics with a report of tracked time
+func (c *Client) TaskStats(ctx context.Context, params models.TimeReportParams) (*models.TaskStats, error) {
+	var stats models.TaskStats
+	if err := c.do(ctx, http.MethodGet, "/tasks/stats", queryValues(params), nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// BatchTasks applies create, update and delete operations in bulk. Failed
+// operations are not an error: check Failed and the result of each.
+func (c *Client) BatchTasks(ctx context.Context, req models.BatchRequest) (*models.BatchResponse, error) {
+	var resp models.BatchResponse
+	if err := c.do(ctx, http.MethodPost, "/tasks/batch", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// TaskHistory returns a page of the change history of a task
+func (c *Client) TaskHistory(ctx context.Context, id uint, params models.PaginationParams) (*models.ActivityPage, error) {
+	var page models.ActivityPage
+	if err := c.do(ctx, http.MethodGet, taskPath(id, "history"), queryValues(params), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
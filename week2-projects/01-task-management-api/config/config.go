@@ -2,46 +2,194 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	Log         LogConfig
+	Metrics     MetricsConfig
+	Database    DatabaseConfig
+	JWT         JWTConfig
+	Auth        AuthConfig
+	Mail        MailConfig
+	Recurrence  RecurrenceConfig
+	Attachments AttachmentConfig
+	Reminders   ReminderConfig
+	Webhooks    WebhookConfig
+	Events      EventConfig
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Port string
-	Mode string // "debug" or "release"
+	Port           string
+	Mode           string   // "debug" or "release"
+	TrustedProxies []string // Proxies whose X-Forwarded-For gives the client address
+}
+
+// LogConfig holds logging configuration
+type LogConfig struct {
+	Level  string // "debug", "info", "warn" or "error"
+	Format string // "json" or "text"
+}
+
+// MetricsConfig holds configuration for the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled bool
+	Token   string // Bearer token required to scrape /metrics; empty leaves it open
 }
 
 // DatabaseConfig holds database-related configuration
 type DatabaseConfig struct {
-	Path string
+	Driver      string        // "sqlite" or "postgres"
+	Path        string        // SQLite database file
+	DSN         string        // Postgres connection string
+	AutoMigrate bool          // Apply pending migrations on startup
+	SlowQuery   time.Duration // Queries taking longer are logged as warnings
 }
 
 // JWTConfig holds JWT-related configuration
 type JWTConfig struct {
-	Secret     string
-	Expiration time.Duration
+	Secret            string
+	Expiration        time.Duration // Access token lifetime
+	RefreshExpiration time.Duration // Refresh token lifetime, extended on every refresh
+}
+
+// AuthConfig holds configuration for account verification, password resets
+// and login lockout
+type AuthConfig struct {
+	RequireVerification    bool          // Refuse logins until the email address is verified
+	VerificationExpiration time.Duration // Lifetime of email verification links
+	ResetExpiration        time.Duration // Lifetime of password reset links
+	MaxFailedLogins        int           // Failed logins for one email from one address before they are locked out
+	MaxFailedLoginsPerIP   int           // Failed logins from one address, across all emails, before it is locked out
+	LockoutDuration        time.Duration // How long locked out logins are refused
+}
+
+// MailConfig holds configuration for outgoing email
+type MailConfig struct {
+	Driver       string // "log", "file" or "smtp"
+	From         string
+	Dir          string // Directory the file driver writes messages to
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	AppURL       string // Base URL of the web app that links in emails point to; empty sends bare tokens
+}
+
+// RecurrenceConfig holds configuration for the recurring task scheduler
+type RecurrenceConfig struct {
+	Interval time.Duration // How often the scheduler runs
+	Horizon  time.Duration // How far ahead occurrences are materialized
+}
+
+// AttachmentConfig holds configuration for task file attachments
+type AttachmentConfig struct {
+	Dir          string   // Directory where files are stored
+	MaxSize      int64    // Maximum file size in bytes
+	AllowedTypes []string // Allowed MIME types, detected from the file content
+}
+
+// ReminderConfig holds configuration for due-date reminders
+type ReminderConfig struct {
+	Interval time.Duration   // How often due dates are checked
+	Offsets  []time.Duration // How long before the due date reminders fire
+}
+
+// WebhookConfig holds configuration for outbound webhook delivery
+type WebhookConfig struct {
+	Interval    time.Duration // How often queued deliveries are sent
+	Timeout     time.Duration // HTTP timeout per delivery attempt
+	MaxAttempts int           // Attempts before a delivery is marked failed
+	Concurrency int           // Webhooks sent to at once
+	Retention   time.Duration // How long finished deliveries are kept, 0 for ever
+}
+
+// EventConfig holds configuration for the real-time event stream
+type EventConfig struct {
+	BufferSize       int           // Recent events kept for clients resuming with Last-Event-ID
+	Heartbeat        time.Duration // Interval of keep-alive comments on idle streams
+	TicketExpiration time.Duration // How long a stream ticket can be used to connect
 }
 
 // LoadConfig loads configuration from environment variables with defaults
 func LoadConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Mode: getEnv("GIN_MODE", "debug"), // "debug" or "release"
+			Port:           getEnv("SERVER_PORT", "8080"),
+			Mode:           getEnv("GIN_MODE", "debug"), // "debug" or "release"
+			TrustedProxies: getEnvList("TRUSTED_PROXIES", nil),
+		},
+		Log: LogConfig{
+			Level:  getEnv("LOG_LEVEL", "info"),
+			Format: getEnv("LOG_FORMAT", "json"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvBool("METRICS_ENABLED", true),
+			Token:   getEnv("METRICS_TOKEN", ""),
 		},
 		Database: DatabaseConfig{
-			Path: getEnv("DB_PATH", "./data/tasks.db"),
+			Driver:      getEnv("DB_DRIVER", "sqlite"),
+			Path:        getEnv("DB_PATH", "./data/tasks.db"),
+			DSN:         getEnv("DB_DSN", ""), // e.g. "host=localhost user=tasks password=tasks dbname=tasks sslmode=disable"
+			AutoMigrate: getEnvBool("DB_AUTO_MIGRATE", true),
+			SlowQuery:   getEnvDuration("DB_SLOW_QUERY", 200*time.Millisecond),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-secret-key-change-this-in-production"),
-			Expiration: 24 * time.Hour, // Token expires in 24 hours
+			Secret:            getEnv("JWT_SECRET", "your-secret-key-change-this-in-production"),
+			Expiration:        getEnvDuration("JWT_EXPIRATION", 15*time.Minute),
+			RefreshExpiration: getEnvDuration("JWT_REFRESH_EXPIRATION", 30*24*time.Hour),
+		},
+		Auth: AuthConfig{
+			RequireVerification:    getEnvBool("AUTH_REQUIRE_VERIFICATION", true),
+			VerificationExpiration: getEnvDuration("AUTH_VERIFICATION_EXPIRATION", 48*time.Hour),
+			ResetExpiration:        getEnvDuration("AUTH_RESET_EXPIRATION", time.Hour),
+			MaxFailedLogins:        int(getEnvInt64("AUTH_MAX_FAILED_LOGINS", 5)),
+			MaxFailedLoginsPerIP:   int(getEnvInt64("AUTH_MAX_FAILED_LOGINS_PER_IP", 20)),
+			LockoutDuration:        getEnvDuration("AUTH_LOCKOUT_DURATION", 15*time.Minute),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "Task Manager <no-reply@localhost>"),
+			Dir:          getEnv("MAIL_DIR", "./data/mail"),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			AppURL:       strings.TrimSuffix(getEnv("APP_URL", ""), "/"),
+		},
+		Recurrence: RecurrenceConfig{
+			Interval: getEnvDuration("RECURRENCE_INTERVAL", 5*time.Minute),
+			Horizon:  getEnvDuration("RECURRENCE_HORIZON", 7*24*time.Hour),
+		},
+		Attachments: AttachmentConfig{
+			Dir:     getEnv("ATTACHMENT_DIR", "./data/attachments"),
+			MaxSize: getEnvInt64("ATTACHMENT_MAX_SIZE", 10<<20), // 10 MB
+			AllowedTypes: getEnvList("ATTACHMENT_ALLOWED_TYPES", []string{
+				"image/png", "image/jpeg", "image/gif", "image/webp",
+				"application/pdf", "application/zip",
+				"text/plain", "text/csv",
+			}),
+		},
+		Reminders: ReminderConfig{
+			Interval: getEnvDuration("REMINDER_INTERVAL", time.Minute),
+			Offsets:  getEnvDurationList("REMINDER_OFFSETS", []time.Duration{24 * time.Hour, time.Hour}),
+		},
+		Webhooks: WebhookConfig{
+			Interval:    getEnvDuration("WEBHOOK_INTERVAL", 10*time.Second),
+			Timeout:     getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts: int(getEnvInt64("WEBHOOK_MAX_ATTEMPTS", 8)),
+			Concurrency: int(getEnvInt64("WEBHOOK_CONCURRENCY", 4)),
+			Retention:   getEnvDuration("WEBHOOK_RETENTION", 30*24*time.Hour),
+		},
+		Events: EventConfig{
+			BufferSize:       int(getEnvInt64("EVENT_BUFFER_SIZE", 1000)),
+			Heartbeat:        getEnvDuration("EVENT_HEARTBEAT", 25*time.Second),
+			TicketExpiration: getEnvDuration("EVENT_TICKET_EXPIRATION", time.Minute),
 		},
 	}
 }
@@ -54,3 +202,62 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvBool gets a boolean environment variable (e.g. "true", "0") or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "5m") or returns a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvInt64 gets an integer environment variable or returns a default value
+func getEnvInt64(key string, defaultValue int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvList gets a comma-separated environment variable or returns a default value
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvDurationList gets a comma-separated list of durations (e.g. "24h,1h") or returns a default value
+func getEnvDurationList(key string, defaultValue []time.Duration) []time.Duration {
+	var durations []time.Duration
+	for _, item := range getEnvList(key, nil) {
+		duration, err := time.ParseDuration(item)
+		if err != nil || duration <= 0 {
+			return defaultValue
+		}
+		durations = append(durations, duration)
+	}
+	if len(durations) == 0 {
+		return defaultValue
+	}
+	return durations
+}
@@ -3,40 +3,81 @@ package database
 import (
 	"fmt"
 	"log"
-	"task-management-api/models"
+	"os"
+	"path/filepath"
+	"task-management-api/config"
+	"task-management-api/logging"
+	"task-management-api/migrations"
 
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
-// InitDatabase initializes the database connection and runs migrations
-func InitDatabase(dbPath string) error {
-	var err error
+// InitDatabase connects to the configured database and, unless disabled,
+// applies pending migrations
+func InitDatabase(cfg config.DatabaseConfig) error {
+	if err := Connect(cfg); err != nil {
+		return err
+	}
 
-	// Open SQLite database connection
-	DB, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	if !cfg.AutoMigrate {
+		log.Println("✓ Automatic migrations disabled, run \"migrate up\" to update the schema")
+		return nil
+	}
+
+	applied, err := migrations.Up(DB)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	log.Println("✓ Database connection established")
+	log.Printf("✓ Database migrations completed (%d applied)", applied)
+
+	return nil
+}
+
+// Connect opens the database connection for the configured driver
+func Connect(cfg config.DatabaseConfig) error {
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return err
+	}
 
-	// Run auto migrations
-	err = DB.AutoMigrate(&models.User{}, &models.Task{})
+	DB, err = gorm.Open(dialector, &gorm.Config{
+		Logger: logging.NewGormLogger(cfg.SlowQuery),
+	})
 	if err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	log.Println("✓ Database migrations completed")
+	log.Printf("✓ Database connection established (%s)", cfg.Driver)
 
 	return nil
 }
 
+// openDialector returns the GORM dialector for the configured driver
+func openDialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "sqlite":
+		// Create the directory of the database file
+		if dir := filepath.Dir(cfg.Path); dir != "." {
+			if err := os.MkdirAll(dir, 0o750); err != nil {
+				return nil, fmt.Errorf("failed to create database directory: %w", err)
+			}
+		}
+		return sqlite.Open(cfg.Path), nil
+	case "postgres":
+		if cfg.DSN == "" {
+			return nil, fmt.Errorf("DB_DSN is required for the postgres driver")
+		}
+		return postgres.Open(cfg.DSN), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q (use sqlite or postgres)", cfg.Driver)
+	}
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
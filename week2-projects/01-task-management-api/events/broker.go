@@ -0,0 +1,146 @@
+// Package events is an in-process publish/subscribe broker for real-time task
+// updates. Recent events are kept in a short buffer so that clients can
+// resume after reconnecting.
+package events
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	TaskCreated = "task.created"
+	TaskUpdated = "task.updated"
+	TaskDeleted = "task.deleted"
+
+	WorkflowUpdated  = "workflow.updated"
+	ColumnRebalanced = "column.rebalanced" // Ranks of a whole column were rewritten; reload it
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is disconnected; the client then resumes from the buffer
+const subscriberBuffer = 64
+
+// Event is a published event. IDs increase by one per event, across all users.
+type Event struct {
+	ID     uint64
+	Type   string
+	UserID uint
+	Data   []byte // JSON
+	Time   time.Time
+}
+
+// Subscription receives the events of one user. The channel is closed when
+// the subscriber falls too far behind.
+type Subscription struct {
+	StartID uint64 // ID of the latest event when the subscription started
+	userID  uint
+	events  chan Event
+}
+
+// Events returns the channel delivering the subscription's events
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Broker fans events out to subscribers and keeps the most recent ones
+type Broker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	buffer      []Event // Most recent events, oldest first
+	bufferSize  int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBroker creates a broker that keeps the last bufferSize events for resuming
+func NewBroker(bufferSize int) *Broker {
+	return &Broker{
+		buffer:      make([]Event, 0, bufferSize),
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event to the user's subscribers
+func (b *Broker) Publish(userID uint, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("failed to encode event", "type", eventType, "error", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{ID: b.lastID, Type: eventType, UserID: userID, Data: payload, Time: time.Now()}
+
+	if len(b.buffer) == b.bufferSize && b.bufferSize > 0 {
+		copy(b.buffer, b.buffer[1:])
+		b.buffer = b.buffer[:len(b.buffer)-1]
+	}
+	if b.bufferSize > 0 {
+		b.buffer = append(b.buffer, event)
+	}
+
+	for sub := range b.subscribers {
+		if sub.userID != userID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Too slow; disconnect rather than block publishers
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the user's events. If resume is set,
+// it also returns the buffered events after lastID. ok is false when events
+// after lastID are no longer buffered, so the client has to reload its state.
+func (b *Broker) Subscribe(userID uint, lastID uint64, resume bool) (sub *Subscription, missed []Event, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ok = true
+	if resume {
+		switch {
+		case lastID > b.lastID:
+			// The ID is from before a restart
+			ok = false
+		case lastID < b.lastID:
+			if len(b.buffer) == 0 || b.buffer[0].ID > lastID+1 {
+				ok = false
+				break
+			}
+			for _, event := range b.buffer {
+				if event.ID > lastID && event.UserID == userID {
+					missed = append(missed, event)
+				}
+			}
+		}
+	}
+
+	sub = &Subscription{StartID: b.lastID, userID: userID, events: make(chan Event, subscriberBuffer)}
+	b.subscribers[sub] = struct{}{}
+	return sub, missed, ok
+}
+
+// Unsubscribe removes a subscriber
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+// remove unregisters a subscriber and closes its channel; b.mu must be held
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// pendingKey is the context key of the events staged in a transaction
+type pendingKey struct{}
+
+// Pending collects events staged during a database transaction, so they are
+// only published once it has committed
+type Pending struct {
+	events []stagedEvent
+}
+
+type stagedEvent struct {
+	userID    uint
+	eventType string
+	data      interface{}
+}
+
+// Track returns a session of db that collects the events staged through it
+func Track(db *gorm.DB) (*gorm.DB, *Pending) {
+	pending := &Pending{}
+	ctx := context.WithValue(db.Statement.Context, pendingKey{}, pending)
+	return db.WithContext(ctx), pending
+}
+
+// Stage records an event in the transaction's pending events. It does nothing
+// if tx is not tracked. Pass data by value so later changes don't affect it.
+func Stage(tx *gorm.DB, userID uint, eventType string, data interface{}) {
+	if tx.Statement.Context == nil {
+		return
+	}
+	if pending, ok := tx.Statement.Context.Value(pendingKey{}).(*Pending); ok {
+		pending.events = append(pending.events, stagedEvent{userID: userID, eventType: eventType, data: data})
+	}
+}
+
+// PublishPending publishes the staged events in order. Call it only after the
+// transaction committed.
+func (b *Broker) PublishPending(pending *Pending) {
+	for _, event := range pending.events {
+		b.Publish(event.userID, event.eventType, event.data)
+	}
+	pending.events = nil
+}
@@ -0,0 +1,221 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"task-management-api/models"
+	"time"
+)
+
+// CSVColumns are the columns written by WriteCSV. ReadCSV maps the same
+// names to CreateTaskRequest fields and ignores the read-only ones.
+var CSVColumns = []string{"id", "title", "description", "status", "priority", "due_date", "labels", "created_at", "updated_at"}
+
+// labelSeparator separates label names inside the labels column
+const labelSeparator = ";"
+
+// formulaPrefix is put before cells a spreadsheet app would run as a formula,
+// those starting with one of formulaTriggers. ReadCSV removes it again.
+const (
+	formulaPrefix   = "'"
+	formulaTriggers = "=+-@\t\r"
+)
+
+// importColumns are the columns ReadCSV maps to CreateTaskRequest fields
+var importColumns = map[string]bool{
+	"title":       true,
+	"description": true,
+	"status":      true,
+	"priority":    true,
+	"due_date":    true,
+	"labels":      true,
+}
+
+// ImportRow is one task read from an import file
+type ImportRow struct {
+	Line    int                      // Line number in the file, for error messages
+	Request models.CreateTaskRequest // Not yet validated
+	Labels  []string                 // Label names, resolved by the caller
+	Err     error                    // Set when a value could not be parsed
+}
+
+// WriteCSV writes tasks as CSV with a header row. Cells that could run as a
+// formula when the file is opened in a spreadsheet app are prefixed with '.
+func WriteCSV(w io.Writer, tasks []models.Task) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(CSVColumns); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		dueDate := ""
+		if task.DueDate != nil {
+			dueDate = task.DueDate.UTC().Format(time.RFC3339)
+		}
+
+		names := make([]string, len(task.Labels))
+		for i, label := range task.Labels {
+			names[i] = label.Name
+		}
+
+		record := []string{
+			strconv.FormatUint(uint64(task.ID), 10),
+			task.Title,
+			task.Description,
+			string(task.Status),
+			string(task.Priority),
+			dueDate,
+			strings.Join(names, labelSeparator),
+			task.CreatedAt.UTC().Format(time.RFC3339),
+			task.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		for i := range record {
+			record[i] = escapeFormula(record[i])
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// ReadCSV reads tasks from CSV. The header row names the columns; names are
+// matched case-insensitively and unknown columns are ignored. At most maxRows
+// data rows are read.
+func ReadCSV(r io.Reader, maxRows int) ([]ImportRow, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	in.TrimLeadingSpace = true
+
+	header, err := in.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	// Spreadsheet apps often start the file with a byte order mark
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if importColumns[name] {
+			columns[name] = i
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New(`CSV header must include a "title" column`)
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if isBlank(record) {
+			continue
+		}
+		if len(rows) == maxRows {
+			return nil, fmt.Errorf("too many rows, at most %d are allowed", maxRows)
+		}
+
+		line, _ := in.FieldPos(0)
+		rows = append(rows, parseRecord(line, record, columns))
+	}
+	return rows, nil
+}
+
+// parseRecord converts one CSV record to a create request
+func parseRecord(line int, record []string, columns map[string]int) ImportRow {
+	row := ImportRow{Line: line}
+
+	value := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return unescapeFormula(strings.TrimSpace(record[i]))
+	}
+
+	row.Request.Title = value("title")
+	row.Request.Description = value("description")
+	if priority := value("priority"); priority != "" {
+		p := models.TaskPriority(strings.ToLower(priority))
+		row.Request.Priority = &p
+	}
+	if status := value("status"); status != "" {
+		s := models.TaskStatus(strings.ToLower(status))
+		row.Request.Status = &s
+	}
+	if due := value("due_date"); due != "" {
+		dueDate, err := ParseDate(due)
+		if err != nil {
+			row.Err = err
+			return row
+		}
+		row.Request.DueDate = &dueDate
+	}
+	for _, name := range strings.Split(value("labels"), labelSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			row.Labels = append(row.Labels, name)
+		}
+	}
+	return row
+}
+
+// ParseDate parses an RFC 3339 timestamp or a plain YYYY-MM-DD date, which
+// is taken as midnight UTC
+func ParseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid due_date %q, expected RFC 3339 or YYYY-MM-DD", value)
+}
+
+// escapeFormula prefixes a cell starting like a formula, so spreadsheet apps
+// show it as text. A cell that would lose its own leading ' on import is
+// prefixed too.
+func escapeFormula(cell string) string {
+	if cell != "" && (strings.ContainsRune(formulaTriggers, rune(cell[0])) || isEscaped(cell)) {
+		return formulaPrefix + cell
+	}
+	return cell
+}
+
+// unescapeFormula removes the prefix escapeFormula added
+func unescapeFormula(cell string) string {
+	if isEscaped(cell) {
+		return cell[len(formulaPrefix):]
+	}
+	return cell
+}
+
+// isEscaped reports whether a cell starts with the prefix followed by a
+// formula trigger or another prefix
+func isEscaped(cell string) bool {
+	rest, ok := strings.CutPrefix(cell, formulaPrefix)
+	return ok && rest != "" && strings.ContainsRune(formulaTriggers+formulaPrefix, rune(rest[0]))
+}
+
+// isBlank reports whether every field of a record is empty
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
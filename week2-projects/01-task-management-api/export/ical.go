@@ -0,0 +1,117 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"task-management-api/models"
+)
+
+// icalTimeFormat is the UTC date-time format of RFC 5545
+const icalTimeFormat = "20060102T150405Z"
+
+// maxLineOctets is the line length after which iCalendar content is folded
+const maxLineOctets = 75
+
+// icalPriority maps task priorities to iCalendar priorities (1 highest, 9 lowest)
+var icalPriority = map[models.TaskPriority]int{
+	models.PriorityUrgent: 1,
+	models.PriorityHigh:   3,
+	models.PriorityMedium: 5,
+	models.PriorityLow:    9,
+}
+
+// WriteICS writes tasks with due dates as an iCalendar feed. Each task becomes
+// an event at its due date; tasks without a due date are skipped.
+func WriteICS(w io.Writer, calendarName string, tasks []models.Task) error {
+	out := &icalWriter{w: bufio.NewWriter(w)}
+
+	out.line("BEGIN:VCALENDAR")
+	out.line("VERSION:2.0")
+	out.line("PRODID:-//task-management-api//Tasks//EN")
+	out.line("CALSCALE:GREGORIAN")
+	out.line("METHOD:PUBLISH")
+	out.line("X-WR-CALNAME:" + escapeText(calendarName))
+
+	for _, task := range tasks {
+		if task.DueDate == nil {
+			continue
+		}
+
+		out.line("BEGIN:VEVENT")
+		out.line(fmt.Sprintf("UID:task-%d@task-management-api", task.ID))
+		out.line("DTSTAMP:" + task.UpdatedAt.UTC().Format(icalTimeFormat))
+		out.line("DTSTART:" + task.DueDate.UTC().Format(icalTimeFormat))
+		out.line("SUMMARY:" + escapeText(task.Title))
+		if task.Description != "" {
+			out.line("DESCRIPTION:" + escapeText(task.Description))
+		}
+		if priority, ok := icalPriority[task.Priority]; ok {
+			out.line(fmt.Sprintf("PRIORITY:%d", priority))
+		}
+		if task.Status == models.StatusCancelled {
+			out.line("STATUS:CANCELLED")
+		} else {
+			out.line("STATUS:CONFIRMED")
+		}
+		if len(task.Labels) > 0 {
+			names := make([]string, len(task.Labels))
+			for i, label := range task.Labels {
+				names[i] = escapeText(label.Name)
+			}
+			out.line("CATEGORIES:" + strings.Join(names, ","))
+		}
+		out.line("X-TASK-STATUS:" + string(task.Status))
+		out.line("LAST-MODIFIED:" + task.UpdatedAt.UTC().Format(icalTimeFormat))
+		out.line("END:VEVENT")
+	}
+
+	out.line("END:VCALENDAR")
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// icalWriter writes folded CRLF-terminated content lines, keeping the first error
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes one content line, folding it at 75 octets without splitting
+// UTF-8 characters
+func (iw *icalWriter) line(s string) {
+	if iw.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+
+	_, iw.err = iw.w.WriteString(b.String())
+}
+
+// escapeText escapes a TEXT property value
+func escapeText(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return replacer.Replace(s)
+}
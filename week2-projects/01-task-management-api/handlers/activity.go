@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-management-api/middleware"
+	"task-management-api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Default and maximum page sizes for paginated lists
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ActivityHandler handles requests for the task activity log
+type ActivityHandler struct{}
+
+// NewActivityHandler creates a new ActivityHandler
+func NewActivityHandler() *ActivityHandler {
+	return &ActivityHandler{}
+}
+
+// GetTaskHistory returns the change history of a single task
+// @Summary Get task history
+// @Description Get the activity log of a task, oldest first. Also available for deleted tasks.
+// @Tags activity
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Items per page (default 20, max 100)"
+// @Success 200 {object} models.ActivityPage
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/history [get]
+func (h *ActivityHandler) GetTaskHistory(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Get task ID from URL
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid task ID",
+		})
+		return
+	}
+
+	// Check ownership, including deleted tasks whose history is kept
+	var task models.Task
+	if err := requestDB(c).Unscoped().Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Task not found",
+		})
+		return
+	}
+
+	query := requestDB(c).Model(&models.Activity{}).Where("task_id = ?", task.ID)
+	respondWithActivityPage(c, query, "created_at asc, id asc")
+}
+
+// GetActivityFeed returns the activity across all of the user's tasks
+// @Summary Get activity feed
+// @Description Get the activity log of all tasks of the authenticated user, newest first
+// @Tags activity
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Items per page (default 20, max 100)"
+// @Success 200 {object} models.ActivityPage
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /activity [get]
+func (h *ActivityHandler) GetActivityFeed(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	query := requestDB(c).Model(&models.Activity{}).Where("user_id = ?", userID)
+	respondWithActivityPage(c, query, "created_at desc, id desc")
+}
+
+// respondWithActivityPage paginates an activity query and writes the page as JSON
+func respondWithActivityPage(c *gin.Context, query *gorm.DB, order string) {
+	var params models.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	page, pageSize := pageBounds(params)
+	result := models.ActivityPage{
+		Items:    []models.Activity{},
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	if err := query.Count(&result.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch activity",
+		})
+		return
+	}
+
+	if err := query.Order(order).Offset((page - 1) * pageSize).Limit(pageSize).Find(&result.Items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch activity",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// pageBounds applies defaults and limits to pagination parameters
+func pageBounds(params models.PaginationParams) (page, pageSize int) {
+	page = params.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize = params.PageSize
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/storage"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is the extra request body allowance for multipart headers
+const multipartOverhead = 1 << 20
+
+// AttachmentHandler handles requests for task file attachments
+type AttachmentHandler struct {
+	Config  config.AttachmentConfig
+	Storage storage.Storage
+}
+
+// NewAttachmentHandler creates a new AttachmentHandler
+func NewAttachmentHandler(cfg *config.Config, store storage.Storage) *AttachmentHandler {
+	return &AttachmentHandler{Config: cfg.Attachments, Storage: store}
+}
+
+// UploadAttachment uploads a file to a task
+// @Summary Upload an attachment
+// @Description Upload a file to a task as multipart form field "file". The type is detected from the content and must be allowed.
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param file formData file true "File to upload"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/attachments [post]
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	task, userID, ok := findTaskForUser(c)
+	if !ok {
+		return
+	}
+
+	// Cap the request body before the multipart form is parsed
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.Config.MaxSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.respondTooLarge(c)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": `A file is required in form field "file"`,
+		})
+		return
+	}
+	if fileHeader.Size > h.Config.MaxSize {
+		h.respondTooLarge(c)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read uploaded file",
+		})
+		return
+	}
+	defer file.Close()
+
+	// Detect the type from the content instead of trusting the client
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read uploaded file",
+		})
+		return
+	}
+	head = head[:n]
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !h.isAllowedType(contentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "File type not allowed: " + contentType,
+		})
+		return
+	}
+
+	key, err := storage.NewKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store file",
+		})
+		return
+	}
+
+	// Store at most one byte over the limit so oversized files are detected
+	content := io.LimitReader(io.MultiReader(bytes.NewReader(head), file), h.Config.MaxSize+1)
+	size, err := h.Storage.Save(key, content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store file",
+		})
+		return
+	}
+	if size > h.Config.MaxSize {
+		h.Storage.Delete(key)
+		h.respondTooLarge(c)
+		return
+	}
+
+	attachment := models.Attachment{
+		TaskID:      task.ID,
+		UserID:      userID,
+		FileName:    cleanFileName(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        size,
+		StorageKey:  key,
+	}
+
+	if err := requestDB(c).Create(&attachment).Error; err != nil {
+		h.Storage.Delete(key)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create attachment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachments lists the attachments of a task
+// @Summary Get attachments
+// @Description Get the attachments of a task
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {array} models.Attachment
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/attachments [get]
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	task, _, ok := findTaskForUser(c)
+	if !ok {
+		return
+	}
+
+	var attachments []models.Attachment
+	if err := requestDB(c).Where("task_id = ?", task.ID).Order("created_at asc").Find(&attachments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch attachments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment streams an attachment's file
+// @Summary Download an attachment
+// @Description Download the file of an attachment
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/attachments/{attachment_id}/download [get]
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachment, ok := findTaskAttachment(c)
+	if !ok {
+		return
+	}
+
+	reader, err := h.Storage.Open(attachment.StorageKey)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": "Failed to open attachment file",
+		})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, reader, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment deletes an attachment
+// @Summary Delete an attachment
+// @Description Delete an attachment of a task
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/attachments/{attachment_id} [delete]
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	attachment, ok := findTaskAttachment(c)
+	if !ok {
+		return
+	}
+
+	// Soft delete like tasks; the file is kept so the attachment can be restored
+	if err := requestDB(c).Delete(&attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete attachment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// isAllowedType reports whether a detected MIME type may be uploaded
+func (h *AttachmentHandler) isAllowedType(contentType string) bool {
+	for _, allowed := range h.Config.AllowedTypes {
+		if allowed == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// respondTooLarge writes the response for files over the size limit
+func (h *AttachmentHandler) respondTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error": "File exceeds the maximum size of " + strconv.FormatInt(h.Config.MaxSize, 10) + " bytes",
+	})
+}
+
+// findTaskAttachment loads the attachment named in the URL on a task of the
+// authenticated user
+func findTaskAttachment(c *gin.Context) (models.Attachment, bool) {
+	var attachment models.Attachment
+
+	task, _, ok := findTaskForUser(c)
+	if !ok {
+		return attachment, false
+	}
+
+	// Get attachment ID from URL
+	attachmentID, err := strconv.ParseUint(c.Param("attachment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid attachment ID",
+		})
+		return attachment, false
+	}
+
+	if err := requestDB(c).Where("id = ? AND task_id = ?", attachmentID, task.ID).First(&attachment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Attachment not found",
+		})
+		return attachment, false
+	}
+
+	return attachment, true
+}
+
+// maxFileNameLength is the most bytes of a file name that are kept
+const maxFileNameLength = 255
+
+// cleanFileName strips any path, control characters (including the bidi ones
+// that can disguise an extension) and invalid UTF-8 from a client-supplied
+// file name, and shortens long names from the front so that the extension is
+// kept
+func cleanFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(name, ""))
+
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == ".." || name == "/" || name == "" {
+		return "file"
+	}
+
+	// Cut at a rune boundary
+	if len(name) > maxFileNameLength {
+		start := len(name) - maxFileNameLength
+		for start < len(name) && !utf8.RuneStart(name[start]) {
+			start++
+		}
+		name = name[start:]
+	}
+	return name
+}
@@ -1,33 +1,55 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"task-management-api/account"
 	"task-management-api/config"
-	"task-management-api/database"
+	"task-management-api/mailer"
+	"task-management-api/middleware"
 	"task-management-api/models"
 	"task-management-api/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// mailTimeout bounds how long sending one account email may take
+const mailTimeout = 30 * time.Second
+
+// dummyPasswordHash is compared against when a login names an unknown email,
+// so that the response time does not reveal which emails have accounts
+var dummyPasswordHash, _ = utils.HashPassword("not-a-real-password-0")
+
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
 	Config *config.Config
+	Mailer mailer.Sender
+	Logins *account.LoginLimiter
 }
 
-// NewAuthHandler creates a new AuthHandler
-func NewAuthHandler(cfg *config.Config) *AuthHandler {
-	return &AuthHandler{Config: cfg}
+// NewAuthHandler creates a new AuthHandler that sends account emails through sender
+func NewAuthHandler(cfg *config.Config, sender mailer.Sender) *AuthHandler {
+	return &AuthHandler{
+		Config: cfg,
+		Mailer: sender,
+		Logins: account.NewLoginLimiter(cfg.Auth.MaxFailedLogins, cfg.Auth.MaxFailedLoginsPerIP, cfg.Auth.LockoutDuration),
+	}
 }
 
 // Register handles user registration
 // @Summary Register a new user
-// @Description Create a new user account
+// @Description Create a new user account and email a verification link. Unless verification is disabled, no tokens are returned until the address is verified.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body models.UserRegisterRequest true "Registration details"
-// @Success 201 {object} map[string]interface{}
+// @Success 201 {object} models.AuthResponse
 // @Failure 400 {object} map[string]string
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -41,9 +63,17 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// Check the password policy
+	if err := utils.ValidatePassword(req.Password, req.Username, req.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	// Check if user already exists
 	var existingUser models.User
-	if err := database.DB.Where("email = ? OR username = ?", req.Email, req.Username).First(&existingUser).Error; err == nil {
+	if err := requestDB(c).Where("email = ? OR username = ?", req.Email, req.Username).First(&existingUser).Error; err == nil {
 		c.JSON(http.StatusConflict, gin.H{
 			"error": "User with this email or username already exists",
 		})
@@ -66,21 +96,32 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Password: hashedPassword,
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	var verifyToken string
+	err = requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		verifyToken, err = account.IssueToken(tx, user.ID, models.TokenVerifyEmail, h.Config.Auth.VerificationExpiration, time.Now())
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create user",
 		})
 		return
 	}
+	h.sendMail(account.VerificationMessage(&user, verifyToken, h.Config.Mail.AppURL, h.Config.Auth.VerificationExpiration))
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(
-		user.ID,
-		user.Username,
-		user.Email,
-		h.Config.JWT.Secret,
-		h.Config.JWT.Expiration,
-	)
+	if h.Config.Auth.RequireVerification {
+		c.JSON(http.StatusCreated, models.AuthResponse{
+			Message: "User registered successfully, check your email to verify your address before logging in",
+			User:    user.ToResponse(),
+		})
+		return
+	}
+
+	// Start a session and generate tokens
+	tokens, err := h.startSession(c, &user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate authentication token",
@@ -89,23 +130,27 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Return success response
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully",
-		"user":    user.ToResponse(),
-		"token":   token,
+	c.JSON(http.StatusCreated, models.AuthResponse{
+		Message:      "User registered successfully",
+		User:         user.ToResponse(),
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 	})
 }
 
 // Login handles user login
 // @Summary Login user
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return an access token and a refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body models.UserLoginRequest true "Login credentials"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} models.AuthResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.UserLoginRequest
@@ -118,31 +163,50 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Find user by email
+	// Locked logins refuse even the right password. Unknown emails are
+	// counted and locked like real ones, so both get the same responses.
+	now := time.Now()
+	ip := c.ClientIP()
+	if wait := h.Logins.LockedFor(req.Email, ip, now); wait > 0 {
+		respondLocked(c, wait)
+		return
+	}
+
+	// Find user by email and check the password
 	var user models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+	err := requestDB(c).Where("email = ?", req.Email).First(&user).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.CheckPassword(dummyPasswordHash, req.Password)
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to login",
+		})
+		return
+	default:
+		err = utils.CheckPassword(user.Password, req.Password)
+	}
+	if err != nil {
+		if wait := h.Logins.RecordFailure(req.Email, ip, now); wait > 0 {
+			respondLocked(c, wait)
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid email or password",
 		})
 		return
 	}
+	h.Logins.RecordSuccess(req.Email, ip)
 
-	// Check password
-	if err := utils.CheckPassword(user.Password, req.Password); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid email or password",
+	if h.Config.Auth.RequireVerification && !user.IsVerified() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Email address not verified, check your email or request a new verification link",
 		})
 		return
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(
-		user.ID,
-		user.Username,
-		user.Email,
-		h.Config.JWT.Secret,
-		h.Config.JWT.Expiration,
-	)
+	// Start a session and generate tokens
+	tokens, err := h.startSession(c, &user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate authentication token",
@@ -151,10 +215,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Return success response
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user":    user.ToResponse(),
-		"token":   token,
+	c.JSON(http.StatusOK, models.AuthResponse{
+		Message:      "Login successful",
+		User:         user.ToResponse(),
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 	})
 }
 
@@ -179,7 +245,7 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 	// Fetch user from database
 	var user models.User
-	if err := database.DB.First(&user, userID).Error; err != nil {
+	if err := requestDB(c).First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "User not found",
 		})
@@ -188,3 +254,448 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user.ToResponse())
 }
+
+// Refresh exchanges a refresh token for a new access token
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token. The refresh token is rotated: the response contains a new one and the old one stops working.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshTokenRequest
+
+	// Bind and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	tokenHash := utils.HashToken(req.RefreshToken)
+
+	// Find the session owning this refresh token
+	var session models.Session
+	if err := requestDB(c).Where("refresh_token_hash = ?", tokenHash).First(&session).Error; err != nil {
+		// A rotated-out token being presented again means it was stolen or
+		// leaked; revoke the whole session so neither party can continue
+		result := requestDB(c).Model(&models.Session{}).
+			Where("previous_token_hash = ? AND revoked_at IS NULL", tokenHash).
+			Update("revoked_at", time.Now())
+		if result.Error == nil && result.RowsAffected > 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Refresh token reuse detected, session revoked",
+			})
+			return
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid refresh token",
+		})
+		return
+	}
+
+	if !session.IsActive() {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Session expired or revoked",
+		})
+		return
+	}
+
+	var user models.User
+	if err := requestDB(c).First(&user, session.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid refresh token",
+		})
+		return
+	}
+
+	// Rotate the refresh token. The hash condition makes concurrent refreshes
+	// with the same token fail instead of both succeeding.
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate authentication token",
+		})
+		return
+	}
+
+	now := time.Now()
+	result := requestDB(c).Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, tokenHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  utils.HashToken(refreshToken),
+			"previous_token_hash": tokenHash,
+			"expires_at":          now.Add(h.Config.JWT.RefreshExpiration),
+			"last_used_at":        now,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to refresh session",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid refresh token",
+		})
+		return
+	}
+
+	token, err := utils.GenerateToken(
+		user.ID,
+		session.ID,
+		user.Username,
+		user.Email,
+		h.Config.JWT.Secret,
+		h.Config.JWT.Expiration,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate authentication token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.Config.JWT.Expiration.Seconds()),
+	})
+}
+
+// Logout revokes the current session
+// @Summary Logout
+// @Description Revoke the session of the current access token. Its access and refresh tokens stop working immediately.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	// Get user and session ID from context (set by auth middleware)
+	userID, ok := middleware.GetUserID(c)
+	sessionID, hasSession := middleware.GetSessionID(c)
+	if !ok || !hasSession {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	if err := requestDB(c).Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to logout",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// LogoutAll revokes every session of the current user
+// @Summary Logout everywhere
+// @Description Revoke all sessions of the authenticated user on every device
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.LogoutAllResponse
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	result := requestDB(c).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to logout",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LogoutAllResponse{
+		Message:         "Logged out from all sessions",
+		RevokedSessions: result.RowsAffected,
+	})
+}
+
+// VerifyEmail verifies the email address of an account
+// @Summary Verify email address
+// @Description Verify an email address with the token from the verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} map[string]string
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+
+	// Bind and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	var user *models.User
+	err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = account.VerifyEmail(tx, req.Token, time.Now())
+		return err
+	})
+	if errors.Is(err, account.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid or expired verification token",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuthResponse{
+		Message: "Email verified successfully",
+		User:    user.ToResponse(),
+	})
+}
+
+// ResendVerification sends a new verification email
+// @Summary Resend verification email
+// @Description Send a new verification link to an unverified account. The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.EmailRequest true "Account email"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req models.EmailRequest
+
+	// Bind and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	err := requestDB(c).Where("email = ?", req.Email).First(&user).Error
+	if err == nil && !user.IsVerified() {
+		ttl := h.Config.Auth.VerificationExpiration
+		if err := h.issueAndSend(requestDB(c), &user, models.TokenVerifyEmail, ttl, account.VerificationMessage); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to send verification email",
+			})
+			return
+		}
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send verification email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the account exists and is not verified yet, a verification email is on its way",
+	})
+}
+
+// ForgotPassword sends a password reset email
+// @Summary Request password reset
+// @Description Email a password reset link. The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.EmailRequest true "Account email"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.EmailRequest
+
+	// Bind and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	err := requestDB(c).Where("email = ?", req.Email).First(&user).Error
+	if err == nil {
+		ttl := h.Config.Auth.ResetExpiration
+		if err := h.issueAndSend(requestDB(c), &user, models.TokenResetPassword, ttl, account.PasswordResetMessage); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to send password reset email",
+			})
+			return
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send password reset email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the account exists, a password reset email is on its way",
+	})
+}
+
+// ResetPassword sets a new password with a reset token
+// @Summary Reset password
+// @Description Set a new password with the token from the password reset email. All sessions of the account are revoked and a lockout is lifted.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+
+	// Bind and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	var user *models.User
+	err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = account.ResetPassword(tx, req.Token, req.Password, time.Now())
+		return err
+	})
+	switch {
+	case errors.Is(err, account.ErrInvalidToken):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid or expired reset token",
+		})
+		return
+	case errors.Is(err, account.ErrWeakPassword):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to reset password",
+		})
+		return
+	}
+	h.Logins.Forget(user.Email)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully, log in with your new password",
+	})
+}
+
+// issueAndSend issues an account token and emails it to the user, unless one
+// was sent moments ago
+func (h *AuthHandler) issueAndSend(db *gorm.DB, user *models.User, purpose models.AccountTokenPurpose, ttl time.Duration,
+	message func(*models.User, string, string, time.Duration) mailer.Message) error {
+	now := time.Now()
+	recent, err := account.RecentlyIssued(db, user.ID, purpose, now)
+	if err != nil || recent {
+		return err
+	}
+
+	token, err := account.IssueToken(db, user.ID, purpose, ttl, now)
+	if err != nil {
+		return err
+	}
+	h.sendMail(message(user, token, h.Config.Mail.AppURL, ttl))
+	return nil
+}
+
+// sendMail sends an account email in the background, so that responses take
+// the same time whether or not an email is sent. Failures are logged; users
+// can ask for the email again.
+func (h *AuthHandler) sendMail(msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+		if err := h.Mailer.Send(ctx, msg); err != nil {
+			slog.Error("failed to send mail", "to", msg.To, "subject", msg.Subject, "error", err)
+		}
+	}()
+}
+
+// respondLocked reports locked out logins and when to try again
+func respondLocked(c *gin.Context, wait time.Duration) {
+	minutes := int(math.Ceil(wait.Minutes()))
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": "Too many failed logins, try again in " + strconv.Itoa(minutes) + " minute(s) or reset your password",
+	})
+}
+
+// startSession creates a new session for the user and issues its tokens
+func (h *AuthHandler) startSession(c *gin.Context, user *models.User) (*models.TokenResponse, error) {
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		ExpiresAt:        now.Add(h.Config.JWT.RefreshExpiration),
+		LastUsedAt:       now,
+		UserAgent:        c.Request.UserAgent(),
+		IPAddress:        c.ClientIP(),
+	}
+	if err := requestDB(c).Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateToken(
+		user.ID,
+		session.ID,
+		user.Username,
+		user.Email,
+		h.Config.JWT.Secret,
+		h.Config.JWT.Expiration,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.Config.JWT.Expiration.Seconds()),
+	}, nil
+}
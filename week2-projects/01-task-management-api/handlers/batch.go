@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"task-management-api/events"
+	"task-management-api/middleware"
+	"task-management-api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// errBatchAborted rolls back an atomic batch after an operation failed
+var errBatchAborted = errors.New("batch aborted")
+
+// BatchTasks runs several task operations in one request
+// @Summary Run task operations in bulk
+// @Description Create, update and delete tasks in one request. In atomic mode (default) all operations are applied in one transaction and any failure rolls back the whole batch; in best_effort mode each operation is applied on its own. The response has one result per operation.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param request body models.BatchRequest true "Operations"
+// @Success 200 {object} models.BatchResponse
+// @Success 207 {object} models.BatchResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/batch [post]
+func (h *TaskHandler) BatchTasks(c *gin.Context) {
+	var req models.BatchRequest
+
+	// Bind and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = models.BatchAtomic
+	}
+
+	results := make([]models.BatchResult, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = models.BatchResult{Index: i, Op: op.Op, ID: op.ID}
+	}
+
+	if mode == models.BatchBestEffort {
+		// Each operation runs in its own transaction
+		for i := range req.Operations {
+			db, pending := events.Track(requestDB(c))
+			if runBatchOperation(db, userID, &req.Operations[i], &results[i]) {
+				h.Events.PublishPending(pending)
+			}
+		}
+	} else {
+		// Operations run as savepoints of one transaction; the first failure
+		// rolls back everything
+		failed := -1
+		db, pending := events.Track(requestDB(c))
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for i := range req.Operations {
+				if !runBatchOperation(tx, userID, &req.Operations[i], &results[i]) {
+					failed = i
+					return errBatchAborted
+				}
+			}
+			return nil
+		})
+		if err != nil && failed < 0 {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to run batch",
+			})
+			return
+		}
+		if failed >= 0 {
+			markBatchAborted(results, failed)
+		} else {
+			h.Events.PublishPending(pending)
+		}
+	}
+
+	response := models.BatchResponse{Mode: mode, Results: results}
+	for _, result := range results {
+		if result.Status == models.BatchItemSucceeded {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	// Multi-Status tells clients to look at the individual results
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, response)
+}
+
+// runBatchOperation applies one batch operation with the same validation and
+// ownership checks as the single-task endpoints, and fills in its result.
+// It reports whether the operation succeeded.
+func runBatchOperation(db *gorm.DB, userID uint, op *models.BatchOperation, result *models.BatchResult) bool {
+	var (
+		task *models.Task
+		err  error
+		code int
+	)
+
+	switch op.Op {
+	case models.BatchCreate:
+		var req models.CreateTaskRequest
+		if err = decodeBatchData(op.Data, &req); err == nil {
+			task, err = createTask(db, userID, &req)
+		}
+		code = http.StatusCreated
+	case models.BatchUpdate:
+		var req models.UpdateTaskRequest
+		if op.ID == 0 {
+			err = &taskError{status: http.StatusBadRequest, message: "Task ID is required"}
+		} else if err = decodeBatchData(op.Data, &req); err == nil {
+			task, err = updateTask(db, userID, op.ID, &req)
+		}
+		code = http.StatusOK
+	case models.BatchDelete:
+		if op.ID == 0 {
+			err = &taskError{status: http.StatusBadRequest, message: "Task ID is required"}
+		} else {
+			err = deleteTask(db, userID, op.ID)
+		}
+		code = http.StatusOK
+	}
+
+	if err != nil {
+		result.Status = models.BatchItemFailed
+		var taskErr *taskError
+		if errors.As(err, &taskErr) {
+			result.Code = taskErr.status
+			result.Error = taskErr.message
+		} else {
+			result.Code = http.StatusInternalServerError
+			result.Error = "Failed to " + string(op.Op) + " task"
+		}
+		return false
+	}
+
+	result.Status = models.BatchItemSucceeded
+	result.Code = code
+	if task != nil {
+		result.ID = task.ID
+		result.Task = task
+	}
+	return true
+}
+
+// decodeBatchData decodes and validates the data of a create or update
+// operation using the request's binding rules
+func decodeBatchData(data json.RawMessage, req interface{}) error {
+	if len(data) == 0 || string(data) == "null" {
+		return &taskError{status: http.StatusBadRequest, message: "Operation data is required"}
+	}
+	if err := json.Unmarshal(data, req); err != nil {
+		return &taskError{status: http.StatusBadRequest, message: "Invalid request payload: " + err.Error()}
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return &taskError{status: http.StatusBadRequest, message: "Invalid request payload: " + err.Error()}
+	}
+	return nil
+}
+
+// markBatchAborted updates the results of an atomic batch whose operation at
+// index failed: earlier operations were rolled back, later ones never ran
+func markBatchAborted(results []models.BatchResult, failed int) {
+	for i := range results {
+		switch {
+		case i < failed:
+			results[i].Status = models.BatchItemRolledBack
+			results[i].Code = http.StatusFailedDependency
+			results[i].Error = "Rolled back because operation " + strconv.Itoa(failed) + " failed"
+			results[i].Task = nil
+			if results[i].Op == models.BatchCreate {
+				results[i].ID = 0
+			}
+		case i > failed:
+			results[i].Status = models.BatchItemSkipped
+			results[i].Code = http.StatusFailedDependency
+			results[i].Error = "Skipped because operation " + strconv.Itoa(failed) + " failed"
+		}
+	}
+}
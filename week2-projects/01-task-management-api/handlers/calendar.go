@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"task-management-api/export"
+	"task-management-api/middleware"
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// calendarFeedPath is the URL path of the iCalendar feed, followed by "<token>.ics"
+const calendarFeedPath = "/api/v1/calendar/"
+
+// CalendarHandler handles the iCalendar feed of tasks
+type CalendarHandler struct{}
+
+// NewCalendarHandler creates a new CalendarHandler
+func NewCalendarHandler() *CalendarHandler {
+	return &CalendarHandler{}
+}
+
+// CreateFeedToken creates the user's calendar feed URL
+// @Summary Create calendar feed URL
+// @Description Create a secret iCalendar feed URL for subscribing from calendar apps. Any previous URL stops working. The token is only returned in this response.
+// @Tags calendar
+// @Produce json
+// @Success 201 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /calendar/token [post]
+func (h *CalendarHandler) CreateFeedToken(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create calendar feed",
+		})
+		return
+	}
+
+	// Replace any existing token
+	err = requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.CalendarToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CalendarToken{UserID: userID, TokenHash: utils.HashToken(token)}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create calendar feed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token": token,
+		"url":   feedURL(c, token),
+	})
+}
+
+// DeleteFeedToken disables the user's calendar feed URL
+// @Summary Delete calendar feed URL
+// @Description Disable the iCalendar feed URL of the authenticated user
+// @Tags calendar
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /calendar/token [delete]
+func (h *CalendarHandler) DeleteFeedToken(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	if err := requestDB(c).Where("user_id = ?", userID).Delete(&models.CalendarToken{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete calendar feed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Calendar feed disabled",
+	})
+}
+
+// GetFeed serves the iCalendar feed for a feed token
+// @Summary Get calendar feed
+// @Description iCalendar feed of the user's tasks that have a due date. Authenticated by the secret token in the URL.
+// @Tags calendar
+// @Produce text/calendar
+// @Param feed path string true "Feed token followed by .ics"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Router /calendar/{feed} [get]
+func (h *CalendarHandler) GetFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("feed"), ".ics")
+
+	// Look up the token; unknown and revoked tokens look the same
+	var feedToken models.CalendarToken
+	if token == "" || requestDB(c).Where("token_hash = ?", utils.HashToken(token)).First(&feedToken).Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Calendar feed not found",
+		})
+		return
+	}
+
+	var user models.User
+	if err := requestDB(c).First(&user, feedToken.UserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Calendar feed not found",
+		})
+		return
+	}
+
+	var tasks []models.Task
+	if err := requestDB(c).Preload("Labels").
+		Where("user_id = ? AND due_date IS NOT NULL", user.ID).
+		Order("due_date asc").
+		Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch tasks",
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="tasks.ics"`)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+	if err := export.WriteICS(c.Writer, "Tasks - "+user.Username, tasks); err != nil {
+		c.Error(err)
+	}
+}
+
+// feedURL builds the absolute feed URL for a token from the request's host
+func feedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + calendarFeedPath + token + ".ics"
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-management-api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CommentHandler handles requests for task comments
+type CommentHandler struct{}
+
+// NewCommentHandler creates a new CommentHandler
+func NewCommentHandler() *CommentHandler {
+	return &CommentHandler{}
+}
+
+// CreateComment adds a comment or a reply to a task
+// @Summary Create a comment
+// @Description Comment on a task, or reply to an existing comment with parent_id
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param request body models.CreateCommentRequest true "Comment"
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/comments [post]
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	task, userID, ok := findTaskForUser(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request
+	var req models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	// Replies must belong to the same task
+	if req.ParentID != nil {
+		var parent models.Comment
+		if err := requestDB(c).Where("id = ? AND task_id = ?", *req.ParentID, task.ID).First(&parent).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Parent comment not found",
+			})
+			return
+		}
+	}
+
+	comment := models.Comment{
+		TaskID:   task.ID,
+		UserID:   userID,
+		ParentID: req.ParentID,
+		Body:     req.Body,
+	}
+
+	if err := requestDB(c).Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// GetComments returns the comment threads of a task
+// @Summary Get comments
+// @Description Get the comments of a task as threads, oldest first
+// @Tags comments
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {array} models.Comment
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/comments [get]
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	task, _, ok := findTaskForUser(c)
+	if !ok {
+		return
+	}
+
+	var comments []*models.Comment
+	if err := requestDB(c).Where("task_id = ?", task.ID).Order("created_at asc, id asc").Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch comments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildThreads(comments))
+}
+
+// UpdateComment edits a comment
+// @Summary Edit a comment
+// @Description Edit a comment (author only)
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Param request body models.UpdateCommentRequest true "New comment body"
+// @Success 200 {object} models.Comment
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/comments/{comment_id} [put]
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	comment, ok := findAuthoredComment(c)
+	if !ok {
+		return
+	}
+
+	// Bind update request
+	var req models.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	comment.Body = req.Body
+	comment.Edited = true
+
+	if err := requestDB(c).Save(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment deletes a comment and its replies
+// @Summary Delete a comment
+// @Description Delete a comment and all replies to it (author only)
+// @Tags comments
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/comments/{comment_id} [delete]
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	comment, ok := findAuthoredComment(c)
+	if !ok {
+		return
+	}
+
+	err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		// Walk the thread breadth-first so nested replies are removed too
+		ids := []uint{comment.ID}
+		for len(ids) > 0 {
+			if err := tx.Where("id IN ?", ids).Delete(&models.Comment{}).Error; err != nil {
+				return err
+			}
+
+			var replyIDs []uint
+			if err := tx.Model(&models.Comment{}).Where("parent_id IN ?", ids).Pluck("id", &replyIDs).Error; err != nil {
+				return err
+			}
+			ids = replyIDs
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment deleted successfully",
+	})
+}
+
+// findAuthoredComment loads the comment named in the URL on a task of the
+// authenticated user and checks that the user wrote it
+func findAuthoredComment(c *gin.Context) (models.Comment, bool) {
+	var comment models.Comment
+
+	task, userID, ok := findTaskForUser(c)
+	if !ok {
+		return comment, false
+	}
+
+	// Get comment ID from URL
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return comment, false
+	}
+
+	if err := requestDB(c).Where("id = ? AND task_id = ?", commentID, task.ID).First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Comment not found",
+		})
+		return comment, false
+	}
+
+	if comment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only the author can modify this comment",
+		})
+		return comment, false
+	}
+
+	return comment, true
+}
+
+// buildThreads nests replies under their parents. The input must be ordered
+// oldest first; replies keep that order within their thread.
+func buildThreads(comments []*models.Comment) []*models.Comment {
+	byID := make(map[uint]*models.Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+	}
+
+	threads := []*models.Comment{}
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			if parent, ok := byID[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, comment)
+				continue
+			}
+		}
+		threads = append(threads, comment)
+	}
+	return threads
+}
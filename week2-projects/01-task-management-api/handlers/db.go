@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"task-management-api/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestDB returns the database bound to the request's context, so query
+// logs carry the request ID and queries stop when the client goes away
+func requestDB(c *gin.Context) *gorm.DB {
+	return database.DB.WithContext(c.Request.Context())
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"task-management-api/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocsHandler serves the OpenAPI document and the docs page rendered from it
+type DocsHandler struct {
+	spec []byte
+	page []byte
+}
+
+// NewDocsHandler creates a new DocsHandler. The document describes the code,
+// so it is built and rendered once at startup.
+func NewDocsHandler() (*DocsHandler, error) {
+	doc := openapi.Build()
+
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	page, err := openapi.RenderDocs(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &DocsHandler{spec: spec, page: page}, nil
+}
+
+// GetSpec returns the OpenAPI document
+// @Summary Get OpenAPI document
+// @Description Get the OpenAPI 3 document describing the auth and task endpoints
+// @Tags docs
+// @Produce json
+// @Success 200 {object} openapi.Document
+// @Router /openapi.json [get]
+func (h *DocsHandler) GetSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// GetDocs returns the API docs page
+// @Summary Get API docs
+// @Description Get an HTML page documenting the auth and task endpoints
+// @Tags docs
+// @Produce html
+// @Success 200 {string} string
+// @Router /docs [get]
+func (h *DocsHandler) GetDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", h.page)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"task-management-api/config"
+	"task-management-api/events"
+	"task-management-api/middleware"
+	"task-management-api/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamRetry is the reconnect delay suggested to clients, in milliseconds
+const streamRetry = 3000
+
+// EventHandler streams real-time task events
+type EventHandler struct {
+	Broker           *events.Broker
+	Heartbeat        time.Duration
+	JWTSecret        string
+	TicketExpiration time.Duration
+}
+
+// NewEventHandler creates a new EventHandler
+func NewEventHandler(cfg *config.Config, broker *events.Broker) *EventHandler {
+	return &EventHandler{
+		Broker:           broker,
+		Heartbeat:        cfg.Events.Heartbeat,
+		JWTSecret:        cfg.JWT.Secret,
+		TicketExpiration: cfg.Events.TicketExpiration,
+	}
+}
+
+// CreateTicket issues a short-lived ticket for opening the event stream
+// @Summary Create an event stream ticket
+// @Description Returns a ticket to open the event stream with the ticket query parameter, for clients like the browser EventSource that cannot send the Authorization header. The ticket can be used to connect until it expires; the stream ends when the access token that requested it expires. Logging out revokes it.
+// @Tags events
+// @Produce json
+// @Success 201 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /events/ticket [post]
+func (h *EventHandler) CreateTicket(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+	sessionID, _ := middleware.GetSessionID(c)
+
+	streamUntil, ok := middleware.GetTokenExpiry(c)
+	if !ok {
+		streamUntil = time.Now().Add(h.TicketExpiration)
+	}
+
+	ticket, err := utils.GenerateTicket(userID, sessionID, h.JWTSecret, h.TicketExpiration, streamUntil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create ticket",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"ticket":     ticket,
+		"expires_in": int(h.TicketExpiration.Seconds()),
+		"url":        "/api/v1/events?ticket=" + url.QueryEscape(ticket),
+	})
+}
+
+// StreamEvents streams task changes as Server-Sent Events
+// @Summary Stream task events
+// @Description Server-Sent Events stream of task.created, task.updated and task.deleted events for the authenticated user. Authenticate with the Authorization header or, from a browser EventSource, with a ticket from POST /events/ticket. Reconnect with the Last-Event-ID header (or last_event_id query parameter) to receive missed events. A "reset" event means missed events are no longer available and the client should reload its tasks. The stream ends with a "token_expired" event when the access token expires.
+// @Tags events
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Param last_event_id query int false "ID of the last event received"
+// @Param ticket query string false "Ticket from POST /events/ticket, instead of the Authorization header"
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /events [get]
+func (h *EventHandler) StreamEvents(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Resume point, from the header or the query parameter
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID uint64
+	resume := lastEventID != ""
+	if resume {
+		var err error
+		lastID, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid Last-Event-ID",
+			})
+			return
+		}
+	}
+
+	sub, missed, complete := h.Broker.Subscribe(userID, lastID, resume)
+	defer h.Broker.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	if complete {
+		for _, event := range missed {
+			writeEvent(w, event.ID, event.Type, event.Data)
+		}
+		// Move the client's resume point past events of other users
+		fmt.Fprintf(w, "id: %d\n\n", sub.StartID)
+	} else {
+		writeEvent(w, sub.StartID, "reset", []byte(`{"reason":"missed events are no longer available"}`))
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(h.Heartbeat)
+	defer heartbeat.Stop()
+
+	// End the stream when the access token expires, or the one that
+	// requested the ticket, so revoked or logged-out users stop receiving
+	// events
+	var expired <-chan time.Time
+	if expiresAt, ok := middleware.GetTokenExpiry(c); ok {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case event, open := <-sub.Events():
+			if !open {
+				// The client fell behind; it resumes from the buffer on reconnect
+				return
+			}
+			writeEvent(w, event.ID, event.Type, event.Data)
+			w.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		case <-expired:
+			writeEvent(w, 0, "token_expired", []byte("{}"))
+			w.Flush()
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes one Server-Sent Event. An ID of 0 leaves the client's
+// resume point unchanged.
+func writeEvent(w io.Writer, id uint64, eventType string, data []byte) {
+	if id > 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, data)
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"task-management-api/events"
+	"task-management-api/export"
+	"task-management-api/middleware"
+	"task-management-api/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// Limits for import files
+const (
+	maxImportSize = 5 << 20
+	maxImportRows = 1000
+)
+
+// ExportTasks downloads the user's tasks as CSV or JSON
+// @Summary Export tasks
+// @Description Download the authenticated user's tasks as CSV or JSON. Accepts the same filters as GET /tasks.
+// @Tags tasks
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "File format (default csv)" Enums(csv, json)
+// @Param status query string false "Filter by status" Enums(todo, in_progress, completed, cancelled)
+// @Param priority query string false "Filter by priority" Enums(low, medium, high, urgent)
+// @Param labels query string false "Comma-separated label IDs"
+// @Param label_match query string false "Label matching" Enums(any, all)
+// @Param sort_by query string false "Sort by field" Enums(created_at, due_date, priority)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/export [get]
+func (h *TaskHandler) ExportTasks(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Parse format and filter parameters
+	var params models.TaskExportParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	query, err := filterTasks(requestDB(c), userID, params.TaskFilterParams)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	var tasks []models.Task
+	if err := query.Preload("Labels").Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch tasks",
+		})
+		return
+	}
+
+	format := params.Format
+	if format == "" {
+		format = "csv"
+	}
+	fileName := "tasks-" + time.Now().Format("20060102") + "." + format
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+
+	if format == "json" {
+		if tasks == nil {
+			tasks = []models.Task{}
+		}
+		c.JSON(http.StatusOK, tasks)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := export.WriteCSV(c.Writer, tasks); err != nil {
+		c.Error(err)
+	}
+}
+
+// ImportTasks creates tasks from a CSV or JSON file
+// @Summary Import tasks
+// @Description Create tasks from a CSV file (header row with title, description, status, priority, due_date, labels) or a JSON array of task objects. Send the file as multipart form field "file", or as the request body with Content-Type text/csv or application/json. Every row is validated like POST /tasks; if any row is invalid nothing is imported.
+// @Tags tasks
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Accept json
+// @Produce json
+// @Param file formData file false "CSV or JSON file"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/import [post]
+func (h *TaskHandler) ImportTasks(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+multipartOverhead)
+
+	// Read rows from the uploaded file or the request body
+	rows, err := readImportRows(c)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		var taskErr *taskError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("Import file exceeds the maximum size of %d bytes", maxImportSize),
+			})
+		case errors.As(err, &taskErr):
+			c.JSON(taskErr.status, gin.H{
+				"error": taskErr.message,
+			})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid import file: " + err.Error(),
+			})
+		}
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Import file contains no tasks",
+		})
+		return
+	}
+
+	// Validate every row before creating anything
+	rowErrors, err := validateImportRows(requestDB(c), userID, rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to import tasks",
+		})
+		return
+	}
+	if len(rowErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Import file has invalid rows; no tasks were imported",
+			"errors": rowErrors,
+		})
+		return
+	}
+
+	// Create all tasks in one transaction
+	tasks := make([]*models.Task, 0, len(rows))
+	failedRow := 0
+	db, pending := events.Track(requestDB(c))
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i := range rows {
+			task, err := createTask(tx, userID, &rows[i].Request)
+			if err != nil {
+				failedRow = i + 1
+				return err
+			}
+			tasks = append(tasks, task)
+		}
+		return nil
+	})
+	if err != nil {
+		var taskErr *taskError
+		if errors.As(err, &taskErr) {
+			c.JSON(taskErr.status, gin.H{
+				"error":  "Import file has invalid rows; no tasks were imported",
+				"errors": []models.ImportRowError{{Row: failedRow, Line: rows[failedRow-1].Line, Error: taskErr.message}},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to import tasks",
+		})
+		return
+	}
+
+	h.Events.PublishPending(pending)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"imported": len(tasks),
+		"tasks":    tasks,
+	})
+}
+
+// readImportRows reads the import rows from a multipart file upload or the
+// raw request body, choosing the parser by file extension or content type
+func readImportRows(c *gin.Context) ([]export.ImportRow, error) {
+	var (
+		reader io.Reader
+		format string
+	)
+
+	contentType, _, _ := mime.ParseMediaType(c.ContentType())
+	switch contentType {
+	case "multipart/form-data":
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return nil, err
+			}
+			return nil, &taskError{status: http.StatusBadRequest, message: `A file is required in form field "file"`}
+		}
+		if fileHeader.Size > maxImportSize {
+			return nil, &http.MaxBytesError{Limit: maxImportSize}
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		reader = file
+		format = "csv"
+		if strings.EqualFold(path.Ext(fileHeader.Filename), ".json") {
+			format = "json"
+		}
+	case "text/csv":
+		reader, format = c.Request.Body, "csv"
+	case "application/json":
+		reader, format = c.Request.Body, "json"
+	default:
+		return nil, &taskError{status: http.StatusUnsupportedMediaType, message: "Send a multipart file upload, text/csv or application/json"}
+	}
+
+	if format == "json" {
+		return readJSONRows(reader)
+	}
+	return export.ReadCSV(reader, maxImportRows)
+}
+
+// readJSONRows reads a JSON array of create requests
+func readJSONRows(r io.Reader) ([]export.ImportRow, error) {
+	var requests []models.CreateTaskRequest
+	if err := json.NewDecoder(r).Decode(&requests); err != nil {
+		return nil, err
+	}
+	if len(requests) > maxImportRows {
+		return nil, fmt.Errorf("too many rows, at most %d are allowed", maxImportRows)
+	}
+
+	rows := make([]export.ImportRow, len(requests))
+	for i := range requests {
+		rows[i].Request = requests[i]
+	}
+	return rows, nil
+}
+
+// validateImportRows resolves label names and validates each row with the
+// binding rules of CreateTaskRequest, returning one error per invalid row
+func validateImportRows(db *gorm.DB, userID uint, rows []export.ImportRow) ([]models.ImportRowError, error) {
+	var labels []models.Label
+	if err := db.Where("user_id = ?", userID).Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	labelIDs := make(map[string]uint, len(labels))
+	for _, label := range labels {
+		labelIDs[strings.ToLower(label.Name)] = label.ID
+	}
+
+	rowErrors := []models.ImportRowError{}
+	for i := range rows {
+		row := &rows[i]
+		err := row.Err
+
+		for _, name := range row.Labels {
+			if err != nil {
+				break
+			}
+			id, ok := labelIDs[strings.ToLower(name)]
+			if !ok {
+				err = fmt.Errorf("label %q not found", name)
+				break
+			}
+			row.Request.LabelIDs = append(row.Request.LabelIDs, id)
+		}
+
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&row.Request)
+		}
+		if err == nil && row.Request.Recurrence != nil {
+			err = errors.New("recurrence is not supported in imports")
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: i + 1, Line: row.Line, Error: err.Error()})
+		}
+	}
+	return rowErrors, nil
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"task-management-api/middleware"
+	"task-management-api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// LabelHandler handles label-related requests
+type LabelHandler struct{}
+
+// NewLabelHandler creates a new LabelHandler
+func NewLabelHandler() *LabelHandler {
+	return &LabelHandler{}
+}
+
+// CreateLabel creates a new label
+// @Summary Create a label
+// @Description Create a new label for the authenticated user
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param request body models.CreateLabelRequest true "Label details"
+// @Success 201 {object} models.Label
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /labels [post]
+func (h *LabelHandler) CreateLabel(c *gin.Context) {
+	var req models.CreateLabelRequest
+
+	// Bind and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Label names are unique per user
+	if labelNameTaken(requestDB(c), userID, req.Name, 0) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Label with this name already exists",
+		})
+		return
+	}
+
+	label := models.Label{
+		Name:   req.Name,
+		Color:  strings.ToLower(req.Color),
+		UserID: userID,
+	}
+
+	if err := requestDB(c).Create(&label).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create label",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, label)
+}
+
+// GetLabels retrieves all labels of the authenticated user
+// @Summary Get all labels
+// @Description Get all labels of the authenticated user
+// @Tags labels
+// @Produce json
+// @Success 200 {array} models.Label
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /labels [get]
+func (h *LabelHandler) GetLabels(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var labels []models.Label
+	if err := requestDB(c).Where("user_id = ?", userID).Order("name asc").Find(&labels).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch labels",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, labels)
+}
+
+// UpdateLabel updates an existing label
+// @Summary Update a label
+// @Description Rename or recolor a label (must belong to authenticated user)
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path int true "Label ID"
+// @Param request body models.UpdateLabelRequest true "Updated label details"
+// @Success 200 {object} models.Label
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /labels/{id} [put]
+func (h *LabelHandler) UpdateLabel(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Get label ID from URL
+	labelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid label ID",
+		})
+		return
+	}
+
+	// Fetch label
+	var label models.Label
+	if err := requestDB(c).Where("id = ? AND user_id = ?", labelID, userID).First(&label).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Label not found",
+		})
+		return
+	}
+
+	// Bind update request
+	var req models.UpdateLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	// Update fields if provided
+	if req.Name != nil {
+		if labelNameTaken(requestDB(c), userID, *req.Name, label.ID) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Label with this name already exists",
+			})
+			return
+		}
+		label.Name = *req.Name
+	}
+	if req.Color != nil {
+		label.Color = strings.ToLower(*req.Color)
+	}
+
+	if err := requestDB(c).Save(&label).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update label",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, label)
+}
+
+// DeleteLabel deletes a label and detaches it from all tasks
+// @Summary Delete a label
+// @Description Delete a label by ID (must belong to authenticated user)
+// @Tags labels
+// @Produce json
+// @Param id path int true "Label ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /labels/{id} [delete]
+func (h *LabelHandler) DeleteLabel(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Get label ID from URL
+	labelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid label ID",
+		})
+		return
+	}
+
+	var rowsAffected int64
+	err = requestDB(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", labelID, userID).Delete(&models.Label{})
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+		if rowsAffected == 0 {
+			return nil
+		}
+		return tx.Exec("DELETE FROM task_labels WHERE label_id = ?", labelID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete label",
+		})
+		return
+	}
+
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Label not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Label deleted successfully",
+	})
+}
+
+// labelNameTaken reports whether the user already has another label with the given name
+func labelNameTaken(db *gorm.DB, userID uint, name string, exceptID uint) bool {
+	var count int64
+	db.Model(&models.Label{}).
+		Where("user_id = ? AND name = ? AND id != ?", userID, name, exceptID).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-management-api/middleware"
+	"task-management-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecurrenceHandler handles requests for recurring task schedules
+type RecurrenceHandler struct{}
+
+// NewRecurrenceHandler creates a new RecurrenceHandler
+func NewRecurrenceHandler() *RecurrenceHandler {
+	return &RecurrenceHandler{}
+}
+
+// GetRecurrences lists the authenticated user's recurrence rules
+// @Summary List recurrence rules
+// @Description Get all recurring task schedules of the authenticated user
+// @Tags recurrences
+// @Produce json
+// @Success 200 {array} models.RecurrenceRule
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /recurrences [get]
+func (h *RecurrenceHandler) GetRecurrences(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var rules []models.RecurrenceRule
+	if err := requestDB(c).Where("user_id = ?", userID).Order("created_at desc").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch recurrences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRecurrence retrieves a single recurrence rule with its occurrences
+// @Summary Get a recurrence rule
+// @Description Get a recurrence rule and the tasks generated from it
+// @Tags recurrences
+// @Produce json
+// @Param id path int true "Recurrence ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /recurrences/{id} [get]
+func (h *RecurrenceHandler) GetRecurrence(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Get recurrence ID from URL
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid recurrence ID",
+		})
+		return
+	}
+
+	var rule models.RecurrenceRule
+	if err := requestDB(c).Where("id = ? AND user_id = ?", ruleID, userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Recurrence not found",
+		})
+		return
+	}
+
+	var occurrences []models.Task
+	if err := requestDB(c).Preload("Labels").Where("recurrence_rule_id = ?", rule.ID).Order("due_date asc").Find(&occurrences).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch occurrences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recurrence":  rule,
+		"occurrences": occurrences,
+	})
+}
+
+// DeleteRecurrence stops a recurring schedule
+// @Summary Delete a recurrence rule
+// @Description Stop generating new occurrences. Existing tasks are kept.
+// @Tags recurrences
+// @Produce json
+// @Param id path int true "Recurrence ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /recurrences/{id} [delete]
+func (h *RecurrenceHandler) DeleteRecurrence(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Get recurrence ID from URL
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid recurrence ID",
+		})
+		return
+	}
+
+	result := requestDB(c).Where("id = ? AND user_id = ?", ruleID, userID).Delete(&models.RecurrenceRule{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete recurrence",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Recurrence not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recurrence deleted successfully",
+	})
+}
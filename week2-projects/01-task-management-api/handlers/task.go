@@ -1,22 +1,33 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
-	"task-management-api/database"
+	"strings"
+	"task-management-api/activity"
+	"task-management-api/events"
 	"task-management-api/middleware"
 	"task-management-api/models"
+	"task-management-api/recurrence"
+	"task-management-api/timetrack"
+	"task-management-api/webhooks"
+	"task-management-api/workflow"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // TaskHandler handles task-related requests
-type TaskHandler struct{}
+type TaskHandler struct {
+	Events *events.Broker
+}
 
-// NewTaskHandler creates a new TaskHandler
-func NewTaskHandler() *TaskHandler {
-	return &TaskHandler{}
+// NewTaskHandler creates a new TaskHandler that publishes task changes to broker
+func NewTaskHandler(broker *events.Broker) *TaskHandler {
+	return &TaskHandler{Events: broker}
 }
 
 // CreateTask creates a new task
@@ -50,33 +61,13 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		return
 	}
 
-	// Set default values
-	priority := models.PriorityMedium
-	if req.Priority != nil {
-		priority = *req.Priority
-	}
-
-	status := models.StatusTodo
-	if req.Status != nil {
-		status = *req.Status
-	}
-
-	// Create task
-	task := models.Task{
-		Title:       req.Title,
-		Description: req.Description,
-		Priority:    priority,
-		Status:      status,
-		DueDate:     req.DueDate,
-		UserID:      userID,
-	}
-
-	if err := database.DB.Create(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create task",
-		})
+	db, pending := events.Track(requestDB(c))
+	task, err := createTask(db, userID, &req)
+	if err != nil {
+		respondTaskError(c, err, "Failed to create task")
 		return
 	}
+	h.Events.PublishPending(pending)
 
 	c.JSON(http.StatusCreated, task)
 }
@@ -114,7 +105,29 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 	}
 
 	// Build query
-	query := database.DB.Where("user_id = ?", userID)
+	query, err := filterTasks(requestDB(c), userID, filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	// Execute query
+	var tasks []models.Task
+	if err := query.Preload("Labels").Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch tasks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// filterTasks builds a query for the user's tasks with filters and sorting applied
+func filterTasks(db *gorm.DB, userID uint, filters models.TaskFilterParams) (*gorm.DB, error) {
+	query := db.Where("user_id = ?", userID)
 
 	// Apply filters
 	if filters.Status != "" {
@@ -124,6 +137,27 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		query = query.Where("priority = ?", filters.Priority)
 	}
 
+	// Apply label filter: "any" matches tasks with at least one of the labels,
+	// "all" only tasks that carry every one of them
+	if filters.Labels != "" {
+		labelIDs, err := parseIDList(filters.Labels)
+		if err != nil {
+			return nil, err
+		}
+
+		if filters.LabelMatch == "all" {
+			query = query.Where("id IN (?)", db.Table("task_labels").
+				Select("task_id").
+				Where("label_id IN ?", labelIDs).
+				Group("task_id").
+				Having("COUNT(DISTINCT label_id) = ?", len(labelIDs)))
+		} else {
+			query = query.Where("id IN (?)", db.Table("task_labels").
+				Select("task_id").
+				Where("label_id IN ?", labelIDs))
+		}
+	}
+
 	// Apply sorting
 	sortBy := "created_at"
 	if filters.SortBy != "" {
@@ -135,18 +169,55 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		order = filters.Order
 	}
 
-	query = query.Order(sortBy + " " + order)
+	return query.Order(sortBy + " " + order), nil
+}
 
-	// Execute query
-	var tasks []models.Task
-	if err := query.Find(&tasks).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch tasks",
-		})
-		return
+// parseIDList parses a comma-separated list of IDs, ignoring duplicates
+func parseIDList(value string) ([]uint, error) {
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q", part)
+		}
+		if !seen[uint(id)] {
+			seen[uint(id)] = true
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("empty ID list")
+	}
+	return ids, nil
+}
+
+// findUserLabels loads the labels with the given IDs, failing if any of them
+// does not exist or belongs to another user
+func findUserLabels(db *gorm.DB, userID uint, ids []uint) ([]models.Label, error) {
+	labels := []models.Label{}
+	if len(ids) == 0 {
+		return labels, nil
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	if err := db.Where("id IN ? AND user_id = ?", ids, userID).Find(&labels).Error; err != nil {
+		return nil, errors.New("failed to load labels")
+	}
+
+	found := make(map[uint]bool)
+	for _, label := range labels {
+		found[label.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("label %d not found", id)
+		}
+	}
+	return labels, nil
 }
 
 // GetTask retrieves a single task by ID
@@ -180,7 +251,7 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 
 	// Fetch task
 	var task models.Task
-	if err := database.DB.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+	if err := requestDB(c).Preload("Labels").Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Task not found",
 		})
@@ -222,15 +293,6 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		return
 	}
 
-	// Fetch task
-	var task models.Task
-	if err := database.DB.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Task not found",
-		})
-		return
-	}
-
 	// Bind update request
 	var req models.UpdateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -240,31 +302,67 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		return
 	}
 
-	// Update fields if provided
-	if req.Title != nil {
-		task.Title = *req.Title
-	}
-	if req.Description != nil {
-		task.Description = *req.Description
-	}
-	if req.Priority != nil {
-		task.Priority = *req.Priority
+	db, pending := events.Track(requestDB(c))
+	task, err := updateTask(db, userID, uint(taskID), &req)
+	if err != nil {
+		respondTaskError(c, err, "Failed to update task")
+		return
 	}
-	if req.Status != nil {
-		task.Status = *req.Status
+	h.Events.PublishPending(pending)
+
+	c.JSON(http.StatusOK, task)
+}
+
+// MoveTask moves a task on the board
+// @Summary Move a task
+// @Description Move a task to a column and position on the board; its status becomes the column's category
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param request body models.MoveTaskRequest true "Target column and neighbor"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/move [post]
+func (h *TaskHandler) MoveTask(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
 	}
-	if req.DueDate != nil {
-		task.DueDate = req.DueDate
+
+	// Get task ID from URL
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid task ID",
+		})
+		return
 	}
 
-	// Save updates
-	if err := database.DB.Save(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update task",
+	// Bind move request
+	var req models.MoveTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
 		})
 		return
 	}
 
+	db, pending := events.Track(requestDB(c))
+	task, err := moveTask(db, userID, uint(taskID), &req)
+	if err != nil {
+		respondTaskError(c, err, "Failed to move task")
+		return
+	}
+	h.Events.PublishPending(pending)
+
 	c.JSON(http.StatusOK, task)
 }
 
@@ -297,21 +395,12 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 		return
 	}
 
-	// Delete task
-	result := database.DB.Where("id = ? AND user_id = ?", taskID, userID).Delete(&models.Task{})
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete task",
-		})
-		return
-	}
-
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Task not found",
-		})
+	db, pending := events.Track(requestDB(c))
+	if err := deleteTask(db, userID, uint(taskID)); err != nil {
+		respondTaskError(c, err, "Failed to delete task")
 		return
 	}
+	h.Events.PublishPending(pending)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Task deleted successfully",
@@ -323,7 +412,12 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 // @Description Get statistics about tasks for the authenticated user
 // @Tags tasks
 // @Produce json
+// @Param time_group_by query string false "Group tracked time" Enums(day, week, task)
+// @Param from query string false "First day of the time report (YYYY-MM-DD)"
+// @Param to query string false "Last day of the time report (YYYY-MM-DD)"
+// @Param tz query string false "Time zone for report days (default UTC)"
 // @Success 200 {object} models.TaskStats
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Security BearerAuth
 // @Router /tasks/stats [get]
@@ -337,20 +431,29 @@ func (h *TaskHandler) GetTaskStats(c *gin.Context) {
 		return
 	}
 
+	// Parse time report parameters
+	var reportParams models.TimeReportParams
+	if err := c.ShouldBindQuery(&reportParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
 	var stats models.TaskStats
 
 	// Total tasks
-	database.DB.Model(&models.Task{}).Where("user_id = ?", userID).Count(&stats.TotalTasks)
+	requestDB(c).Model(&models.Task{}).Where("user_id = ?", userID).Count(&stats.TotalTasks)
 
 	// Completed tasks
-	database.DB.Model(&models.Task{}).Where("user_id = ? AND status = ?", userID, models.StatusCompleted).Count(&stats.CompletedTasks)
+	requestDB(c).Model(&models.Task{}).Where("user_id = ? AND status = ?", userID, models.StatusCompleted).Count(&stats.CompletedTasks)
 
 	// Pending tasks (todo + in_progress)
-	database.DB.Model(&models.Task{}).Where("user_id = ? AND status IN ?", userID, []models.TaskStatus{models.StatusTodo, models.StatusInProgress}).Count(&stats.PendingTasks)
+	requestDB(c).Model(&models.Task{}).Where("user_id = ? AND status IN ?", userID, []models.TaskStatus{models.StatusTodo, models.StatusInProgress}).Count(&stats.PendingTasks)
 
 	// Overdue tasks
 	now := time.Now()
-	database.DB.Model(&models.Task{}).
+	requestDB(c).Model(&models.Task{}).
 		Where("user_id = ? AND status != ? AND due_date IS NOT NULL AND due_date < ?", userID, models.StatusCompleted, now).
 		Count(&stats.OverdueTasks)
 
@@ -358,7 +461,7 @@ func (h *TaskHandler) GetTaskStats(c *gin.Context) {
 	stats.TasksByPriority = make(map[string]int64)
 	for _, priority := range []models.TaskPriority{models.PriorityLow, models.PriorityMedium, models.PriorityHigh, models.PriorityUrgent} {
 		var count int64
-		database.DB.Model(&models.Task{}).Where("user_id = ? AND priority = ?", userID, priority).Count(&count)
+		requestDB(c).Model(&models.Task{}).Where("user_id = ? AND priority = ?", userID, priority).Count(&count)
 		stats.TasksByPriority[string(priority)] = count
 	}
 
@@ -366,9 +469,345 @@ func (h *TaskHandler) GetTaskStats(c *gin.Context) {
 	stats.TasksByStatus = make(map[string]int64)
 	for _, status := range []models.TaskStatus{models.StatusTodo, models.StatusInProgress, models.StatusCompleted, models.StatusCancelled} {
 		var count int64
-		database.DB.Model(&models.Task{}).Where("user_id = ? AND status = ?", userID, status).Count(&count)
+		requestDB(c).Model(&models.Task{}).Where("user_id = ? AND status = ?", userID, status).Count(&count)
 		stats.TasksByStatus[string(status)] = count
 	}
 
+	// Tasks by workflow column
+	board, err := workflow.Load(requestDB(c), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load workflow",
+		})
+		return
+	}
+	var columnCounts []struct {
+		ColumnID uint
+		Count    int64
+	}
+	requestDB(c).Model(&models.Task{}).Select("column_id, COUNT(*) AS count").
+		Where("user_id = ? AND column_id IS NOT NULL", userID).
+		Group("column_id").Scan(&columnCounts)
+	stats.TasksByColumn = make(map[string]int64)
+	for _, column := range board.Columns {
+		stats.TasksByColumn[column.Key] = 0
+		for _, counted := range columnCounts {
+			if counted.ColumnID == column.ID {
+				stats.TasksByColumn[column.Key] = counted.Count
+			}
+		}
+	}
+
+	// Tracked time and estimates
+	stats.Time, err = timetrack.Report(requestDB(c), userID, reportParams, time.Now())
+	if errors.Is(err, timetrack.ErrInvalidReport) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to build time report",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, stats)
 }
+
+// taskError is a task operation failure caused by the request, such as a
+// missing task or invalid labels, with the HTTP status it maps to
+type taskError struct {
+	status  int
+	message string
+}
+
+func (e *taskError) Error() string {
+	return e.message
+}
+
+// errTaskNotFound is returned when a task does not exist or belongs to another user
+var errTaskNotFound = &taskError{status: http.StatusNotFound, message: "Task not found"}
+
+// workflowTaskError turns a request that breaks the user's workflow rules
+// into a taskError; other errors are returned unchanged
+func workflowTaskError(err error) error {
+	switch {
+	case errors.Is(err, workflow.ErrColumnNotFound),
+		errors.Is(err, workflow.ErrInvalidPosition),
+		errors.Is(err, workflow.ErrInvalidWorkflow):
+		return &taskError{status: http.StatusBadRequest, message: err.Error()}
+	case errors.Is(err, workflow.ErrNoColumnForStatus),
+		errors.Is(err, workflow.ErrTransitionNotAllowed),
+		errors.Is(err, workflow.ErrColumnInUse):
+		return &taskError{status: http.StatusConflict, message: err.Error()}
+	}
+	return err
+}
+
+// respondTaskError writes the response for a failed task operation. Errors
+// that are not a taskError are internal and reported with the fallback message.
+func respondTaskError(c *gin.Context, err error, fallback string) {
+	var taskErr *taskError
+	if errors.As(err, &taskErr) {
+		c.JSON(taskErr.status, gin.H{
+			"error": taskErr.message,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": fallback,
+	})
+}
+
+// createTask creates a task for the user from a validated request, together
+// with its recurrence rule, labels, activity entry and webhook events
+func createTask(db *gorm.DB, userID uint, req *models.CreateTaskRequest) (*models.Task, error) {
+	// Set default values
+	priority := models.PriorityMedium
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+
+	status := models.StatusTodo
+	if req.Status != nil {
+		status = *req.Status
+	}
+
+	task := models.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    priority,
+		Status:      status,
+		DueDate:     req.DueDate,
+		UserID:      userID,
+	}
+	if req.EstimateMinutes != nil && *req.EstimateMinutes > 0 {
+		task.EstimateMinutes = req.EstimateMinutes
+	}
+
+	// Build the recurrence rule if requested; the task becomes its first occurrence
+	var rule *models.RecurrenceRule
+	if req.Recurrence != nil {
+		if req.DueDate == nil {
+			return nil, &taskError{status: http.StatusBadRequest, message: "Recurring tasks require a due_date"}
+		}
+
+		var err error
+		rule, err = recurrence.NewRule(userID, req.Recurrence, *req.DueDate, req.Title, req.Description, priority)
+		if err != nil {
+			return nil, &taskError{status: http.StatusBadRequest, message: "Invalid recurrence: " + err.Error()}
+		}
+	}
+
+	// Resolve labels; they must belong to the user
+	labels, err := findUserLabels(db, userID, req.LabelIDs)
+	if err != nil {
+		return nil, &taskError{status: http.StatusBadRequest, message: err.Error()}
+	}
+	task.Labels = labels
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if rule != nil {
+			if err := tx.Create(rule).Error; err != nil {
+				return err
+			}
+			task.RecurrenceRuleID = &rule.ID
+		}
+		if err := workflow.Place(tx, &task); err != nil {
+			return err
+		}
+		if err := tx.Omit("Labels.*").Create(&task).Error; err != nil {
+			return err
+		}
+		if err := activity.Record(tx, models.ActionCreated, &userID, nil, &task); err != nil {
+			return err
+		}
+		events.Stage(tx, userID, events.TaskCreated, task)
+		return webhooks.Enqueue(tx, userID, models.EventTaskCreated, task)
+	})
+	if err != nil {
+		return nil, workflowTaskError(err)
+	}
+	return &task, nil
+}
+
+// updateTask applies a validated update to one of the user's tasks. A new
+// status moves the task to a board column for that status.
+func updateTask(db *gorm.DB, userID, taskID uint, req *models.UpdateTaskRequest) (*models.Task, error) {
+	return modifyTask(db, userID, taskID, func(tx *gorm.DB, task *models.Task) error {
+		// Update fields if provided
+		if req.Title != nil {
+			task.Title = *req.Title
+		}
+		if req.Description != nil {
+			task.Description = *req.Description
+		}
+		if req.Priority != nil {
+			task.Priority = *req.Priority
+		}
+		if req.Status != nil && *req.Status != task.Status {
+			if err := workflow.SetStatus(tx, task, *req.Status); err != nil {
+				return err
+			}
+		}
+		if req.DueDate != nil {
+			task.DueDate = req.DueDate
+		}
+		if req.EstimateMinutes != nil {
+			task.EstimateMinutes = req.EstimateMinutes
+			if *req.EstimateMinutes == 0 {
+				task.EstimateMinutes = nil
+			}
+		}
+
+		// Replace the label set if provided
+		if req.LabelIDs != nil {
+			labels, err := findUserLabels(tx, userID, *req.LabelIDs)
+			if err != nil {
+				return &taskError{status: http.StatusBadRequest, message: err.Error()}
+			}
+			if err := tx.Model(task).Association("Labels").Replace(labels); err != nil {
+				return err
+			}
+			task.Labels = labels
+		}
+		return nil
+	})
+}
+
+// moveTask moves one of the user's tasks to a position on the board, setting
+// its status to the category of the target column
+func moveTask(db *gorm.DB, userID, taskID uint, req *models.MoveTaskRequest) (*models.Task, error) {
+	return modifyTask(db, userID, taskID, func(tx *gorm.DB, task *models.Task) error {
+		return workflow.Move(tx, task, req.ColumnID, req.AfterTaskID, req.BeforeTaskID)
+	})
+}
+
+// modifyTask loads one of the user's tasks, lets apply change it and saves
+// the result in one transaction, together with the activity entry, webhook
+// events and real-time event for the change
+func modifyTask(db *gorm.DB, userID, taskID uint, apply func(tx *gorm.DB, task *models.Task) error) (*models.Task, error) {
+	var task models.Task
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Fetch task
+		if err := tx.Preload("Labels").Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+			return errTaskNotFound
+		}
+
+		// Keep the previous version for the activity log
+		before := task
+		before.Labels = append([]models.Label(nil), task.Labels...)
+
+		// Remember whether this update completes the task
+		wasCompleted := task.Status == models.StatusCompleted
+
+		if err := apply(tx, &task); err != nil {
+			return err
+		}
+		if err := tx.Omit("Labels").Save(&task).Error; err != nil {
+			return err
+		}
+		if err := activity.Record(tx, models.ActionUpdated, &userID, &before, &task); err != nil {
+			return err
+		}
+
+		// Notify webhooks of the change; reordering within a column is only
+		// sent to real-time clients
+		changes := activity.Diff(&before, &task)
+		if len(changes) > 0 || task.Rank != before.Rank {
+			events.Stage(tx, userID, events.TaskUpdated, task)
+		}
+		if len(changes) > 0 {
+			update := webhooks.TaskUpdate{Task: task, Changes: changes}
+			if err := webhooks.Enqueue(tx, userID, models.EventTaskUpdated, update); err != nil {
+				return err
+			}
+		}
+
+		// Completing a recurring occurrence also creates the next one
+		justCompleted := !wasCompleted && task.Status == models.StatusCompleted
+		if justCompleted {
+			if err := webhooks.Enqueue(tx, userID, models.EventTaskCompleted, task); err != nil {
+				return err
+			}
+		}
+		if justCompleted && task.RecurrenceRuleID != nil {
+			if _, err := recurrence.AdvanceAfterCompletion(tx, *task.RecurrenceRuleID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, workflowTaskError(err)
+	}
+	return &task, nil
+}
+
+// deleteTask soft-deletes one of the user's tasks and records the deletion
+func deleteTask(db *gorm.DB, userID, taskID uint) error {
+	// Fetch task so the activity log can record what was deleted
+	var task models.Task
+	if err := db.Preload("Labels").Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+		return errTaskNotFound
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := softDeleteTask(tx, &task); err != nil {
+			return err
+		}
+		if err := timetrack.StopTask(tx, task.ID, time.Now()); err != nil {
+			return err
+		}
+		events.Stage(tx, userID, events.TaskDeleted, gin.H{"id": task.ID})
+		return activity.Record(tx, models.ActionDeleted, &userID, &task, nil)
+	})
+}
+
+// softDeleteTask soft-deletes a task together with its comments and
+// attachments, so they share the task's gorm.DeletedAt lifecycle.
+// Attachment files stay on disk so a restored task keeps its files.
+func softDeleteTask(tx *gorm.DB, task *models.Task) error {
+	if err := tx.Delete(task).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("task_id = ?", task.ID).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("task_id = ?", task.ID).Delete(&models.Attachment{}).Error
+}
+
+// findTaskForUser loads the task named by the :id URL parameter for the
+// authenticated user, writing an error response and returning false if it cannot
+func findTaskForUser(c *gin.Context) (models.Task, uint, bool) {
+	var task models.Task
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return task, 0, false
+	}
+
+	// Get task ID from URL
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid task ID",
+		})
+		return task, 0, false
+	}
+
+	if err := requestDB(c).Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Task not found",
+		})
+		return task, 0, false
+	}
+
+	return task, userID, true
+}
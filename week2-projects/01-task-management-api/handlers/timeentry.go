@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"task-management-api/middleware"
+	"task-management-api/models"
+	"task-management-api/timetrack"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeEntryHandler handles timers and time entries on tasks
+type TimeEntryHandler struct{}
+
+// NewTimeEntryHandler creates a new TimeEntryHandler
+func NewTimeEntryHandler() *TimeEntryHandler {
+	return &TimeEntryHandler{}
+}
+
+// StartTimer starts a timer on a task
+// @Summary Start a timer
+// @Description Start tracking time on a task; only one timer per user can run at a time
+// @Tags time
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param request body models.StartTimerRequest false "Optional note"
+// @Success 201 {object} models.TimeEntry
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/timer/start [post]
+func (h *TimeEntryHandler) StartTimer(c *gin.Context) {
+	task, _, ok := findTaskForUser(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req models.StartTimerRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request payload: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	entry, err := timetrack.Start(requestDB(c), &task, req.Note, time.Now())
+	if err != nil {
+		respondTaskError(c, timeEntryError(err), "Failed to start timer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// StopTimer stops the timer running on a task
+// @Summary Stop a timer
+// @Description Stop the authenticated user's timer on a task, turning it into a time entry
+// @Tags time
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.TimeEntry
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/timer/stop [post]
+func (h *TimeEntryHandler) StopTimer(c *gin.Context) {
+	task, userID, ok := findTaskForUser(c)
+	if !ok {
+		return
+	}
+
+	entry, err := timetrack.Stop(requestDB(c), userID, task.ID, time.Now())
+	if err != nil {
+		respondTaskError(c, timeEntryError(err), "Failed to stop timer")
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetRunningTimer returns the user's running timer
+// @Summary Get running timer
+// @Description Get the timer the authenticated user is running, if any
+// @Tags time
+// @Produce json
+// @Success 200 {object} models.TimeEntry
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /timer [get]
+func (h *TimeEntryHandler) GetRunningTimer(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	entry, err := timetrack.Running(requestDB(c), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch timer",
+		})
+		return
+	}
+	if entry == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No timer is running",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// CreateTimeEntry logs time on a task manually
+// @Summary Log time
+// @Description Record time spent on a task, given a start and either an end or a number of minutes
+// @Tags time
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param request body models.CreateTimeEntryRequest true "Time entry"
+// @Success 201 {object} models.TimeEntry
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/time-entries [post]
+func (h *TimeEntryHandler) CreateTimeEntry(c *gin.Context) {
+	task, _, ok := findTaskForUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateTimeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	entry, err := timetrack.Log(requestDB(c), &task, &req, time.Now())
+	if err != nil {
+		respondTaskError(c, timeEntryError(err), "Failed to log time")
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// GetTimeEntries lists the time tracked on a task
+// @Summary Get time entries
+// @Description Get the time entries of a task, newest first, with the total tracked and the estimate
+// @Tags time
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.TaskTime
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/time-entries [get]
+func (h *TimeEntryHandler) GetTimeEntries(c *gin.Context) {
+	task, _, ok := findTaskForUser(c)
+	if !ok {
+		return
+	}
+
+	entries := []models.TimeEntry{}
+	if err := requestDB(c).Where("task_id = ?", task.ID).Order("started_at desc, id desc").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch time entries",
+		})
+		return
+	}
+
+	result := models.TaskTime{
+		TaskID:          task.ID,
+		EstimateMinutes: task.EstimateMinutes,
+		Entries:         entries,
+	}
+	now := time.Now()
+	for i := range result.Entries {
+		timetrack.Fill(&result.Entries[i], now)
+		result.TrackedSeconds += result.Entries[i].Seconds
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateTimeEntry corrects a time entry
+// @Summary Update a time entry
+// @Description Change the times or note of a time entry; a running timer can only change its note
+// @Tags time
+// @Accept json
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param entry_id path int true "Time entry ID"
+// @Param request body models.UpdateTimeEntryRequest true "Changed fields"
+// @Success 200 {object} models.TimeEntry
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/time-entries/{entry_id} [put]
+func (h *TimeEntryHandler) UpdateTimeEntry(c *gin.Context) {
+	entry, ok := findTimeEntry(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateTimeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	if err := timetrack.Update(requestDB(c), &entry, &req, time.Now()); err != nil {
+		respondTaskError(c, timeEntryError(err), "Failed to update time entry")
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteTimeEntry deletes a time entry
+// @Summary Delete a time entry
+// @Description Delete a time entry, or discard a running timer
+// @Tags time
+// @Produce json
+// @Param id path int true "Task ID"
+// @Param entry_id path int true "Time entry ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /tasks/{id}/time-entries/{entry_id} [delete]
+func (h *TimeEntryHandler) DeleteTimeEntry(c *gin.Context) {
+	entry, ok := findTimeEntry(c)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(c).Delete(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete time entry",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Time entry deleted successfully",
+	})
+}
+
+// findTimeEntry loads the time entry named in the URL on a task of the
+// authenticated user
+func findTimeEntry(c *gin.Context) (models.TimeEntry, bool) {
+	var entry models.TimeEntry
+
+	task, _, ok := findTaskForUser(c)
+	if !ok {
+		return entry, false
+	}
+
+	// Get entry ID from URL
+	entryID, err := strconv.ParseUint(c.Param("entry_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid time entry ID",
+		})
+		return entry, false
+	}
+
+	if err := requestDB(c).Where("id = ? AND task_id = ?", entryID, task.ID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Time entry not found",
+		})
+		return entry, false
+	}
+
+	timetrack.Fill(&entry, time.Now())
+	return entry, true
+}
+
+// timeEntryError turns a time tracking request that cannot be recorded into
+// a taskError; other errors are returned unchanged
+func timeEntryError(err error) error {
+	switch {
+	case errors.Is(err, timetrack.ErrInvalidEntry):
+		return &taskError{status: http.StatusBadRequest, message: err.Error()}
+	case errors.Is(err, timetrack.ErrNoTimer):
+		return &taskError{status: http.StatusNotFound, message: err.Error()}
+	case errors.Is(err, timetrack.ErrTimerRunning):
+		return &taskError{status: http.StatusConflict, message: err.Error()}
+	}
+	return err
+}
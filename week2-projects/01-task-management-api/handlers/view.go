@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-management-api/middleware"
+	"task-management-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SavedViewHandler handles requests for saved task views
+type SavedViewHandler struct{}
+
+// NewSavedViewHandler creates a new SavedViewHandler
+func NewSavedViewHandler() *SavedViewHandler {
+	return &SavedViewHandler{}
+}
+
+// CreateView saves a named filter and sort combination
+// @Summary Create a saved view
+// @Description Save a named task filter for the authenticated user
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param request body models.SavedViewRequest true "View details"
+// @Success 201 {object} models.SavedView
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /views [post]
+func (h *SavedViewHandler) CreateView(c *gin.Context) {
+	var req models.SavedViewRequest
+
+	// Bind and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// Validate the label list up front so the view can always be applied
+	if req.Filters.Labels != "" {
+		if _, err := parseIDList(req.Filters.Labels); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid filters: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	view := models.SavedView{
+		Name:    req.Name,
+		Filters: req.Filters,
+		UserID:  userID,
+	}
+
+	if err := requestDB(c).Create(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create view",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, view)
+}
+
+// GetViews lists the authenticated user's saved views
+// @Summary Get all saved views
+// @Description Get all saved views of the authenticated user
+// @Tags views
+// @Produce json
+// @Success 200 {array} models.SavedView
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /views [get]
+func (h *SavedViewHandler) GetViews(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var views []models.SavedView
+	if err := requestDB(c).Where("user_id = ?", userID).Order("name asc").Find(&views).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch views",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// GetView retrieves a saved view by ID
+// @Summary Get a saved view
+// @Description Get a saved view by ID (must belong to authenticated user)
+// @Tags views
+// @Produce json
+// @Param id path int true "View ID"
+// @Success 200 {object} models.SavedView
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /views/{id} [get]
+func (h *SavedViewHandler) GetView(c *gin.Context) {
+	view, ok := h.findView(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// GetViewTasks returns the tasks matching a saved view
+// @Summary Get tasks of a saved view
+// @Description Apply a saved view's filters and sorting to the user's tasks
+// @Tags views
+// @Produce json
+// @Param id path int true "View ID"
+// @Success 200 {array} models.Task
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /views/{id}/tasks [get]
+func (h *SavedViewHandler) GetViewTasks(c *gin.Context) {
+	view, ok := h.findView(c)
+	if !ok {
+		return
+	}
+
+	query, err := filterTasks(requestDB(c), view.UserID, view.Filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid view filters: " + err.Error(),
+		})
+		return
+	}
+
+	var tasks []models.Task
+	if err := query.Preload("Labels").Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch tasks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// UpdateView replaces the name and filters of a saved view
+// @Summary Update a saved view
+// @Description Replace a saved view's name and filters
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path int true "View ID"
+// @Param request body models.SavedViewRequest true "View details"
+// @Success 200 {object} models.SavedView
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /views/{id} [put]
+func (h *SavedViewHandler) UpdateView(c *gin.Context) {
+	view, ok := h.findView(c)
+	if !ok {
+		return
+	}
+
+	// Bind update request
+	var req models.SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Filters.Labels != "" {
+		if _, err := parseIDList(req.Filters.Labels); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid filters: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	view.Name = req.Name
+	view.Filters = req.Filters
+
+	if err := requestDB(c).Save(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update view",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// DeleteView deletes a saved view
+// @Summary Delete a saved view
+// @Description Delete a saved view by ID (must belong to authenticated user)
+// @Tags views
+// @Produce json
+// @Param id path int true "View ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /views/{id} [delete]
+func (h *SavedViewHandler) DeleteView(c *gin.Context) {
+	view, ok := h.findView(c)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(c).Delete(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete view",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "View deleted successfully",
+	})
+}
+
+// findView loads the view named in the URL for the authenticated user,
+// writing an error response and returning false if it cannot
+func (h *SavedViewHandler) findView(c *gin.Context) (models.SavedView, bool) {
+	var view models.SavedView
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return view, false
+	}
+
+	// Get view ID from URL
+	viewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid view ID",
+		})
+		return view, false
+	}
+
+	if err := requestDB(c).Where("id = ? AND user_id = ?", viewID, userID).First(&view).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "View not found",
+		})
+		return view, false
+	}
+
+	return view, true
+}
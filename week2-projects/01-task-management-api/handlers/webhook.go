@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"task-management-api/middleware"
+	"task-management-api/models"
+	"task-management-api/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles requests for outbound webhooks
+type WebhookHandler struct{}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler() *WebhookHandler {
+	return &WebhookHandler{}
+}
+
+// CreateWebhook registers a webhook URL
+// @Summary Register a webhook
+// @Description Register a URL to receive task events. The signing secret is only returned in this response.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body models.CreateWebhookRequest true "Webhook details"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+
+	// Bind and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	if err := webhooks.ValidateURL(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": webhookURLError(err),
+		})
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create webhook",
+		})
+		return
+	}
+
+	hook := models.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: secret,
+		Events: joinEvents(req.Events),
+		Active: true,
+	}
+
+	if err := requestDB(c).Create(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create webhook",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"webhook": hook,
+		"secret":  secret,
+	})
+}
+
+// GetWebhooks lists the authenticated user's webhooks
+// @Summary Get all webhooks
+// @Description Get all webhooks of the authenticated user
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} models.Webhook
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var hooks []models.Webhook
+	if err := requestDB(c).Where("user_id = ?", userID).Order("created_at asc").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch webhooks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+// UpdateWebhook changes a webhook's URL, events or active flag
+// @Summary Update a webhook
+// @Description Update a webhook (must belong to authenticated user)
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param request body models.UpdateWebhookRequest true "Updated webhook details"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	// Bind update request
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	// Update fields if provided
+	if req.URL != nil {
+		if err := webhooks.ValidateURL(*req.URL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": webhookURLError(err),
+			})
+			return
+		}
+		hook.URL = *req.URL
+	}
+	if req.Events != nil {
+		hook.Events = joinEvents(*req.Events)
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+
+	if err := requestDB(c).Save(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update webhook",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook deletes a webhook
+// @Summary Delete a webhook
+// @Description Delete a webhook; pending deliveries to it are dropped
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(c).Delete(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete webhook",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// GetDeliveries returns the delivery log of a webhook
+// @Summary Get webhook deliveries
+// @Description Get the delivery log of a webhook, newest first
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Items per page (default 20, max 100)"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var params models.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+	page, pageSize := pageBounds(params)
+
+	deliveries := []models.WebhookDelivery{}
+	if err := requestDB(c).Where("webhook_id = ?", hook.ID).
+		Order("created_at desc, id desc").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch deliveries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// findWebhook loads the webhook named in the URL for the authenticated user
+func findWebhook(c *gin.Context) (models.Webhook, bool) {
+	var hook models.Webhook
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return hook, false
+	}
+
+	// Get webhook ID from URL
+	hookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook ID",
+		})
+		return hook, false
+	}
+
+	if err := requestDB(c).Where("id = ? AND user_id = ?", hookID, userID).First(&hook).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Webhook not found",
+		})
+		return hook, false
+	}
+
+	return hook, true
+}
+
+// webhookURLError returns the message for a refused webhook URL, starting
+// with a capital like the other error messages
+func webhookURLError(err error) string {
+	message := err.Error()
+	return strings.ToUpper(message[:1]) + message[1:]
+}
+
+// joinEvents stores an event list as a comma-separated string
+func joinEvents(events []models.WebhookEvent) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"task-management-api/events"
+	"task-management-api/middleware"
+	"task-management-api/models"
+	"task-management-api/workflow"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WorkflowHandler handles kanban workflow and board requests
+type WorkflowHandler struct {
+	Events *events.Broker
+}
+
+// NewWorkflowHandler creates a new WorkflowHandler that publishes workflow changes to broker
+func NewWorkflowHandler(broker *events.Broker) *WorkflowHandler {
+	return &WorkflowHandler{Events: broker}
+}
+
+// GetWorkflow returns the user's workflow
+// @Summary Get workflow
+// @Description Get the columns and allowed transitions of the authenticated user's board
+// @Tags workflow
+// @Produce json
+// @Success 200 {object} models.Workflow
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /workflow [get]
+func (h *WorkflowHandler) GetWorkflow(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	board, err := workflow.Load(requestDB(c), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load workflow",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// UpdateWorkflow replaces the user's workflow
+// @Summary Replace workflow
+// @Description Replace the columns (matched by key, in board order) and allowed transitions of the board
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateWorkflowRequest true "Columns and transitions"
+// @Success 200 {object} models.Workflow
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /workflow [put]
+func (h *WorkflowHandler) UpdateWorkflow(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.UpdateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	var updated *models.Workflow
+	db, pending := events.Track(requestDB(c))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		updated, err = workflow.Replace(tx, userID, &req)
+		return err
+	})
+	if err != nil {
+		respondTaskError(c, workflowTaskError(err), "Failed to update workflow")
+		return
+	}
+	h.Events.PublishPending(pending)
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetBoard returns the user's tasks grouped by workflow column
+// @Summary Get board
+// @Description Get the board of the authenticated user: every column with its tasks in board order
+// @Tags workflow
+// @Produce json
+// @Success 200 {object} models.Board
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /board [get]
+func (h *WorkflowHandler) GetBoard(c *gin.Context) {
+	// Get user ID from context
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	current, err := workflow.Load(requestDB(c), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load workflow",
+		})
+		return
+	}
+
+	var tasks []models.Task
+	if err := requestDB(c).Preload("Labels").
+		Where("user_id = ? AND column_id IS NOT NULL", userID).
+		Order("rank asc, id asc").
+		Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch tasks",
+		})
+		return
+	}
+
+	board := models.Board{
+		WorkflowID:  current.ID,
+		Columns:     make([]models.BoardColumn, len(current.Columns)),
+		Transitions: current.Transitions,
+	}
+	index := make(map[uint]int)
+	for i, column := range current.Columns {
+		board.Columns[i] = models.BoardColumn{WorkflowColumn: column, Tasks: []models.Task{}}
+		index[column.ID] = i
+	}
+	for _, task := range tasks {
+		if i, ok := index[*task.ColumnID]; ok {
+			board.Columns[i].Tasks = append(board.Columns[i].Tasks, task)
+		}
+	}
+
+	c.JSON(http.StatusOK, board)
+}
@@ -2,11 +2,21 @@ package main
 
 import (
 	"log"
+	"os"
 	"strings"
 	"task-management-api/config"
 	"task-management-api/database"
+	"task-management-api/events"
 	"task-management-api/handlers"
+	"task-management-api/logging"
+	"task-management-api/mailer"
+	"task-management-api/metrics"
 	"task-management-api/middleware"
+	"task-management-api/recurrence"
+	"task-management-api/reminders"
+	"task-management-api/storage"
+	"task-management-api/webhooks"
+	_ "time/tzdata" // Embed time zone data so recurrence rules work on hosts without it
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,27 +25,97 @@ func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
 
+	// Log structured lines through slog
+	if _, err := logging.Setup(cfg.Log); err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
+	}
+
+	// "migrate" runs schema migrations instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(cfg, os.Args[2:])
+		return
+	}
+
 	// Initialize database
-	if err := database.InitDatabase(cfg.Database.Path); err != nil {
+	if err := database.InitDatabase(cfg.Database); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer database.CloseDatabase()
 
+	// Collect request and query metrics
+	appMetrics := metrics.New()
+	if err := appMetrics.InstrumentDB(database.DB); err != nil {
+		log.Fatalf("Failed to instrument database: %v", err)
+	}
+
+	// Real-time event broker shared by handlers and schedulers
+	broker := events.NewBroker(cfg.Events.BufferSize)
+
+	// Start the recurring task scheduler
+	scheduler := recurrence.NewScheduler(database.DB, broker, cfg.Recurrence.Interval, cfg.Recurrence.Horizon)
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	// Start due-date reminders and webhook delivery
+	reminderScheduler := reminders.NewScheduler(database.DB, cfg.Reminders.Interval, cfg.Reminders.Offsets)
+	reminderScheduler.Start()
+	defer reminderScheduler.Stop()
+
+	dispatcher := webhooks.NewDispatcher(database.DB, cfg.Webhooks.Interval, cfg.Webhooks.Timeout, cfg.Webhooks.MaxAttempts,
+		cfg.Webhooks.Concurrency, cfg.Webhooks.Retention)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	// Initialize attachment storage
+	attachmentStore, err := storage.NewLocalStorage(cfg.Attachments.Dir)
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+
+	// Initialize the mailer for account emails
+	sender, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
 	// Set Gin mode
 	gin.SetMode(cfg.Server.Mode)
 
 	// Create Gin router
 	router := gin.New()
 
+	// Only trust X-Forwarded-For from known proxies, so clients cannot pick
+	// the address that login limits are counted against
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// Apply global middleware
+	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.LoggerMiddleware())
+	router.Use(middleware.MetricsMiddleware(appMetrics))
 	router.Use(middleware.ErrorHandlerMiddleware())
 	router.Use(middleware.CORSMiddleware())
 	router.Use(gin.Recovery())
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(cfg)
-	taskHandler := handlers.NewTaskHandler()
+	authHandler := handlers.NewAuthHandler(cfg, sender)
+	taskHandler := handlers.NewTaskHandler(broker)
+	recurrenceHandler := handlers.NewRecurrenceHandler()
+	labelHandler := handlers.NewLabelHandler()
+	viewHandler := handlers.NewSavedViewHandler()
+	activityHandler := handlers.NewActivityHandler()
+	commentHandler := handlers.NewCommentHandler()
+	attachmentHandler := handlers.NewAttachmentHandler(cfg, attachmentStore)
+	webhookHandler := handlers.NewWebhookHandler()
+	calendarHandler := handlers.NewCalendarHandler()
+	eventHandler := handlers.NewEventHandler(cfg, broker)
+	workflowHandler := handlers.NewWorkflowHandler(broker)
+	timeEntryHandler := handlers.NewTimeEntryHandler()
+	docsHandler, err := handlers.NewDocsHandler()
+	if err != nil {
+		log.Fatalf("Failed to build API docs: %v", err)
+	}
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -45,6 +125,15 @@ func main() {
 		})
 	})
 
+	// Prometheus metrics
+	if cfg.Metrics.Enabled {
+		router.GET("/metrics", middleware.MetricsAuthMiddleware(cfg.Metrics.Token), gin.WrapH(appMetrics.Handler()))
+	}
+
+	// API documentation
+	router.GET("/openapi.json", docsHandler.GetSpec)
+	router.GET("/docs", docsHandler.GetDocs)
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
@@ -53,7 +142,14 @@ func main() {
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/verify-email", authHandler.VerifyEmail)
+			auth.POST("/resend-verification", authHandler.ResendVerification)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
 			auth.GET("/profile", middleware.AuthMiddleware(cfg), authHandler.GetProfile)
+			auth.POST("/logout", middleware.AuthMiddleware(cfg), authHandler.Logout)
+			auth.POST("/logout-all", middleware.AuthMiddleware(cfg), authHandler.LogoutAll)
 		}
 
 		// Task routes (protected)
@@ -62,11 +158,101 @@ func main() {
 		{
 			tasks.POST("", taskHandler.CreateTask)
 			tasks.GET("", taskHandler.GetTasks)
+			tasks.POST("/batch", taskHandler.BatchTasks)
+			tasks.GET("/export", taskHandler.ExportTasks)
+			tasks.POST("/import", taskHandler.ImportTasks)
 			tasks.GET("/stats", taskHandler.GetTaskStats) // Must come before /:id
 			tasks.GET("/:id", taskHandler.GetTask)
+			tasks.GET("/:id/history", activityHandler.GetTaskHistory)
+
+			// Comments
+			tasks.POST("/:id/comments", commentHandler.CreateComment)
+			tasks.GET("/:id/comments", commentHandler.GetComments)
+			tasks.PUT("/:id/comments/:comment_id", commentHandler.UpdateComment)
+			tasks.DELETE("/:id/comments/:comment_id", commentHandler.DeleteComment)
+
+			// Attachments
+			tasks.POST("/:id/attachments", attachmentHandler.UploadAttachment)
+			tasks.GET("/:id/attachments", attachmentHandler.GetAttachments)
+			tasks.GET("/:id/attachments/:attachment_id/download", attachmentHandler.DownloadAttachment)
+			tasks.DELETE("/:id/attachments/:attachment_id", attachmentHandler.DeleteAttachment)
+
+			// Time tracking
+			tasks.POST("/:id/timer/start", timeEntryHandler.StartTimer)
+			tasks.POST("/:id/timer/stop", timeEntryHandler.StopTimer)
+			tasks.POST("/:id/time-entries", timeEntryHandler.CreateTimeEntry)
+			tasks.GET("/:id/time-entries", timeEntryHandler.GetTimeEntries)
+			tasks.PUT("/:id/time-entries/:entry_id", timeEntryHandler.UpdateTimeEntry)
+			tasks.DELETE("/:id/time-entries/:entry_id", timeEntryHandler.DeleteTimeEntry)
 			tasks.PUT("/:id", taskHandler.UpdateTask)
+			tasks.POST("/:id/move", taskHandler.MoveTask)
 			tasks.DELETE("/:id", taskHandler.DeleteTask)
 		}
+
+		// Running timer (protected)
+		v1.GET("/timer", middleware.AuthMiddleware(cfg), timeEntryHandler.GetRunningTimer)
+
+		// Kanban workflow and board (protected)
+		v1.GET("/workflow", middleware.AuthMiddleware(cfg), workflowHandler.GetWorkflow)
+		v1.PUT("/workflow", middleware.AuthMiddleware(cfg), workflowHandler.UpdateWorkflow)
+		v1.GET("/board", middleware.AuthMiddleware(cfg), workflowHandler.GetBoard)
+
+		// Activity feed (protected)
+		v1.GET("/activity", middleware.AuthMiddleware(cfg), activityHandler.GetActivityFeed)
+
+		// Real-time event stream (protected)
+		v1.POST("/events/ticket", middleware.AuthMiddleware(cfg), eventHandler.CreateTicket)
+		v1.GET("/events", middleware.EventStreamAuthMiddleware(cfg), eventHandler.StreamEvents)
+
+		// Recurrence routes (protected)
+		recurrences := v1.Group("/recurrences")
+		recurrences.Use(middleware.AuthMiddleware(cfg))
+		{
+			recurrences.GET("", recurrenceHandler.GetRecurrences)
+			recurrences.GET("/:id", recurrenceHandler.GetRecurrence)
+			recurrences.DELETE("/:id", recurrenceHandler.DeleteRecurrence)
+		}
+
+		// Label routes (protected)
+		labels := v1.Group("/labels")
+		labels.Use(middleware.AuthMiddleware(cfg))
+		{
+			labels.POST("", labelHandler.CreateLabel)
+			labels.GET("", labelHandler.GetLabels)
+			labels.PUT("/:id", labelHandler.UpdateLabel)
+			labels.DELETE("/:id", labelHandler.DeleteLabel)
+		}
+
+		// Webhook routes (protected)
+		hooks := v1.Group("/webhooks")
+		hooks.Use(middleware.AuthMiddleware(cfg))
+		{
+			hooks.POST("", webhookHandler.CreateWebhook)
+			hooks.GET("", webhookHandler.GetWebhooks)
+			hooks.PUT("/:id", webhookHandler.UpdateWebhook)
+			hooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			hooks.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+		}
+
+		// Calendar feed; the feed itself is authenticated by the token in its URL
+		calendar := v1.Group("/calendar")
+		{
+			calendar.POST("/token", middleware.AuthMiddleware(cfg), calendarHandler.CreateFeedToken)
+			calendar.DELETE("/token", middleware.AuthMiddleware(cfg), calendarHandler.DeleteFeedToken)
+			calendar.GET("/:feed", calendarHandler.GetFeed)
+		}
+
+		// Saved view routes (protected)
+		views := v1.Group("/views")
+		views.Use(middleware.AuthMiddleware(cfg))
+		{
+			views.POST("", viewHandler.CreateView)
+			views.GET("", viewHandler.GetViews)
+			views.GET("/:id", viewHandler.GetView)
+			views.GET("/:id/tasks", viewHandler.GetViewTasks)
+			views.PUT("/:id", viewHandler.UpdateView)
+			views.DELETE("/:id", viewHandler.DeleteView)
+		}
 	}
 
 	// Print available routes
@@ -77,15 +263,74 @@ func main() {
 	log.Println("Mode:", cfg.Server.Mode)
 	log.Println("\nAvailable endpoints:")
 	log.Println("  GET    /health                    - Health check")
+	if cfg.Metrics.Enabled {
+		log.Println("  GET    /metrics                   - Prometheus metrics")
+	}
+	log.Println("  GET    /openapi.json              - OpenAPI document of auth and task endpoints")
+	log.Println("  GET    /docs                      - API docs page")
 	log.Println("  POST   /api/v1/auth/register      - Register new user")
 	log.Println("  POST   /api/v1/auth/login         - Login user")
+	log.Println("  POST   /api/v1/auth/refresh       - Refresh access token")
+	log.Println("  POST   /api/v1/auth/verify-email  - Verify email address with emailed token")
+	log.Println("  POST   /api/v1/auth/resend-verification - Resend verification email")
+	log.Println("  POST   /api/v1/auth/forgot-password - Email a password reset token")
+	log.Println("  POST   /api/v1/auth/reset-password - Set new password with reset token")
 	log.Println("  GET    /api/v1/auth/profile       - Get user profile (protected)")
+	log.Println("  POST   /api/v1/auth/logout        - Revoke current session (protected)")
+	log.Println("  POST   /api/v1/auth/logout-all    - Revoke all sessions (protected)")
 	log.Println("  POST   /api/v1/tasks              - Create task (protected)")
 	log.Println("  GET    /api/v1/tasks              - Get all tasks (protected)")
+	log.Println("  POST   /api/v1/tasks/batch        - Create, update and delete tasks in bulk (protected)")
+	log.Println("  GET    /api/v1/tasks/export       - Export tasks as CSV or JSON (protected)")
+	log.Println("  POST   /api/v1/tasks/import       - Import tasks from CSV or JSON (protected)")
 	log.Println("  GET    /api/v1/tasks/stats        - Get task statistics (protected)")
 	log.Println("  GET    /api/v1/tasks/:id          - Get task by ID (protected)")
+	log.Println("  GET    /api/v1/tasks/:id/history  - Get task change history (protected)")
+	log.Println("  POST   /api/v1/tasks/:id/comments - Add comment or reply (protected)")
+	log.Println("  GET    /api/v1/tasks/:id/comments - Get comment threads (protected)")
+	log.Println("  PUT    /api/v1/tasks/:id/comments/:comment_id - Edit own comment (protected)")
+	log.Println("  DELETE /api/v1/tasks/:id/comments/:comment_id - Delete own comment (protected)")
+	log.Println("  POST   /api/v1/tasks/:id/attachments - Upload attachment (protected)")
+	log.Println("  GET    /api/v1/tasks/:id/attachments - Get attachments (protected)")
+	log.Println("  GET    /api/v1/tasks/:id/attachments/:attachment_id/download - Download attachment (protected)")
+	log.Println("  DELETE /api/v1/tasks/:id/attachments/:attachment_id - Delete attachment (protected)")
+	log.Println("  POST   /api/v1/tasks/:id/timer/start - Start timer on task (protected)")
+	log.Println("  POST   /api/v1/tasks/:id/timer/stop - Stop timer on task (protected)")
+	log.Println("  POST   /api/v1/tasks/:id/time-entries - Log time manually (protected)")
+	log.Println("  GET    /api/v1/tasks/:id/time-entries - Get time entries and total (protected)")
+	log.Println("  PUT    /api/v1/tasks/:id/time-entries/:entry_id - Correct time entry (protected)")
+	log.Println("  DELETE /api/v1/tasks/:id/time-entries/:entry_id - Delete time entry (protected)")
+	log.Println("  GET    /api/v1/timer              - Get running timer (protected)")
 	log.Println("  PUT    /api/v1/tasks/:id          - Update task (protected)")
+	log.Println("  POST   /api/v1/tasks/:id/move     - Move task on the board (protected)")
 	log.Println("  DELETE /api/v1/tasks/:id          - Delete task (protected)")
+	log.Println("  GET    /api/v1/workflow           - Get board columns and transitions (protected)")
+	log.Println("  PUT    /api/v1/workflow           - Replace board columns and transitions (protected)")
+	log.Println("  GET    /api/v1/board              - Get tasks grouped by column (protected)")
+	log.Println("  GET    /api/v1/activity           - Get activity feed (protected)")
+	log.Println("  POST   /api/v1/events/ticket      - Create a ticket for EventSource clients (protected)")
+	log.Println("  GET    /api/v1/events             - Stream task events via SSE (protected, or ?ticket=)")
+	log.Println("  GET    /api/v1/recurrences        - List recurring schedules (protected)")
+	log.Println("  GET    /api/v1/recurrences/:id    - Get schedule and occurrences (protected)")
+	log.Println("  DELETE /api/v1/recurrences/:id    - Stop a recurring schedule (protected)")
+	log.Println("  POST   /api/v1/labels             - Create label (protected)")
+	log.Println("  GET    /api/v1/labels             - Get all labels (protected)")
+	log.Println("  PUT    /api/v1/labels/:id         - Update label (protected)")
+	log.Println("  DELETE /api/v1/labels/:id         - Delete label (protected)")
+	log.Println("  POST   /api/v1/webhooks           - Register webhook (protected)")
+	log.Println("  GET    /api/v1/webhooks           - Get webhooks (protected)")
+	log.Println("  PUT    /api/v1/webhooks/:id       - Update webhook (protected)")
+	log.Println("  DELETE /api/v1/webhooks/:id       - Delete webhook (protected)")
+	log.Println("  GET    /api/v1/webhooks/:id/deliveries - Get webhook delivery log (protected)")
+	log.Println("  POST   /api/v1/calendar/token     - Create calendar feed URL (protected)")
+	log.Println("  DELETE /api/v1/calendar/token     - Disable calendar feed URL (protected)")
+	log.Println("  GET    /api/v1/calendar/:token.ics - iCalendar feed of tasks with due dates (token in URL)")
+	log.Println("  POST   /api/v1/views              - Create saved view (protected)")
+	log.Println("  GET    /api/v1/views              - Get saved views (protected)")
+	log.Println("  GET    /api/v1/views/:id          - Get saved view (protected)")
+	log.Println("  GET    /api/v1/views/:id/tasks    - Get tasks matching a view (protected)")
+	log.Println("  PUT    /api/v1/views/:id          - Update saved view (protected)")
+	log.Println("  DELETE /api/v1/views/:id          - Delete saved view (protected)")
 	log.Println(strings.Repeat("=", 60) + "\n")
 
 	// Start server
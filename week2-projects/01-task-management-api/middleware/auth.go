@@ -1,10 +1,14 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"task-management-api/config"
+	"task-management-api/database"
+	"task-management-api/models"
 	"task-management-api/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -44,10 +48,66 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens whose session was revoked by logout
+		if isSessionRevoked(c.Request.Context(), claims.SessionID, claims.UserID) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Session has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
 		// Add user info to context for use in handlers
 		c.Set("user_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
 		c.Set("username", claims.Username)
 		c.Set("email", claims.Email)
+		if claims.ExpiresAt != nil {
+			c.Set("token_expires_at", claims.ExpiresAt.Time)
+		}
+
+		c.Next()
+	}
+}
+
+// EventStreamAuthMiddleware authenticates event streams with a ticket from
+// the ticket query parameter, for clients like the browser EventSource that
+// cannot send headers, or else with the Authorization header like
+// AuthMiddleware. Tickets are bound to a session and refused once it is
+// revoked.
+func EventStreamAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	authenticate := AuthMiddleware(cfg)
+
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			authenticate(c)
+			return
+		}
+
+		claims, err := utils.ValidateTicket(ticket, cfg.JWT.Secret)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired ticket",
+			})
+			c.Abort()
+			return
+		}
+
+		// Reject tickets whose session was revoked by logout
+		if isSessionRevoked(c.Request.Context(), claims.SessionID, claims.UserID) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Session has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
+		if claims.StreamUntil != nil {
+			c.Set("token_expires_at", claims.StreamUntil.Time)
+		}
 
 		c.Next()
 	}
@@ -62,3 +122,37 @@ func GetUserID(c *gin.Context) (uint, bool) {
 	id, ok := userID.(uint)
 	return id, ok
 }
+
+// GetTokenExpiry retrieves the expiry time of the current access token from the Gin context
+func GetTokenExpiry(c *gin.Context) (time.Time, bool) {
+	expiresAt, exists := c.Get("token_expires_at")
+	if !exists {
+		return time.Time{}, false
+	}
+	t, ok := expiresAt.(time.Time)
+	return t, ok
+}
+
+// GetSessionID retrieves the session ID of the current access token from the Gin context
+func GetSessionID(c *gin.Context) (uint, bool) {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return 0, false
+	}
+	id, ok := sessionID.(uint)
+	return id, ok
+}
+
+// isSessionRevoked checks the session revocation list. Tokens without a
+// session, or whose session is revoked, expired or unknown, are rejected.
+func isSessionRevoked(ctx context.Context, sessionID, userID uint) bool {
+	if sessionID == 0 {
+		return true
+	}
+
+	var session models.Session
+	if err := database.DB.WithContext(ctx).Select("id", "user_id", "expires_at", "revoked_at").First(&session, sessionID).Error; err != nil {
+		return true
+	}
+	return session.UserID != userID || !session.IsActive()
+}
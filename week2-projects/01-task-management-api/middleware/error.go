@@ -1,20 +1,36 @@
 package middleware
 
 import (
-	"log"
+	"fmt"
 	"net/http"
+	"runtime/debug"
+	"task-management-api/logging"
 
 	"github.com/gin-gonic/gin"
 )
 
-// ErrorHandlerMiddleware handles errors and panics gracefully
+// panicKey marks requests whose handler panicked, for the metrics middleware
+const panicKey = "panic_recovered"
+
+// ErrorHandlerMiddleware handles errors and panics gracefully. Panics are
+// logged with the request they happened in and the stack, and the client gets
+// the request ID to quote when reporting the error.
 func ErrorHandlerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("PANIC RECOVERED: %v", err)
+				ctx := c.Request.Context()
+				logging.FromContext(ctx).ErrorContext(ctx, "panic recovered",
+					"panic", fmt.Sprint(err),
+					"method", c.Request.Method,
+					"path", loggedPath(c),
+					"route", c.FullPath(),
+					"stack", string(debug.Stack()),
+				)
+				c.Set(panicKey, true)
 				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": "Internal server error",
+					"error":      "Internal server error",
+					"request_id": GetRequestID(c),
 				})
 				c.Abort()
 			}
@@ -25,7 +41,8 @@ func ErrorHandlerMiddleware() gin.HandlerFunc {
 		// Check if there were any errors during request processing
 		if len(c.Errors) > 0 {
 			err := c.Errors.Last()
-			log.Printf("Request error: %v", err.Error())
+			ctx := c.Request.Context()
+			logging.FromContext(ctx).WarnContext(ctx, "request error", "error", err.Error())
 
 			// Return error response
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -40,7 +57,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
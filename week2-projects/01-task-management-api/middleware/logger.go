@@ -1,13 +1,21 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
+	"strings"
+	"task-management-api/logging"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// LoggerMiddleware logs incoming requests with details
+// secretParams are route parameters holding credentials, such as the token of
+// a calendar feed URL, which never go into the logs
+var secretParams = []string{"feed"}
+
+// LoggerMiddleware logs every request as a structured line with its request
+// ID. Server errors are logged as errors, client errors as warnings. Secret
+// route parameters are redacted from the logged path.
 func LoggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Start timer
@@ -22,14 +30,45 @@ func LoggerMiddleware() gin.HandlerFunc {
 		// Get status code
 		statusCode := c.Writer.Status()
 
+		level := slog.LevelInfo
+		switch {
+		case statusCode >= 500:
+			level = slog.LevelError
+		case statusCode >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", loggedPath(c)),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", statusCode),
+			slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if userID, ok := GetUserID(c); ok {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(userID)))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
 		// Log request details
-		log.Printf("[%s] %s %s | Status: %d | Latency: %v | IP: %s",
-			c.Request.Method,
-			c.Request.URL.Path,
-			c.Request.Proto,
-			statusCode,
-			latency,
-			c.ClientIP(),
-		)
+		ctx := c.Request.Context()
+		logging.FromContext(ctx).LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// loggedPath returns the request path with the values of secret route
+// parameters redacted
+func loggedPath(c *gin.Context) string {
+	path := c.Request.URL.Path
+	for _, name := range secretParams {
+		if value := c.Param(name); value != "" {
+			path = strings.Replace(path, value, "[REDACTED]", 1)
+		}
 	}
+	return path
 }
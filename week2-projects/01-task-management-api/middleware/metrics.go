@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"task-management-api/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware records the count, latency and errors of requests per
+// route. Requests matching no route share one label, so that scanners cannot
+// create a series per path.
+func MetricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.InFlight.Inc()
+		defer m.InFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		status := c.Writer.Status()
+
+		m.Requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		m.RequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		switch {
+		case status >= 500:
+			m.RequestErrors.WithLabelValues(method, route, "server").Inc()
+		case status >= 400:
+			m.RequestErrors.WithLabelValues(method, route, "client").Inc()
+		}
+		if c.GetBool(panicKey) {
+			m.Panics.Inc()
+		}
+	}
+}
+
+// MetricsAuthMiddleware requires the bearer token to scrape metrics. An empty
+// token leaves the endpoint open, for example when it is only reachable from
+// the monitoring network.
+func MetricsAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		expected := "Bearer " + token
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte(expected)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or missing metrics token",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"task-management-api/config"
+	"task-management-api/database"
+	"task-management-api/migrations"
+	"time"
+)
+
+// migrateUsage describes the migrate subcommand
+const migrateUsage = `Usage: task-api migrate <command>
+
+Commands:
+  up          Apply all pending migrations (default)
+  down [N]    Roll back the last N migrations (default 1, "all" for every one)
+  status      List migrations and when they were applied`
+
+// runMigrate runs the migrate subcommand and exits with an error on failure
+func runMigrate(cfg *config.Config, args []string) {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	if err := database.Connect(cfg.Database); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.CloseDatabase()
+
+	switch command {
+	case "up":
+		applied, err := migrations.Up(database.DB)
+		if err != nil {
+			log.Fatalf("Migration failed after %d applied: %v", applied, err)
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if args[1] == "all" {
+				steps = len(migrations.All())
+			} else {
+				n, err := strconv.Atoi(args[1])
+				if err != nil || n < 1 {
+					log.Fatalf("Invalid number of migrations to roll back: %q", args[1])
+				}
+				steps = n
+			}
+		}
+		reverted, err := migrations.Down(database.DB, steps)
+		if err != nil {
+			log.Fatalf("Rollback failed after %d rolled back: %v", reverted, err)
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", reverted)
+
+	case "status":
+		statuses, err := migrations.Statuses(database.DB)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-30s %s\n", status.Version, status.Name, state)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,243 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// initialSchema creates the schema as it was when versioned migrations were
+// introduced. It uses AutoMigrate so that databases created by the earlier
+// AutoMigrate-based startup are adopted as they are.
+var initialSchema = Migration{
+	Version: 1,
+	Name:    "initial_schema",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(v1Tables()...)
+	},
+	Down: func(tx *gorm.DB) error {
+		tables := v1Tables()
+		for i := len(tables) - 1; i >= 0; i-- {
+			if err := tx.Migrator().DropTable(tables[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// v1Tables lists the tables of the initial schema in creation order
+func v1Tables() []interface{} {
+	return []interface{}{
+		&v1User{},
+		&v1Task{},
+		&v1RecurrenceRule{},
+		&v1Label{},
+		&v1TaskLabel{},
+		&v1SavedView{},
+		&v1Session{},
+		&v1Activity{},
+		&v1Comment{},
+		&v1Attachment{},
+		&v1Webhook{},
+		&v1WebhookDelivery{},
+		&v1TaskNotification{},
+		&v1CalendarToken{},
+	}
+}
+
+type v1User struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Username  string         `gorm:"unique;not null"`
+	Email     string         `gorm:"unique;not null"`
+	Password  string         `gorm:"not null"`
+}
+
+func (v1User) TableName() string { return "users" }
+
+type v1Task struct {
+	ID               uint `gorm:"primarykey"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+	Title            string         `gorm:"not null"`
+	Description      string
+	Priority         string `gorm:"type:varchar(20);default:'medium'"`
+	Status           string `gorm:"type:varchar(20);default:'todo'"`
+	DueDate          *time.Time
+	UserID           uint  `gorm:"not null"`
+	RecurrenceRuleID *uint `gorm:"index"`
+}
+
+func (v1Task) TableName() string { return "tasks" }
+
+type v1RecurrenceRule struct {
+	ID               uint `gorm:"primarykey"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+	UserID           uint           `gorm:"not null;index"`
+	Frequency        string         `gorm:"type:varchar(20);not null"`
+	Interval         int            `gorm:"not null;default:1"`
+	Weekdays         string         `gorm:"type:varchar(30)"`
+	MonthDay         int
+	TimeZone         string    `gorm:"type:varchar(64);not null;default:'UTC'"`
+	StartAt          time.Time `gorm:"not null"`
+	Until            *time.Time
+	LastOccurrenceAt time.Time `gorm:"not null"`
+	Title            string    `gorm:"not null"`
+	Description      string
+	Priority         string `gorm:"type:varchar(20);default:'medium'"`
+}
+
+func (v1RecurrenceRule) TableName() string { return "recurrence_rules" }
+
+type v1Label struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Name      string         `gorm:"not null"`
+	Color     string         `gorm:"type:varchar(7);not null"`
+	UserID    uint           `gorm:"not null;index"`
+}
+
+func (v1Label) TableName() string { return "labels" }
+
+type v1TaskLabel struct {
+	TaskID  uint `gorm:"primaryKey;autoIncrement:false"`
+	LabelID uint `gorm:"primaryKey;autoIncrement:false"`
+}
+
+func (v1TaskLabel) TableName() string { return "task_labels" }
+
+type v1TaskFilter struct {
+	Status     string
+	Priority   string
+	Labels     string
+	LabelMatch string
+	SortBy     string
+	Order      string
+}
+
+type v1SavedView struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Name      string         `gorm:"not null"`
+	Filters   v1TaskFilter   `gorm:"embedded;embeddedPrefix:filter_"`
+	UserID    uint           `gorm:"not null;index"`
+}
+
+func (v1SavedView) TableName() string { return "saved_views" }
+
+type v1Session struct {
+	ID                uint `gorm:"primarykey"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	UserID            uint      `gorm:"not null;index"`
+	RefreshTokenHash  string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	PreviousTokenHash string    `gorm:"type:varchar(64);index"`
+	ExpiresAt         time.Time `gorm:"not null"`
+	RevokedAt         *time.Time
+	LastUsedAt        time.Time
+	UserAgent         string
+	IPAddress         string `gorm:"type:varchar(45)"`
+}
+
+func (v1Session) TableName() string { return "sessions" }
+
+type v1Activity struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	TaskID    uint      `gorm:"not null;index"`
+	UserID    uint      `gorm:"not null;index"`
+	ActorID   *uint
+	Action    string `gorm:"type:varchar(20);not null"`
+	Changes   string `gorm:"type:text"`
+}
+
+func (v1Activity) TableName() string { return "activities" }
+
+type v1Comment struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	TaskID    uint           `gorm:"not null;index"`
+	UserID    uint           `gorm:"not null;index"`
+	ParentID  *uint          `gorm:"index"`
+	Body      string         `gorm:"type:text;not null"`
+	Edited    bool           `gorm:"not null;default:false"`
+}
+
+func (v1Comment) TableName() string { return "comments" }
+
+type v1Attachment struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	TaskID      uint           `gorm:"not null;index"`
+	UserID      uint           `gorm:"not null;index"`
+	FileName    string         `gorm:"not null"`
+	ContentType string         `gorm:"type:varchar(100);not null"`
+	Size        int64          `gorm:"not null"`
+	StorageKey  string         `gorm:"type:varchar(64);uniqueIndex;not null"`
+}
+
+func (v1Attachment) TableName() string { return "attachments" }
+
+type v1Webhook struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	UserID    uint           `gorm:"not null;index"`
+	URL       string         `gorm:"not null"`
+	Secret    string         `gorm:"not null"`
+	Events    string         `gorm:"type:varchar(200)"`
+	Active    bool           `gorm:"not null;default:true"`
+}
+
+func (v1Webhook) TableName() string { return "webhooks" }
+
+type v1WebhookDelivery struct {
+	ID             uint `gorm:"primarykey"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	WebhookID      uint      `gorm:"not null;index"`
+	Event          string    `gorm:"type:varchar(50);not null"`
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"type:varchar(20);not null;index"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"index"`
+	LastAttemptAt  *time.Time
+	ResponseStatus int
+	LastError      string
+}
+
+func (v1WebhookDelivery) TableName() string { return "webhook_deliveries" }
+
+type v1TaskNotification struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	TaskID    uint      `gorm:"not null;uniqueIndex:idx_task_notification"`
+	Kind      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_task_notification"`
+	DueAt     time.Time `gorm:"not null;uniqueIndex:idx_task_notification"`
+}
+
+func (v1TaskNotification) TableName() string { return "task_notifications" }
+
+type v1CalendarToken struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"not null;uniqueIndex"`
+	TokenHash string `gorm:"type:varchar(64);not null;uniqueIndex"`
+}
+
+func (v1CalendarToken) TableName() string { return "calendar_tokens" }
@@ -0,0 +1,95 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// kanbanWorkflows adds per-user workflows with custom columns and places
+// tasks in a column with a rank. Existing tasks are assigned to the default
+// columns when their owner's workflow is first created.
+var kanbanWorkflows = Migration{
+	Version: 2,
+	Name:    "kanban_workflows",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().CreateTable(&v2Workflow{}, &v2WorkflowColumn{}, &v2WorkflowTransition{}); err != nil {
+			return err
+		}
+		for _, field := range []string{"ColumnID", "Rank"} {
+			if err := tx.Migrator().AddColumn(&v2Task{}, field); err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().CreateIndex(&v2Task{}, "idx_tasks_column_rank")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&v2Task{}, "idx_tasks_column_rank"); err != nil {
+			return err
+		}
+		for _, field := range []string{"Rank", "ColumnID"} {
+			if err := tx.Migrator().DropColumn(&v2Task{}, field); err != nil {
+				return err
+			}
+		}
+
+		// SQLite drops columns by rebuilding the table, which loses its indexes
+		if err := restoreTaskIndexes(tx); err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable(&v2WorkflowTransition{}, &v2WorkflowColumn{}, &v2Workflow{})
+	},
+}
+
+type v2Workflow struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint `gorm:"not null;uniqueIndex"`
+}
+
+func (v2Workflow) TableName() string { return "workflows" }
+
+type v2WorkflowColumn struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	WorkflowID uint   `gorm:"not null;uniqueIndex:idx_workflow_column_key"`
+	Key        string `gorm:"type:varchar(50);not null;uniqueIndex:idx_workflow_column_key"`
+	Name       string `gorm:"type:varchar(100);not null"`
+	Category   string `gorm:"type:varchar(20);not null"`
+	Position   int    `gorm:"not null"`
+}
+
+func (v2WorkflowColumn) TableName() string { return "workflow_columns" }
+
+type v2WorkflowTransition struct {
+	ID           uint `gorm:"primarykey"`
+	WorkflowID   uint `gorm:"not null;index"`
+	FromColumnID uint `gorm:"not null"`
+	ToColumnID   uint `gorm:"not null"`
+}
+
+func (v2WorkflowTransition) TableName() string { return "workflow_transitions" }
+
+// v2Task holds only the task columns added by this migration
+type v2Task struct {
+	ID       uint   `gorm:"primarykey"`
+	ColumnID *uint  `gorm:"index:idx_tasks_column_rank,priority:1"`
+	Rank     string `gorm:"type:varchar(255);not null;default:'';index:idx_tasks_column_rank,priority:2"`
+}
+
+func (v2Task) TableName() string { return "tasks" }
+
+// restoreTaskIndexes recreates the indexes of the initial tasks table that are
+// missing. Migrations call it after dropping task columns.
+func restoreTaskIndexes(tx *gorm.DB) error {
+	for _, field := range []string{"DeletedAt", "RecurrenceRuleID"} {
+		if !tx.Migrator().HasIndex(&v1Task{}, field) {
+			if err := tx.Migrator().CreateIndex(&v1Task{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// timeTracking adds time entries and task estimates
+var timeTracking = Migration{
+	Version: 3,
+	Name:    "time_tracking",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().CreateTable(&v3TimeEntry{}); err != nil {
+			return err
+		}
+		return tx.Migrator().AddColumn(&v3Task{}, "EstimateMinutes")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropColumn(&v3Task{}, "EstimateMinutes"); err != nil {
+			return err
+		}
+
+		// SQLite drops columns by rebuilding the table, which loses its indexes
+		if err := restoreTaskIndexes(tx); err != nil {
+			return err
+		}
+		if !tx.Migrator().HasIndex(&v2Task{}, "idx_tasks_column_rank") {
+			if err := tx.Migrator().CreateIndex(&v2Task{}, "idx_tasks_column_rank"); err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().DropTable(&v3TimeEntry{})
+	},
+}
+
+type v3TimeEntry struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	TaskID    uint      `gorm:"not null;index"`
+	UserID    uint      `gorm:"not null;index;uniqueIndex:idx_time_entries_running,where:ended_at IS NULL"`
+	StartedAt time.Time `gorm:"not null;index"`
+	EndedAt   *time.Time
+	Seconds   int64  `gorm:"not null;default:0"`
+	Note      string `gorm:"type:varchar(500)"`
+	Manual    bool   `gorm:"not null;default:false"`
+}
+
+func (v3TimeEntry) TableName() string { return "time_entries" }
+
+// v3Task holds only the task column added by this migration
+type v3Task struct {
+	ID              uint `gorm:"primarykey"`
+	EstimateMinutes *int
+}
+
+func (v3Task) TableName() string { return "tasks" }
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// accountLifecycle adds email verification, password reset tokens and login
+// lockout. Existing accounts predate verification and count as verified.
+var accountLifecycle = Migration{
+	Version: 4,
+	Name:    "account_lifecycle",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().CreateTable(&v4AccountToken{}); err != nil {
+			return err
+		}
+		for _, field := range []string{"EmailVerifiedAt", "FailedLogins", "LockedUntil"} {
+			if err := tx.Migrator().AddColumn(&v4User{}, field); err != nil {
+				return err
+			}
+		}
+		return tx.Exec("UPDATE users SET email_verified_at = created_at").Error
+	},
+	Down: func(tx *gorm.DB) error {
+		for _, field := range []string{"LockedUntil", "FailedLogins", "EmailVerifiedAt"} {
+			if err := tx.Migrator().DropColumn(&v4User{}, field); err != nil {
+				return err
+			}
+		}
+
+		// SQLite drops columns by rebuilding the table, which loses its indexes
+		if !tx.Migrator().HasIndex(&v1User{}, "DeletedAt") {
+			if err := tx.Migrator().CreateIndex(&v1User{}, "DeletedAt"); err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().DropTable(&v4AccountToken{})
+	},
+}
+
+type v4AccountToken struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UserID    uint      `gorm:"not null;index"`
+	Purpose   string    `gorm:"type:varchar(20);not null"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+}
+
+func (v4AccountToken) TableName() string { return "account_tokens" }
+
+// v4User holds only the user columns added by this migration
+type v4User struct {
+	ID              uint `gorm:"primarykey"`
+	EmailVerifiedAt *time.Time
+	FailedLogins    int `gorm:"not null;default:0"`
+	LockedUntil     *time.Time
+}
+
+func (v4User) TableName() string { return "users" }
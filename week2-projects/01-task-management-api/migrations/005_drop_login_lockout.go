@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// dropLoginLockout drops the failed login columns of users. Failed logins are
+// now counted per client address in memory, so that guesses from one address
+// cannot lock the owner out of their account.
+var dropLoginLockout = Migration{
+	Version: 5,
+	Name:    "drop_login_lockout",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"LockedUntil", "FailedLogins"} {
+			if err := tx.Migrator().DropColumn(&v5User{}, field); err != nil {
+				return err
+			}
+		}
+
+		// SQLite drops columns by rebuilding the table, which loses its indexes
+		if !tx.Migrator().HasIndex(&v1User{}, "DeletedAt") {
+			return tx.Migrator().CreateIndex(&v1User{}, "DeletedAt")
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		for _, field := range []string{"FailedLogins", "LockedUntil"} {
+			if err := tx.Migrator().AddColumn(&v5User{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// v5User holds only the user columns dropped by this migration
+type v5User struct {
+	ID           uint `gorm:"primarykey"`
+	FailedLogins int  `gorm:"not null;default:0"`
+	LockedUntil  *time.Time
+}
+
+func (v5User) TableName() string { return "users" }
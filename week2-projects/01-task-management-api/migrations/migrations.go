@@ -0,0 +1,213 @@
+// Package migrations holds the versioned database schema. Each migration
+// describes its tables with its own snapshot structs instead of the models, so
+// later model changes never alter what an applied migration does.
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// registry lists all migrations in version order. Append new migrations at
+// the end and never change one that has been released.
+var registry = []Migration{
+	initialSchema,
+	kanbanWorkflows,
+	timeTracking,
+	accountLifecycle,
+	dropLoginLockout,
+}
+
+// Record is a row of the schema_migrations table
+type Record struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName overrides the table name used by Record
+func (Record) TableName() string {
+	return "schema_migrations"
+}
+
+// Status describes a migration and whether it has been applied
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // Nil while the migration is pending
+}
+
+// lockKey identifies the Postgres advisory lock held while migrating, so that
+// several instances starting at once do not run the same migration
+const lockKey = 4_711_034
+
+// All returns every known migration in version order
+func All() []Migration {
+	return append([]Migration(nil), registry...)
+}
+
+// Up applies all pending migrations in order, each in its own transaction,
+// and returns how many were applied
+func Up(db *gorm.DB) (int, error) {
+	if err := ensureTable(db); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, migration := range registry {
+		ran, err := apply(db, migration)
+		if err != nil {
+			return applied, err
+		}
+		if ran {
+			applied++
+		}
+	}
+	return applied, nil
+}
+
+// Down rolls back up to steps migrations, newest first, and returns how many
+// were rolled back
+func Down(db *gorm.DB, steps int) (int, error) {
+	if err := ensureTable(db); err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	for reverted < steps {
+		ran, err := revertLatest(db)
+		if err != nil {
+			return reverted, err
+		}
+		if !ran {
+			break
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// Statuses lists every known migration with the time it was applied
+func Statuses(db *gorm.DB) ([]Status, error) {
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	if err := db.Order("version asc").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	appliedAt := make(map[int]time.Time, len(records))
+	for _, record := range records {
+		appliedAt[record.Version] = record.AppliedAt
+	}
+
+	statuses := make([]Status, len(registry))
+	for i, migration := range registry {
+		statuses[i] = Status{Version: migration.Version, Name: migration.Name}
+		if at, ok := appliedAt[migration.Version]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// apply runs one migration unless it was already applied
+func apply(db *gorm.DB, migration Migration) (bool, error) {
+	ran := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lock(tx); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&Record{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check migration %d: %w", migration.Version, err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if err := migration.Up(tx); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		record := Record{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+		if err := tx.Create(&record).Error; err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+		ran = true
+		return nil
+	})
+	return ran, err
+}
+
+// revertLatest rolls back the most recently applied migration, if any
+func revertLatest(db *gorm.DB) (bool, error) {
+	ran := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lock(tx); err != nil {
+			return err
+		}
+
+		var record Record
+		err := tx.Order("version desc").First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load applied migrations: %w", err)
+		}
+
+		migration, ok := find(record.Version)
+		if !ok {
+			return fmt.Errorf("applied migration %d (%s) is unknown to this build", record.Version, record.Name)
+		}
+		if err := migration.Down(tx); err != nil {
+			return fmt.Errorf("rollback of migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		if err := tx.Delete(&record).Error; err != nil {
+			return fmt.Errorf("failed to record rollback of migration %d: %w", migration.Version, err)
+		}
+		ran = true
+		return nil
+	})
+	return ran, err
+}
+
+// find returns the migration with the given version
+func find(version int) (Migration, bool) {
+	for _, migration := range registry {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// ensureTable creates the schema_migrations table if needed
+func ensureTable(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Record{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// lock serializes migrations across processes for the rest of the transaction.
+// SQLite already allows only one writer at a time.
+func lock(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// AccountTokenPurpose is what an account token can be used for
+type AccountTokenPurpose string
+
+const (
+	TokenVerifyEmail   AccountTokenPurpose = "verify_email"
+	TokenResetPassword AccountTokenPurpose = "reset_password"
+)
+
+// AccountToken is a single-use token sent by email to verify an address or
+// reset a password. Only its hash is stored.
+type AccountToken struct {
+	ID        uint                `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time           `json:"created_at"`
+	UserID    uint                `gorm:"not null;index" json:"-"`
+	Purpose   AccountTokenPurpose `gorm:"type:varchar(20);not null" json:"purpose"`
+	TokenHash string              `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time           `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time          `json:"used_at,omitempty"`
+}
+
+// VerifyEmailRequest represents the payload for verifying an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailRequest represents a request that only names an account by email,
+// such as resending a verification link or requesting a password reset
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the payload for setting a new password with
+// a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8,max=72"` // See utils.ValidatePassword
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ActivityAction represents the kind of change recorded in the activity log
+type ActivityAction string
+
+const (
+	ActionCreated ActivityAction = "created"
+	ActionUpdated ActivityAction = "updated"
+	ActionDeleted ActivityAction = "deleted"
+)
+
+// FieldChange describes the old and new value of a single task field
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// ActivityChanges is a list of field changes stored as JSON
+type ActivityChanges []FieldChange
+
+// Value implements driver.Valuer so the changes are stored as a JSON string
+func (c ActivityChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+// Scan implements sql.Scanner to load the changes from a JSON column
+func (c *ActivityChanges) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	case nil:
+		*c = nil
+		return nil
+	default:
+		return errors.New("unsupported type for ActivityChanges")
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// Activity is an append-only log entry for a change to a task. Entries are
+// never updated or deleted, and outlive the task they refer to.
+type Activity struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time       `gorm:"index" json:"created_at"`
+	TaskID    uint            `gorm:"not null;index" json:"task_id"`
+	UserID    uint            `gorm:"not null;index" json:"user_id"` // Owner of the task, used for the feed
+	ActorID   *uint           `json:"actor_id"`                      // User who made the change; null for system changes
+	Action    ActivityAction  `gorm:"type:varchar(20);not null" json:"action"`
+	Changes   ActivityChanges `gorm:"type:text" json:"changes"`
+}
+
+// PaginationParams represents query parameters for paginated lists
+type PaginationParams struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// ActivityPage represents a page of activity entries
+type ActivityPage struct {
+	Items    []Activity `json:"items"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+	Total    int64      `json:"total"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "encoding/json"
+
+// BatchOp is the kind of a batch operation
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// BatchMode controls how failures inside a batch are handled
+type BatchMode string
+
+const (
+	BatchAtomic     BatchMode = "atomic"      // All operations succeed or none are applied
+	BatchBestEffort BatchMode = "best_effort" // Each operation is applied on its own
+)
+
+// BatchItemStatus is the outcome of one batch operation
+type BatchItemStatus string
+
+const (
+	BatchItemSucceeded  BatchItemStatus = "succeeded"
+	BatchItemFailed     BatchItemStatus = "failed"
+	BatchItemRolledBack BatchItemStatus = "rolled_back" // Succeeded, but undone because another operation failed
+	BatchItemSkipped    BatchItemStatus = "skipped"     // Not attempted because an earlier operation failed
+)
+
+// BatchOperation is one create, update or delete in a batch request. Data
+// holds a CreateTaskRequest for create and an UpdateTaskRequest for update.
+type BatchOperation struct {
+	Op   BatchOp         `json:"op" binding:"required,oneof=create update delete"`
+	ID   uint            `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// BatchRequest represents the payload for running several task operations at once
+type BatchRequest struct {
+	Mode       BatchMode        `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// BatchResult is the outcome of one operation, in request order
+type BatchResult struct {
+	Index  int             `json:"index"`
+	Op     BatchOp         `json:"op"`
+	ID     uint            `json:"id,omitempty"`
+	Status BatchItemStatus `json:"status"`
+	Code   int             `json:"code"` // HTTP status the single-task endpoint would have returned
+	Error  string          `json:"error,omitempty"`
+	Task   *Task           `json:"task,omitempty"`
+}
+
+// BatchResponse is the response of a batch request
+type BatchResponse struct {
+	Mode      BatchMode     `json:"mode"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
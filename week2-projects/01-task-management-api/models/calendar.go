@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// CalendarToken grants read access to a user's iCalendar feed. Calendar apps
+// cannot send an Authorization header, so the secret token is part of the
+// feed URL. Only its hash is stored.
+type CalendarToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+}
+
+// TaskExportParams represents query parameters for exporting tasks
+type TaskExportParams struct {
+	TaskFilterParams
+	Format string `form:"format" binding:"omitempty,oneof=csv json"`
+}
+
+// ImportRowError describes why a row of an import file was rejected
+type ImportRowError struct {
+	Row   int    `json:"row"`            // 1-based position in the file's rows
+	Line  int    `json:"line,omitempty"` // Line number for CSV files
+	Error string `json:"error"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment represents a comment on a task. Replies point to their parent
+// comment, forming a thread.
+type Comment struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	TaskID    uint           `gorm:"not null;index" json:"task_id"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"` // Author
+	ParentID  *uint          `gorm:"index" json:"parent_id,omitempty"`
+	Body      string         `gorm:"type:text;not null" json:"body"`
+	Edited    bool           `gorm:"not null;default:false" json:"edited"`
+	Replies   []*Comment     `gorm:"-" json:"replies,omitempty"` // Filled in when listing a thread
+}
+
+// CreateCommentRequest represents the payload for creating a comment
+type CreateCommentRequest struct {
+	Body     string `json:"body" binding:"required,min=1,max=5000"`
+	ParentID *uint  `json:"parent_id"` // Comment being replied to
+}
+
+// UpdateCommentRequest represents the payload for editing a comment
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=5000"`
+}
+
+// Attachment represents a file uploaded to a task. The file content lives in
+// attachment storage under StorageKey.
+type Attachment struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	TaskID      uint           `gorm:"not null;index" json:"task_id"`
+	UserID      uint           `gorm:"not null;index" json:"user_id"` // Uploader
+	FileName    string         `gorm:"not null" json:"file_name"`
+	ContentType string         `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int64          `gorm:"not null" json:"size"`
+	StorageKey  string         `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Label represents a user-defined tag that can be attached to tasks
+type Label struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	Name      string         `gorm:"not null" json:"name"`
+	Color     string         `gorm:"type:varchar(7);not null" json:"color"` // Hex color, e.g. "#ff8800"
+	UserID    uint           `gorm:"not null;index" json:"user_id"`
+}
+
+// CreateLabelRequest represents the payload for creating a label
+type CreateLabelRequest struct {
+	Name  string `json:"name" binding:"required,min=1,max=50"`
+	Color string `json:"color" binding:"required,hexcolor"`
+}
+
+// UpdateLabelRequest represents the payload for updating a label
+type UpdateLabelRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=1,max=50"`
+	Color *string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+// SavedView stores a named filter and sort combination for a user
+type SavedView struct {
+	ID        uint             `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	DeletedAt gorm.DeletedAt   `gorm:"index" json:"-"`
+	Name      string           `gorm:"not null" json:"name"`
+	Filters   TaskFilterParams `gorm:"embedded;embeddedPrefix:filter_" json:"filters"`
+	UserID    uint             `gorm:"not null;index" json:"user_id"`
+}
+
+// SavedViewRequest represents the payload for creating or replacing a saved view
+type SavedViewRequest struct {
+	Name    string           `json:"name" binding:"required,min=1,max=100"`
+	Filters TaskFilterParams `json:"filters"`
+}
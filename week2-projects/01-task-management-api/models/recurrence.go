@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecurrenceFrequency represents how often a recurring task repeats
+type RecurrenceFrequency string
+
+const (
+	FrequencyDaily   RecurrenceFrequency = "daily"
+	FrequencyWeekly  RecurrenceFrequency = "weekly"
+	FrequencyMonthly RecurrenceFrequency = "monthly"
+)
+
+// RecurrenceRule describes an RRULE-style schedule that generates task occurrences.
+// Occurrences are computed in the rule's time zone so that a task due "every
+// Monday at 09:00" stays at 09:00 local time across DST changes.
+type RecurrenceRule struct {
+	ID               uint                `gorm:"primarykey" json:"id"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt      `gorm:"index" json:"-"`
+	UserID           uint                `gorm:"not null;index" json:"user_id"`
+	Frequency        RecurrenceFrequency `gorm:"type:varchar(20);not null" json:"frequency"`
+	Interval         int                 `gorm:"not null;default:1" json:"interval"`
+	Weekdays         string              `gorm:"type:varchar(30)" json:"weekdays,omitempty"` // Comma-separated, e.g. "MO,WE,FR"
+	MonthDay         int                 `json:"month_day,omitempty"`
+	TimeZone         string              `gorm:"type:varchar(64);not null;default:'UTC'" json:"timezone"`
+	StartAt          time.Time           `gorm:"not null" json:"start_at"` // Due date of the first occurrence
+	Until            *time.Time          `json:"until,omitempty"`
+	LastOccurrenceAt time.Time           `gorm:"not null" json:"last_occurrence_at"` // Due date of the latest materialized occurrence
+
+	// Template copied onto every generated task
+	Title       string       `gorm:"not null" json:"title"`
+	Description string       `json:"description"`
+	Priority    TaskPriority `gorm:"type:varchar(20);default:'medium'" json:"priority"`
+}
+
+// WeekdayList returns the rule's weekdays as a slice
+func (r *RecurrenceRule) WeekdayList() []string {
+	if r.Weekdays == "" {
+		return nil
+	}
+	return strings.Split(r.Weekdays, ",")
+}
+
+// RecurrenceRequest represents the recurrence part of a task creation payload
+type RecurrenceRequest struct {
+	Frequency RecurrenceFrequency `json:"frequency" binding:"required,oneof=daily weekly monthly"`
+	Interval  int                 `json:"interval" binding:"omitempty,min=1,max=365"`
+	Weekdays  []string            `json:"weekdays" binding:"omitempty,dive,oneof=MO TU WE TH FR SA SU"`
+	MonthDay  int                 `json:"month_day" binding:"omitempty,min=1,max=31"`
+	TimeZone  string              `json:"timezone" binding:"omitempty,max=64"`
+	Until     *time.Time          `json:"until"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"` // Set on internal server errors, to quote when reporting them
+}
+
+// MessageResponse is the body of responses that only confirm an action
+type MessageResponse struct {
+	Message string `json:"message"`
+}
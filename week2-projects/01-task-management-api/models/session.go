@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// Session represents a login of a user on one device. Access tokens carry the
+// session ID, so revoking a session invalidates its access tokens immediately.
+// Only hashes of refresh tokens are stored.
+type Session struct {
+	ID                uint       `gorm:"primarykey" json:"id"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	UserID            uint       `gorm:"not null;index" json:"-"`
+	RefreshTokenHash  string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	PreviousTokenHash string     `gorm:"type:varchar(64);index" json:"-"` // Last rotated-out token, used to detect reuse
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt        time.Time  `json:"last_used_at"`
+	UserAgent         string     `json:"user_agent"`
+	IPAddress         string     `gorm:"type:varchar(45)" json:"ip_address"`
+}
+
+// IsActive reports whether the session can still be used
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// RefreshTokenRequest represents the payload for refreshing an access token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse represents the tokens returned after login, registration or refresh
+type TokenResponse struct {
+	Token        string `json:"token"` // Short-lived access token
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
+}
+
+// AuthResponse represents the response of a registration, login or email
+// verification. The tokens are left out when the user still has to verify
+// their email address before logging in.
+type AuthResponse struct {
+	Message      string       `json:"message"`
+	User         UserResponse `json:"user"`
+	Token        string       `json:"token,omitempty"`
+	RefreshToken string       `json:"refresh_token,omitempty"`
+	ExpiresIn    int64        `json:"expires_in,omitempty"`
+}
+
+// LogoutAllResponse represents the response of logging out of every session
+type LogoutAllResponse struct {
+	Message         string `json:"message"`
+	RevokedSessions int64  `json:"revoked_sessions"`
+}
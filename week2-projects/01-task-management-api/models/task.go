@@ -39,6 +39,14 @@ type Task struct {
 	DueDate     *time.Time     `json:"due_date,omitempty"` // Pointer to allow null values
 	UserID      uint           `gorm:"not null" json:"user_id"`
 	User        User           `gorm:"foreignKey:UserID" json:"-"` // Don't include full user in task response
+
+	RecurrenceRuleID *uint   `gorm:"index" json:"recurrence_rule_id,omitempty"` // Set when the task is an occurrence of a recurring schedule
+	Labels           []Label `gorm:"many2many:task_labels;" json:"labels"`
+
+	ColumnID *uint  `gorm:"index:idx_tasks_column_rank,priority:1" json:"column_id"`                                  // Workflow column the task is in
+	Rank     string `gorm:"type:varchar(255);not null;default:'';index:idx_tasks_column_rank,priority:2" json:"rank"` // Fractional index ordering tasks within the column
+
+	EstimateMinutes *int `json:"estimate_minutes,omitempty"` // Planned effort, compared with tracked time
 }
 
 // CreateTaskRequest represents the payload for creating a new task
@@ -48,6 +56,11 @@ type CreateTaskRequest struct {
 	Priority    *TaskPriority `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
 	Status      *TaskStatus   `json:"status" binding:"omitempty,oneof=todo in_progress completed cancelled"`
 	DueDate     *time.Time    `json:"due_date"`
+
+	Recurrence *RecurrenceRequest `json:"recurrence"` // Optional; requires due_date, which becomes the first occurrence
+	LabelIDs   []uint             `json:"label_ids" binding:"omitempty,max=20"`
+
+	EstimateMinutes *int `json:"estimate_minutes" binding:"omitempty,min=0,max=100000"` // 0 means no estimate
 }
 
 // UpdateTaskRequest represents the payload for updating an existing task
@@ -57,14 +70,19 @@ type UpdateTaskRequest struct {
 	Priority    *TaskPriority `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
 	Status      *TaskStatus   `json:"status" binding:"omitempty,oneof=todo in_progress completed cancelled"`
 	DueDate     *time.Time    `json:"due_date"`
+	LabelIDs    *[]uint       `json:"label_ids" binding:"omitempty,max=20"` // Replaces all labels when provided
+
+	EstimateMinutes *int `json:"estimate_minutes" binding:"omitempty,min=0,max=100000"` // 0 removes the estimate
 }
 
 // TaskFilterParams represents query parameters for filtering tasks
 type TaskFilterParams struct {
-	Status   string `form:"status" binding:"omitempty,oneof=todo in_progress completed cancelled"`
-	Priority string `form:"priority" binding:"omitempty,oneof=low medium high urgent"`
-	SortBy   string `form:"sort_by" binding:"omitempty,oneof=created_at due_date priority"`
-	Order    string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Status     string `form:"status" json:"status,omitempty" binding:"omitempty,oneof=todo in_progress completed cancelled"`
+	Priority   string `form:"priority" json:"priority,omitempty" binding:"omitempty,oneof=low medium high urgent"`
+	Labels     string `form:"labels" json:"labels,omitempty" binding:"omitempty,max=200"` // Comma-separated label IDs
+	LabelMatch string `form:"label_match" json:"label_match,omitempty" binding:"omitempty,oneof=any all"`
+	SortBy     string `form:"sort_by" json:"sort_by,omitempty" binding:"omitempty,oneof=created_at due_date priority"`
+	Order      string `form:"order" json:"order,omitempty" binding:"omitempty,oneof=asc desc"`
 }
 
 // TaskStats represents statistics about tasks
@@ -75,4 +93,6 @@ type TaskStats struct {
 	OverdueTasks    int64            `json:"overdue_tasks"`
 	TasksByPriority map[string]int64 `json:"tasks_by_priority"`
 	TasksByStatus   map[string]int64 `json:"tasks_by_status"`
+	TasksByColumn   map[string]int64 `json:"tasks_by_column"` // Keyed by workflow column key
+	Time            *TimeStats       `json:"time"`
 }
@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// TimeEntry is time spent on a task, either measured by a timer or entered
+// manually. A running timer has no EndedAt; each user can run only one.
+type TimeEntry struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	TaskID    uint       `gorm:"not null;index" json:"task_id"`
+	UserID    uint       `gorm:"not null;index;uniqueIndex:idx_time_entries_running,where:ended_at IS NULL" json:"user_id"`
+	StartedAt time.Time  `gorm:"not null;index" json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`                          // Nil while the timer runs
+	Seconds   int64      `gorm:"not null;default:0" json:"seconds"` // Stored once stopped; elapsed time while running
+	Note      string     `gorm:"type:varchar(500)" json:"note"`
+	Manual    bool       `gorm:"not null;default:false" json:"manual"`
+	Running   bool       `gorm:"-" json:"running"`
+}
+
+// TaskTime is the time tracked on one task
+type TaskTime struct {
+	TaskID          uint        `json:"task_id"`
+	EstimateMinutes *int        `json:"estimate_minutes"`
+	TrackedSeconds  int64       `json:"tracked_seconds"`
+	Entries         []TimeEntry `json:"entries"`
+}
+
+// StartTimerRequest represents the optional payload for starting a timer
+type StartTimerRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// CreateTimeEntryRequest represents the payload for logging time manually.
+// Give either ended_at or minutes.
+type CreateTimeEntryRequest struct {
+	StartedAt time.Time  `json:"started_at" binding:"required"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Minutes   *int       `json:"minutes" binding:"omitempty,min=1,max=1440"`
+	Note      string     `json:"note" binding:"max=500"`
+}
+
+// UpdateTimeEntryRequest represents the payload for correcting a time entry.
+// Only the note of a running timer can change.
+type UpdateTimeEntryRequest struct {
+	StartedAt *time.Time `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Minutes   *int       `json:"minutes" binding:"omitempty,min=1,max=1440"`
+	Note      *string    `json:"note" binding:"omitempty,max=500"`
+}
+
+// TimeReportParams represents query parameters for the time report in the
+// task statistics. Dates are YYYY-MM-DD in the time zone tz, to inclusive.
+type TimeReportParams struct {
+	GroupBy  string `form:"time_group_by" binding:"omitempty,oneof=day week task"`
+	From     string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To       string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	TimeZone string `form:"tz" binding:"omitempty,max=64"`
+}
+
+// TimeStats summarizes tracked time in the task statistics
+type TimeStats struct {
+	TrackedSeconds int64         `json:"tracked_seconds"` // Within from/to if given
+	From           string        `json:"from,omitempty"`
+	To             string        `json:"to,omitempty"`
+	TimeZone       string        `json:"time_zone"`
+	GroupBy        string        `json:"group_by,omitempty"`
+	Groups         []TimeGroup   `json:"groups,omitempty"`
+	RunningTimer   *TimeEntry    `json:"running_timer"`
+	Estimates      EstimateStats `json:"estimates"`
+}
+
+// TimeGroup is the time tracked in one day, week or task. Days and weeks are
+// keyed by their first date; weeks start on Monday.
+type TimeGroup struct {
+	Key             string `json:"key"`
+	TaskID          uint   `json:"task_id,omitempty"`
+	Title           string `json:"title,omitempty"`
+	EstimateMinutes *int   `json:"estimate_minutes,omitempty"`
+	Seconds         int64  `json:"seconds"`
+}
+
+// EstimateStats compares estimates with all time tracked on estimated tasks
+type EstimateStats struct {
+	EstimatedTasks   int64          `json:"estimated_tasks"`
+	EstimatedSeconds int64          `json:"estimated_seconds"`
+	ActualSeconds    int64          `json:"actual_seconds"`
+	OverEstimate     []TaskEstimate `json:"over_estimate"` // Most over first
+}
+
+// TaskEstimate is a task whose tracked time exceeds its estimate
+type TaskEstimate struct {
+	TaskID          uint   `json:"task_id"`
+	Title           string `json:"title"`
+	EstimateMinutes int    `json:"estimate_minutes"`
+	ActualSeconds   int64  `json:"actual_seconds"`
+	OverSeconds     int64  `json:"over_seconds"`
+}
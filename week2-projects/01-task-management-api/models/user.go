@@ -16,13 +16,21 @@ type User struct {
 	Email     string         `gorm:"unique;not null" json:"email"`
 	Password  string         `gorm:"not null" json:"-"` // Never expose password in JSON
 	Tasks     []Task         `gorm:"foreignKey:UserID" json:"tasks,omitempty"`
+
+	// Account lifecycle
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+}
+
+// IsVerified reports whether the user has verified their email address
+func (u *User) IsVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
 // UserRegisterRequest represents the registration request payload
 type UserRegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required,min=8,max=72"` // See utils.ValidatePassword
 }
 
 // UserLoginRequest represents the login request payload
@@ -33,18 +41,20 @@ type UserLoginRequest struct {
 
 // UserResponse represents the user data returned to clients (without password)
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            uint      `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 // ToResponse converts a User to UserResponse
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Username:  u.Username,
-		Email:     u.Email,
-		CreatedAt: u.CreatedAt,
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.IsVerified(),
+		CreatedAt:     u.CreatedAt,
 	}
 }
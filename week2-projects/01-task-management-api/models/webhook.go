@@ -0,0 +1,95 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvent represents an event that can be delivered to webhooks
+type WebhookEvent string
+
+const (
+	EventTaskCreated   WebhookEvent = "task.created"
+	EventTaskUpdated   WebhookEvent = "task.updated"
+	EventTaskCompleted WebhookEvent = "task.completed"
+	EventTaskOverdue   WebhookEvent = "task.overdue"
+	EventTaskReminder  WebhookEvent = "task.reminder"
+)
+
+// DeliveryStatus represents the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Webhook is a user-registered URL that receives task events
+type Webhook struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"`
+	URL       string         `gorm:"not null" json:"url"`
+	Secret    string         `gorm:"not null" json:"-"`               // HMAC signing key, only returned on creation
+	Events    string         `gorm:"type:varchar(200)" json:"events"` // Comma-separated; empty means all events
+	Active    bool           `gorm:"not null;default:true" json:"active"`
+}
+
+// Subscribes reports whether the webhook wants the given event
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	if w.Events == "" {
+		return true
+	}
+	for _, e := range strings.Split(w.Events, ",") {
+		if WebhookEvent(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for, or delivered to, a webhook. The
+// table doubles as the delivery log.
+type WebhookDelivery struct {
+	ID             uint           `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	WebhookID      uint           `gorm:"not null;index" json:"webhook_id"`
+	Event          WebhookEvent   `gorm:"type:varchar(50);not null" json:"event"`
+	Payload        string         `gorm:"type:text;not null" json:"payload"`
+	Status         DeliveryStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Attempts       int            `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time      `gorm:"index" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time     `json:"last_attempt_at,omitempty"`
+	ResponseStatus int            `json:"response_status,omitempty"`
+	LastError      string         `json:"last_error,omitempty"`
+}
+
+// TaskNotification records that a reminder or overdue notice was sent for a
+// task's due date, so each one fires only once. Changing the due date allows
+// the notices to fire again.
+type TaskNotification struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	TaskID    uint      `gorm:"not null;uniqueIndex:idx_task_notification" json:"task_id"`
+	Kind      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_task_notification" json:"kind"` // "overdue" or "reminder:<offset>"
+	DueAt     time.Time `gorm:"not null;uniqueIndex:idx_task_notification" json:"due_at"`
+}
+
+// CreateWebhookRequest represents the payload for registering a webhook
+type CreateWebhookRequest struct {
+	URL    string         `json:"url" binding:"required,url,max=500"`
+	Events []WebhookEvent `json:"events" binding:"omitempty,dive,oneof=task.created task.updated task.completed task.overdue task.reminder"`
+}
+
+// UpdateWebhookRequest represents the payload for updating a webhook
+type UpdateWebhookRequest struct {
+	URL    *string         `json:"url" binding:"omitempty,url,max=500"`
+	Events *[]WebhookEvent `json:"events" binding:"omitempty,dive,oneof=task.created task.updated task.completed task.overdue task.reminder"`
+	Active *bool           `json:"active"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Workflow is a user's kanban board: the columns tasks move through and the
+// moves allowed between them. Each column belongs to one of the fixed task
+// statuses, its category, so filters and statistics by status keep working.
+type Workflow struct {
+	ID          uint                 `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	UserID      uint                 `gorm:"not null;uniqueIndex" json:"user_id"`
+	Columns     []WorkflowColumn     `json:"columns"`
+	Transitions []WorkflowTransition `json:"transitions"` // Empty allows every move
+}
+
+// WorkflowColumn is a column of a board
+type WorkflowColumn struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	WorkflowID uint       `gorm:"not null;uniqueIndex:idx_workflow_column_key" json:"-"`
+	Key        string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_workflow_column_key" json:"key"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Category   TaskStatus `gorm:"type:varchar(20);not null" json:"category"`
+	Position   int        `gorm:"not null" json:"position"`
+}
+
+// WorkflowTransition allows tasks to move from one column to another
+type WorkflowTransition struct {
+	ID           uint   `gorm:"primarykey" json:"-"`
+	WorkflowID   uint   `gorm:"not null;index" json:"-"`
+	FromColumnID uint   `gorm:"not null" json:"from_column_id"`
+	ToColumnID   uint   `gorm:"not null" json:"to_column_id"`
+	From         string `gorm:"-" json:"from"` // Column keys, filled in when loaded
+	To           string `gorm:"-" json:"to"`
+}
+
+// WorkflowColumnRequest describes a column when replacing a workflow. Columns
+// are matched to existing ones by key.
+type WorkflowColumnRequest struct {
+	Key      string     `json:"key" binding:"required,min=1,max=50"`
+	Name     string     `json:"name" binding:"required,min=1,max=100"`
+	Category TaskStatus `json:"category" binding:"required,oneof=todo in_progress completed cancelled"`
+}
+
+// WorkflowTransitionRequest allows moves between two columns, named by key
+type WorkflowTransitionRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// UpdateWorkflowRequest represents the payload for replacing a workflow.
+// Columns are listed in board order.
+type UpdateWorkflowRequest struct {
+	Columns     []WorkflowColumnRequest     `json:"columns" binding:"required,min=1,max=20,dive"`
+	Transitions []WorkflowTransitionRequest `json:"transitions" binding:"omitempty,max=400,dive"`
+}
+
+// MoveTaskRequest represents the payload for moving a task on the board. The
+// task is placed directly after after_task_id or before before_task_id, or
+// at the bottom of the column when neither is given.
+type MoveTaskRequest struct {
+	ColumnID     uint  `json:"column_id" binding:"required"`
+	AfterTaskID  *uint `json:"after_task_id"`
+	BeforeTaskID *uint `json:"before_task_id"`
+}
+
+// BoardColumn is a workflow column with its tasks in board order
+type BoardColumn struct {
+	WorkflowColumn
+	Tasks []Task `json:"tasks"`
+}
+
+// Board is the user's workflow with all tasks placed in their columns
+type Board struct {
+	WorkflowID  uint                 `json:"workflow_id"`
+	Columns     []BoardColumn        `json:"columns"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
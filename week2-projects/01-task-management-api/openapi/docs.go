@@ -0,0 +1,266 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+)
+
+// methodOrder sorts the operations of a path
+var methodOrder = map[string]int{"get": 0, "post": 1, "put": 2, "patch": 3, "delete": 4}
+
+// docsSection is a tag with its operations
+type docsSection struct {
+	Tag        Tag
+	Operations []docsOperation
+}
+
+type docsOperation struct {
+	Method    string
+	Path      string
+	Operation *Operation
+	Responses []docsResponse
+}
+
+type docsResponse struct {
+	Status   string
+	Response Response
+}
+
+type docsSchema struct {
+	Name       string
+	Properties []docsProperty
+}
+
+type docsProperty struct {
+	Name        string
+	Schema      *Schema
+	Required    bool
+	Constraints string
+}
+
+// RenderDocs renders a self-contained HTML page documenting the operations
+// and schemas of doc. The page needs no scripts or external assets.
+func RenderDocs(doc *Document) ([]byte, error) {
+	var sections []docsSection
+	for _, tag := range doc.Tags {
+		section := docsSection{Tag: tag}
+		for path, item := range doc.Paths {
+			for method, op := range item {
+				if len(op.Tags) == 0 || op.Tags[0] != tag.Name {
+					continue
+				}
+				section.Operations = append(section.Operations, docsOperation{
+					Method:    method,
+					Path:      path,
+					Operation: op,
+					Responses: sortedResponses(op.Responses),
+				})
+			}
+		}
+		sort.Slice(section.Operations, func(i, j int) bool {
+			a, b := section.Operations[i], section.Operations[j]
+			if a.Path != b.Path {
+				return a.Path < b.Path
+			}
+			return methodOrder[a.Method] < methodOrder[b.Method]
+		})
+		sections = append(sections, section)
+	}
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	schemas := make([]docsSchema, len(names))
+	for i, name := range names {
+		schemas[i] = docsSchema{Name: name, Properties: properties(doc.Components.Schemas[name])}
+	}
+
+	var buf bytes.Buffer
+	err := docsTemplate.Execute(&buf, map[string]interface{}{
+		"Doc":      doc,
+		"Server":   doc.Servers[0].URL,
+		"Sections": sections,
+		"Schemas":  schemas,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render API docs: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sortedResponses lists responses by status code
+func sortedResponses(responses map[string]Response) []docsResponse {
+	result := make([]docsResponse, 0, len(responses))
+	for status, response := range responses {
+		result = append(result, docsResponse{Status: status, Response: response})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Status < result[j].Status })
+	return result
+}
+
+// properties lists the properties of an object schema by name
+func properties(s *Schema) []docsProperty {
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	result := make([]docsProperty, 0, len(s.Properties))
+	for name, property := range s.Properties {
+		result = append(result, docsProperty{
+			Name:        name,
+			Schema:      property,
+			Required:    required[name],
+			Constraints: constraints(property),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// typeName describes the type of a schema, naming referenced components
+func typeName(s *Schema) string {
+	if s == nil {
+		return "any"
+	}
+	switch {
+	case s.Ref != "":
+		return strings.TrimPrefix(s.Ref, "#/components/schemas/")
+	case len(s.AllOf) == 1:
+		return typeName(s.AllOf[0])
+	case s.Type == "array":
+		return "array of " + typeName(s.Items)
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		return "map of " + typeName(s.AdditionalProperties)
+	case s.Type == "":
+		return "any"
+	case s.Format != "":
+		return s.Type + " (" + s.Format + ")"
+	}
+	return s.Type
+}
+
+// refName returns the component a schema refers to, directly or as the
+// element of an array, for linking
+func refName(s *Schema) string {
+	switch {
+	case s == nil:
+		return ""
+	case s.Ref != "":
+		return strings.TrimPrefix(s.Ref, "#/components/schemas/")
+	case len(s.AllOf) == 1:
+		return refName(s.AllOf[0])
+	case s.Items != nil:
+		return refName(s.Items)
+	}
+	return ""
+}
+
+// constraints summarizes the validation rules of a schema
+func constraints(s *Schema) string {
+	var parts []string
+	if s.Nullable {
+		parts = append(parts, "nullable")
+	}
+	if len(s.Enum) > 0 {
+		parts = append(parts, "one of "+strings.Join(s.Enum, ", "))
+	}
+	if s.MinLength != nil {
+		parts = append(parts, fmt.Sprintf("min length %d", *s.MinLength))
+	}
+	if s.MaxLength != nil {
+		parts = append(parts, fmt.Sprintf("max length %d", *s.MaxLength))
+	}
+	if s.Minimum != nil && *s.Minimum != 0 {
+		parts = append(parts, fmt.Sprintf("min %g", *s.Minimum))
+	}
+	if s.Maximum != nil {
+		parts = append(parts, fmt.Sprintf("max %g", *s.Maximum))
+	}
+	if s.MinItems != nil {
+		parts = append(parts, fmt.Sprintf("min %d items", *s.MinItems))
+	}
+	if s.MaxItems != nil {
+		parts = append(parts, fmt.Sprintf("max %d items", *s.MaxItems))
+	}
+	return strings.Join(parts, "; ")
+}
+
+var docsTemplate = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"upper":       strings.ToUpper,
+	"typeName":    typeName,
+	"refName":     refName,
+	"constraints": constraints,
+	"bodySchema": func(content map[string]MediaType) *Schema {
+		if media, ok := content["application/json"]; ok {
+			return media.Schema
+		}
+		return nil
+	},
+	"anchor": func(method, path string) string {
+		return method + strings.NewReplacer("/", "-", "{", "", "}", "").Replace(path)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Doc.Info.Title}} {{.Doc.Info.Version}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #1f2328; line-height: 1.5; }
+nav { position: fixed; top: 0; bottom: 0; width: 260px; overflow-y: auto; padding: 16px; background: #f6f8fa; border-right: 1px solid #d0d7de; font-size: 14px; }
+nav a { display: block; color: #1f2328; text-decoration: none; padding: 2px 0; }
+nav h3 { margin: 16px 0 4px; font-size: 13px; text-transform: uppercase; color: #57606a; }
+main { margin-left: 293px; padding: 16px 32px; max-width: 960px; }
+code, .path { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; }
+.op { border: 1px solid #d0d7de; border-radius: 6px; margin: 16px 0; padding: 12px 16px; }
+.method { display: inline-block; min-width: 56px; text-align: center; font-weight: 600; color: #fff; border-radius: 4px; padding: 0 6px; margin-right: 8px; }
+.get { background: #0969da; } .post { background: #1a7f37; } .put { background: #9a6700; } .patch { background: #8250df; } .delete { background: #cf222e; }
+.lock { color: #57606a; font-size: 13px; }
+table { border-collapse: collapse; width: 100%; font-size: 14px; margin: 8px 0; }
+th, td { text-align: left; border-bottom: 1px solid #d0d7de; padding: 4px 8px; vertical-align: top; }
+.required { color: #cf222e; }
+</style>
+</head>
+<body>
+<nav>
+<strong>{{.Doc.Info.Title}}</strong><br><small>Version {{.Doc.Info.Version}} · <a href="openapi.json" style="display:inline">openapi.json</a></small>
+{{range .Sections}}<h3>{{.Tag.Name}}</h3>{{range .Operations}}<a href="#{{anchor .Method .Path}}"><span class="path">{{upper .Method}} {{.Path}}</span></a>{{end}}{{end}}
+<h3>Schemas</h3>{{range .Schemas}}<a href="#schema-{{.Name}}">{{.Name}}</a>{{end}}
+</nav>
+<main>
+<h1>{{.Doc.Info.Title}}</h1>
+<p>{{.Doc.Info.Description}}</p>
+<p>Base URL: <code>{{.Server}}</code></p>
+{{range .Sections}}
+<h2>{{.Tag.Name}}</h2>
+<p>{{.Tag.Description}}</p>
+{{range .Operations}}{{$op := .Operation}}
+<div class="op" id="{{anchor .Method .Path}}">
+<div><span class="method {{.Method}}">{{upper .Method}}</span><span class="path">{{.Path}}</span>{{if $op.Security}} <span class="lock">🔒 Bearer token</span>{{end}}</div>
+<p><strong>{{$op.Summary}}</strong>{{if $op.Description}}<br>{{$op.Description}}{{end}}</p>
+{{if $op.Parameters}}<table><tr><th>Parameter</th><th>In</th><th>Type</th><th>Description</th></tr>
+{{range $op.Parameters}}<tr><td><code>{{.Name}}</code>{{if .Required}} <span class="required">*</span>{{end}}</td><td>{{.In}}</td><td>{{typeName .Schema}}{{with constraints .Schema}}<br><small>{{.}}</small>{{end}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>{{end}}
+{{with $op.RequestBody}}{{$body := bodySchema .Content}}<p>Request body: <a href="#schema-{{refName $body}}"><code>{{typeName $body}}</code></a></p>{{end}}
+<table><tr><th>Status</th><th>Description</th><th>Body</th></tr>
+{{range .Responses}}<tr><td>{{.Status}}</td><td>{{.Response.Description}}</td><td>{{with bodySchema .Response.Content}}<a href="#schema-{{refName .}}"><code>{{typeName .}}</code></a>{{end}}</td></tr>
+{{end}}</table>
+</div>
+{{end}}{{end}}
+<h2>Schemas</h2>
+{{range .Schemas}}
+<h3 id="schema-{{.Name}}">{{.Name}}</h3>
+<table><tr><th>Field</th><th>Type</th><th>Rules</th></tr>
+{{range .Properties}}<tr><td><code>{{.Name}}</code>{{if .Required}} <span class="required">*</span>{{end}}</td><td>{{with refName .Schema}}<a href="#schema-{{.}}">{{end}}{{typeName .Schema}}{{with refName .Schema}}</a>{{end}}</td><td>{{.Constraints}}</td></tr>
+{{end}}</table>
+{{end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,240 @@
+// Package openapi describes the auth and task endpoints of the API as an
+// OpenAPI 3 document. Schemas are generated from the request and response
+// models, so they change together with the code.
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"task-management-api/models"
+)
+
+// Version is the version of the API described by the document
+const Version = "1.0.0"
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers"`
+	Tags       []Tag               `json:"tags"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL of the API
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// PathItem holds the operations of a path by lowercase HTTP method
+type PathItem map[string]*Operation
+
+// Operation is one endpoint
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body of an operation
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one possible response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header is a response header
+type Header struct {
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the shared schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how requests authenticate
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat"`
+	Description  string `json:"description"`
+}
+
+// builder collects operations into a document
+type builder struct {
+	g   *generator
+	doc *Document
+}
+
+// Build returns the document describing the API
+func Build() *Document {
+	b := &builder{
+		g: newGenerator(),
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info: Info{
+				Title:       "Task Management API",
+				Description: "REST API for managing personal tasks. Authenticate with `POST /auth/login` and send the returned token as `Authorization: Bearer <token>`. Every response carries an `X-Request-ID` header.",
+				Version:     Version,
+			},
+			Servers: []Server{{URL: "/api/v1"}},
+			Tags: []Tag{
+				{Name: "auth", Description: "Accounts, login sessions and tokens"},
+				{Name: "tasks", Description: "Creating, listing, changing and deleting tasks"},
+			},
+			Paths: make(map[string]PathItem),
+			Components: Components{
+				SecuritySchemes: map[string]SecurityScheme{
+					"BearerAuth": {
+						Type:         "http",
+						Scheme:       "bearer",
+						BearerFormat: "JWT",
+						Description:  "Access token from login, registration or refresh",
+					},
+				},
+			},
+		},
+	}
+
+	b.g.enum(models.TaskPriority(""), "low", "medium", "high", "urgent")
+	b.g.enum(models.TaskStatus(""), "todo", "in_progress", "completed", "cancelled")
+	b.g.enum(models.RecurrenceFrequency(""), "daily", "weekly", "monthly")
+	b.g.enum(models.BatchOp(""), "create", "update", "delete")
+	b.g.enum(models.BatchMode(""), "atomic", "best_effort")
+	b.g.enum(models.BatchItemStatus(""), "succeeded", "failed", "rolled_back", "skipped")
+	b.g.enum(models.ActivityAction(""), "created", "updated", "deleted")
+
+	b.authOperations()
+	b.taskOperations()
+
+	b.doc.Components.Schemas = b.g.schemas
+	return b.doc
+}
+
+// add registers an operation under the method and path
+func (b *builder) add(method, path string, op *Operation) {
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		b.doc.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// protected marks an operation as requiring an access token
+func protected(op *Operation) *Operation {
+	op.Security = []map[string][]string{{"BearerAuth": {}}}
+	if _, ok := op.Responses["401"]; !ok {
+		op.Responses["401"] = Response{Description: "Missing, invalid or expired access token"}
+	}
+	return op
+}
+
+// body returns a required JSON request body of the type of value
+func (b *builder) body(value interface{}) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: b.g.ref(value)}},
+	}
+}
+
+// reply returns a JSON response of the type of value
+func (b *builder) reply(description string, value interface{}) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: b.g.ref(value)}},
+	}
+}
+
+// failure returns an error response
+func (b *builder) failure(description string) Response {
+	return b.reply(description, models.ErrorResponse{})
+}
+
+// responses builds the responses of an operation, adding the generic server
+// error every endpoint can return
+func (b *builder) responses(responses map[int]Response) map[string]Response {
+	result := make(map[string]Response, len(responses)+1)
+	for status, response := range responses {
+		result[strconv.Itoa(status)] = response
+	}
+	result["500"] = b.failure("Internal server error; the body includes the request ID")
+	return result
+}
+
+// pathID is the ID parameter of a resource path
+func pathID(name, description string) Parameter {
+	return Parameter{
+		Name:        name,
+		In:          "path",
+		Description: description,
+		Required:    true,
+		Schema:      &Schema{Type: "integer", Format: "int64", Minimum: float(1)},
+	}
+}
+
+// query turns the form-tagged fields of a parameter struct into query
+// parameters, with their binding rules
+func (b *builder) query(value interface{}, descriptions map[string]string) []Parameter {
+	t := reflect.TypeOf(value)
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+		schema, required := b.g.field(field)
+		params = append(params, Parameter{
+			Name:        name,
+			In:          "query",
+			Description: descriptions[name],
+			Required:    required,
+			Schema:      schema,
+		})
+	}
+	return params
+}
@@ -0,0 +1,274 @@
+package openapi
+
+import (
+	"net/http"
+	"task-management-api/models"
+)
+
+// authOperations describes the /auth endpoints
+func (b *builder) authOperations() {
+	tags := []string{"auth"}
+	invalid := b.failure("Invalid request payload")
+
+	b.add(http.MethodPost, "/auth/register", &Operation{
+		OperationID: "register",
+		Summary:     "Register a new user",
+		Description: "Create an account and email a verification link. Unless verification is disabled on the server, no tokens are returned until the address is verified. Passwords must be 8 to 72 characters, contain a letter and a digit or symbol, and not be common or contain the username or email name.",
+		Tags:        tags,
+		RequestBody: b.body(models.UserRegisterRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusCreated:    b.reply("Account created", models.AuthResponse{}),
+			http.StatusBadRequest: b.failure("Invalid payload or password rejected by the password policy"),
+			http.StatusConflict:   b.failure("Email or username already taken"),
+		}),
+	})
+
+	b.add(http.MethodPost, "/auth/login", &Operation{
+		OperationID: "login",
+		Summary:     "Log in",
+		Description: "Start a session and return an access token and a refresh token. Accounts are locked for a while after repeated failed logins.",
+		Tags:        tags,
+		RequestBody: b.body(models.UserLoginRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:           b.reply("Logged in", models.AuthResponse{}),
+			http.StatusBadRequest:   invalid,
+			http.StatusUnauthorized: b.failure("Invalid email or password"),
+			http.StatusForbidden:    b.failure("Email address not verified"),
+			http.StatusTooManyRequests: {
+				Description: "Account locked after too many failed logins",
+				Headers: map[string]Header{
+					"Retry-After": {Description: "Seconds until the account is unlocked", Schema: &Schema{Type: "integer"}},
+				},
+				Content: b.failure("").Content,
+			},
+		}),
+	})
+
+	b.add(http.MethodPost, "/auth/refresh", &Operation{
+		OperationID: "refresh",
+		Summary:     "Refresh the access token",
+		Description: "Exchange a refresh token for a new access token. The refresh token is rotated; presenting an old one again revokes the session.",
+		Tags:        tags,
+		RequestBody: b.body(models.RefreshTokenRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:           b.reply("New tokens", models.TokenResponse{}),
+			http.StatusBadRequest:   invalid,
+			http.StatusUnauthorized: b.failure("Invalid, expired or reused refresh token"),
+		}),
+	})
+
+	b.add(http.MethodPost, "/auth/verify-email", &Operation{
+		OperationID: "verifyEmail",
+		Summary:     "Verify an email address",
+		Description: "Verify the address with the token from the verification email. Tokens work once.",
+		Tags:        tags,
+		RequestBody: b.body(models.VerifyEmailRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:         b.reply("Address verified", models.AuthResponse{}),
+			http.StatusBadRequest: b.failure("Invalid payload, or invalid or expired token"),
+		}),
+	})
+
+	b.add(http.MethodPost, "/auth/resend-verification", &Operation{
+		OperationID: "resendVerification",
+		Summary:     "Resend the verification email",
+		Description: "The response is the same whether or not the account exists.",
+		Tags:        tags,
+		RequestBody: b.body(models.EmailRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusAccepted:   b.reply("Email sent if the account exists and is unverified", models.MessageResponse{}),
+			http.StatusBadRequest: invalid,
+		}),
+	})
+
+	b.add(http.MethodPost, "/auth/forgot-password", &Operation{
+		OperationID: "forgotPassword",
+		Summary:     "Request a password reset",
+		Description: "Email a password reset token. The response is the same whether or not the account exists.",
+		Tags:        tags,
+		RequestBody: b.body(models.EmailRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusAccepted:   b.reply("Email sent if the account exists", models.MessageResponse{}),
+			http.StatusBadRequest: invalid,
+		}),
+	})
+
+	b.add(http.MethodPost, "/auth/reset-password", &Operation{
+		OperationID: "resetPassword",
+		Summary:     "Reset the password",
+		Description: "Set a new password with the token from the reset email. All sessions of the account are revoked and a lockout is lifted.",
+		Tags:        tags,
+		RequestBody: b.body(models.ResetPasswordRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:         b.reply("Password changed", models.MessageResponse{}),
+			http.StatusBadRequest: b.failure("Invalid payload, invalid or expired token, or password rejected by the password policy"),
+		}),
+	})
+
+	b.add(http.MethodGet, "/auth/profile", protected(&Operation{
+		OperationID: "getProfile",
+		Summary:     "Get the profile",
+		Tags:        tags,
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:       b.reply("The authenticated user", models.UserResponse{}),
+			http.StatusNotFound: b.failure("User not found"),
+		}),
+	}))
+
+	b.add(http.MethodPost, "/auth/logout", protected(&Operation{
+		OperationID: "logout",
+		Summary:     "Log out",
+		Description: "Revoke the session of the access token; its access and refresh tokens stop working immediately.",
+		Tags:        tags,
+		Responses: b.responses(map[int]Response{
+			http.StatusOK: b.reply("Session revoked", models.MessageResponse{}),
+		}),
+	}))
+
+	b.add(http.MethodPost, "/auth/logout-all", protected(&Operation{
+		OperationID: "logoutAll",
+		Summary:     "Log out everywhere",
+		Description: "Revoke every session of the user.",
+		Tags:        tags,
+		Responses: b.responses(map[int]Response{
+			http.StatusOK: b.reply("Sessions revoked", models.LogoutAllResponse{}),
+		}),
+	}))
+}
+
+// taskOperations describes the /tasks endpoints
+func (b *builder) taskOperations() {
+	tags := []string{"tasks"}
+	taskID := pathID("id", "Task ID")
+	notFound := b.failure("Task not found")
+
+	b.add(http.MethodPost, "/tasks", protected(&Operation{
+		OperationID: "createTask",
+		Summary:     "Create a task",
+		Description: "New tasks are placed at the end of the first board column of their status. A recurrence requires a due date, which becomes the first occurrence.",
+		Tags:        tags,
+		RequestBody: b.body(models.CreateTaskRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusCreated:    b.reply("Task created", models.Task{}),
+			http.StatusBadRequest: b.failure("Invalid payload, recurrence or labels"),
+			http.StatusConflict:   b.failure("No board column for the status"),
+		}),
+	}))
+
+	b.add(http.MethodGet, "/tasks", protected(&Operation{
+		OperationID: "listTasks",
+		Summary:     "List tasks",
+		Tags:        tags,
+		Parameters: b.query(models.TaskFilterParams{}, map[string]string{
+			"status":      "Only tasks with this status",
+			"priority":    "Only tasks with this priority",
+			"labels":      "Comma-separated label IDs",
+			"label_match": "any (default) matches tasks with one of the labels, all only tasks with every label",
+			"sort_by":     "Sort field (default created_at)",
+			"order":       "Sort order (default desc)",
+		}),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:         b.reply("Matching tasks", []models.Task{}),
+			http.StatusBadRequest: b.failure("Invalid filter"),
+		}),
+	}))
+
+	b.add(http.MethodGet, "/tasks/stats", protected(&Operation{
+		OperationID: "getTaskStats",
+		Summary:     "Get task statistics",
+		Description: "Counts by status, priority and board column, with a report of tracked time.",
+		Tags:        tags,
+		Parameters: b.query(models.TimeReportParams{}, map[string]string{
+			"time_group_by": "Group tracked time by day, week (keyed by the Monday) or task",
+			"from":          "First day of the time report (YYYY-MM-DD)",
+			"to":            "Last day of the time report (YYYY-MM-DD)",
+			"tz":            "IANA time zone of report days (default UTC)",
+		}),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:         b.reply("Statistics", models.TaskStats{}),
+			http.StatusBadRequest: b.failure("Invalid report parameters"),
+		}),
+	}))
+
+	b.add(http.MethodPost, "/tasks/batch", protected(&Operation{
+		OperationID: "batchTasks",
+		Summary:     "Create, update and delete tasks in bulk",
+		Description: "In atomic mode (default) all operations succeed or none are applied; in best_effort mode each is applied on its own. The response is 207 if any operation failed, with the outcome of each.",
+		Tags:        tags,
+		RequestBody: b.body(models.BatchRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:          b.reply("All operations succeeded", models.BatchResponse{}),
+			http.StatusMultiStatus: b.reply("Some operations failed; in atomic mode none were applied", models.BatchResponse{}),
+			http.StatusBadRequest:  b.failure("Invalid payload"),
+		}),
+	}))
+
+	b.add(http.MethodGet, "/tasks/{id}", protected(&Operation{
+		OperationID: "getTask",
+		Summary:     "Get a task",
+		Tags:        tags,
+		Parameters:  []Parameter{taskID},
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:         b.reply("The task", models.Task{}),
+			http.StatusBadRequest: b.failure("Invalid task ID"),
+			http.StatusNotFound:   notFound,
+		}),
+	}))
+
+	b.add(http.MethodPut, "/tasks/{id}", protected(&Operation{
+		OperationID: "updateTask",
+		Summary:     "Update a task",
+		Description: "Only the fields present are changed. label_ids replaces all labels; estimate_minutes 0 removes the estimate.",
+		Tags:        tags,
+		Parameters:  []Parameter{taskID},
+		RequestBody: b.body(models.UpdateTaskRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:         b.reply("The updated task", models.Task{}),
+			http.StatusBadRequest: b.failure("Invalid payload or labels"),
+			http.StatusNotFound:   notFound,
+			http.StatusConflict:   b.failure("The status change is not allowed by the workflow"),
+		}),
+	}))
+
+	b.add(http.MethodDelete, "/tasks/{id}", protected(&Operation{
+		OperationID: "deleteTask",
+		Summary:     "Delete a task",
+		Tags:        tags,
+		Parameters:  []Parameter{taskID},
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:         b.reply("Task deleted", models.MessageResponse{}),
+			http.StatusBadRequest: b.failure("Invalid task ID"),
+			http.StatusNotFound:   notFound,
+		}),
+	}))
+
+	b.add(http.MethodPost, "/tasks/{id}/move", protected(&Operation{
+		OperationID: "moveTask",
+		Summary:     "Move a task on the board",
+		Description: "Move the task to a column, after or before another task of that column; without a neighbor it goes to the end.",
+		Tags:        tags,
+		Parameters:  []Parameter{taskID},
+		RequestBody: b.body(models.MoveTaskRequest{}),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:         b.reply("The moved task", models.Task{}),
+			http.StatusBadRequest: b.failure("Unknown column or invalid position"),
+			http.StatusNotFound:   notFound,
+			http.StatusConflict:   b.failure("The move is not allowed by the workflow"),
+		}),
+	}))
+
+	b.add(http.MethodGet, "/tasks/{id}/history", protected(&Operation{
+		OperationID: "getTaskHistory",
+		Summary:     "Get the change history of a task",
+		Tags:        tags,
+		Parameters: append([]Parameter{taskID}, b.query(models.PaginationParams{}, map[string]string{
+			"page":      "Page number (default 1)",
+			"page_size": "Items per page (default 20)",
+		})...),
+		Responses: b.responses(map[int]Response{
+			http.StatusOK:         b.reply("A page of changes, oldest first", models.ActivityPage{}),
+			http.StatusBadRequest: b.failure("Invalid task ID or pagination"),
+			http.StatusNotFound:   notFound,
+		}),
+	}))
+}
@@ -0,0 +1,220 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI 3.0 schema object, limited to what the API uses
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+// generator turns Go types into schemas. Named structs become components
+// referenced by name; the json tags give the property names and the binding
+// tags the validation rules, so the document follows the models.
+type generator struct {
+	schemas map[string]*Schema
+	enums   map[reflect.Type][]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: make(map[string]*Schema),
+		enums:   make(map[reflect.Type][]string),
+	}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// enum declares the values of a named string type, for fields whose binding
+// tags do not list them
+func (g *generator) enum(value interface{}, values ...string) {
+	g.enums[reflect.TypeOf(value)] = values
+}
+
+// ref returns the schema of the type of value, registering components as needed
+func (g *generator) ref(value interface{}) *Schema {
+	return g.schema(reflect.TypeOf(value))
+}
+
+// schema returns the schema of t
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{Description: "Any JSON value"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		inner := g.schema(t.Elem())
+		if inner.Ref != "" {
+			return &Schema{AllOf: []*Schema{inner}, Nullable: true}
+		}
+		inner.Nullable = true
+		return inner
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Minimum: float(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string", Enum: g.enums[t]}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Interface:
+		return &Schema{Description: "Any JSON value"}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = &Schema{} // Placeholder for recursive types
+			g.schemas[t.Name()] = g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	return &Schema{}
+}
+
+// object builds the schema of a struct from its json-tagged fields
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(s, t)
+	return s
+}
+
+// fields adds the fields of t, including those of embedded structs, to s
+func (g *generator) fields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.fields(s, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, required := g.field(field)
+		s.Properties[name] = property
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// field returns the schema of a struct field with its binding rules applied,
+// and whether the field is required
+func (g *generator) field(field reflect.StructField) (*Schema, bool) {
+	property := g.schema(field.Type)
+	return property, applyBinding(property, field.Tag.Get("binding"))
+}
+
+// applyBinding applies the validation rules of a binding tag to s and reports
+// whether they make the value required. Rules after "dive" apply to the
+// elements of a slice.
+func applyBinding(s *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	required := false
+	target := s
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "dive":
+			if target.Items != nil {
+				target = target.Items
+			}
+		case "oneof":
+			target.Enum = strings.Fields(value)
+		case "email":
+			target.Format = "email"
+		case "url":
+			target.Format = "uri"
+		case "datetime":
+			if value == "2006-01-02" {
+				target.Format = "date"
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			limit(target, key, n)
+		}
+	}
+	return required
+}
+
+// limit applies a min or max rule, which go-playground/validator interprets
+// by the kind of value: length for strings, size for slices, value for numbers
+func limit(s *Schema, key string, n int) {
+	switch s.Type {
+	case "string":
+		if key == "min" {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case "array":
+		if key == "min" {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		if key == "min" {
+			s.Minimum = float(n)
+		} else {
+			s.Maximum = float(n)
+		}
+	}
+}
+
+func float(n int) *float64 {
+	f := float64(n)
+	return &f
+}
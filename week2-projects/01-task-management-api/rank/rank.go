@@ -0,0 +1,139 @@
+// Package rank implements fractional indexing: string keys that sort in list
+// order and always leave room for a new key between any two neighbors, so
+// moving an item only rewrites that item's key.
+//
+// Keys are base-36 fractions ("0.5" is written "i") without trailing zero
+// digits, compared as plain strings. Only digits and lowercase letters are
+// used so that database collations sort keys the same way as Go does.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+// digits are the key digits in ascending byte order
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// MaxLength is the key length beyond which a list should be rebalanced with Spread
+const MaxLength = 64
+
+// ErrInvalidRange is returned when the bounds are not valid keys in ascending order
+var ErrInvalidRange = errors.New("rank: bounds must be valid keys in ascending order")
+
+// Between returns a key that sorts after a and before b. An empty a means
+// "before everything", an empty b "after everything".
+func Between(a, b string) (string, error) {
+	if !valid(a) || !valid(b) || (b != "" && a >= b) {
+		return "", ErrInvalidRange
+	}
+	return midpoint(a, b), nil
+}
+
+// Spread returns n evenly spaced keys in ascending order, for assigning ranks
+// to a whole list at once
+func Spread(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	// Use enough digits that every key is distinct
+	length := 1
+	for capacity := len(digits); capacity <= n; capacity *= len(digits) {
+		length++
+	}
+	space := 1
+	for i := 0; i < length; i++ {
+		space *= len(digits)
+	}
+
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = encode((i+1)*space/(n+1), length)
+	}
+	return keys
+}
+
+// midpoint returns a key between a and b; b == "" means no upper bound
+func midpoint(a, b string) string {
+	// Keep the common prefix, reading missing digits of a as zeros
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(suffix(a, n), b[n:])
+		}
+	}
+
+	low := 0
+	if a != "" {
+		low = strings.IndexByte(digits, a[0])
+	}
+	high := len(digits)
+	if b != "" {
+		high = strings.IndexByte(digits, b[0])
+	}
+
+	// Room for a digit strictly in between. With only one bound, step next to
+	// it so repeated appends and prepends grow keys slowly
+	if high-low > 1 {
+		switch {
+		case a != "" && b == "":
+			return string(digits[low+1])
+		case a == "" && b != "":
+			return string(digits[high-1])
+		}
+		return string(digits[(low+high+1)/2])
+	}
+
+	// Adjacent first digits: b's first digit alone fits if b continues
+	if len(b) > 1 {
+		return b[:1]
+	}
+	return string(digits[low]) + midpoint(suffix(a, 1), "")
+}
+
+// encode writes value as a base-36 fraction with the given number of digits,
+// dropping trailing zeros
+func encode(value, length int) string {
+	key := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		key[i] = digits[value%len(digits)]
+		value /= len(digits)
+	}
+	return strings.TrimRight(string(key), "0")
+}
+
+// valid reports whether s is empty or a key without trailing zeros
+func valid(s string) bool {
+	if s == "" {
+		return true
+	}
+	if s[len(s)-1] == '0' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(digits, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// digitAt returns the digit of s at i, or '0' past its end
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return '0'
+}
+
+// suffix returns s without its first n digits
+func suffix(s string, n int) string {
+	if n >= len(s) {
+		return ""
+	}
+	return s[n:]
+}
@@ -0,0 +1,110 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBetweenRandomInserts(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// Spread inserts at random, and hammer the front, the back and one spot
+	// in the middle, where keys grow fastest
+	strategies := []struct {
+		name     string
+		position func(n int) int
+	}{
+		{"random", func(n int) int { return rng.Intn(n + 1) }},
+		{"front", func(n int) int { return 0 }},
+		{"back", func(n int) int { return n }},
+		{"middle", func(n int) int { return n / 2 }},
+	}
+
+	for _, strategy := range strategies {
+		position := strategy.position
+		t.Run(strategy.name, func(t *testing.T) {
+			var keys []string
+			for i := 0; i < 2000; i++ {
+				at := position(len(keys))
+				a, b := "", ""
+				if at > 0 {
+					a = keys[at-1]
+				}
+				if at < len(keys) {
+					b = keys[at]
+				}
+
+				key, err := Between(a, b)
+				if err != nil {
+					t.Fatalf("Between(%q, %q) error = %v", a, b, err)
+				}
+				if !valid(key) || key == "" {
+					t.Fatalf("Between(%q, %q) = %q, not a valid key", a, b, key)
+				}
+				if (a != "" && key <= a) || (b != "" && key >= b) {
+					t.Fatalf("Between(%q, %q) = %q, not in between", a, b, key)
+				}
+
+				keys = append(keys, "")
+				copy(keys[at+1:], keys[at:])
+				keys[at] = key
+			}
+
+			if !sort.StringsAreSorted(keys) {
+				t.Fatal("keys are not in insertion order")
+			}
+		})
+	}
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{1, 2, 35, 36, 37, 100, 1295, 1296, 5000} {
+		keys := Spread(n)
+		if len(keys) != n {
+			t.Fatalf("Spread(%d) returned %d keys", n, len(keys))
+		}
+		for i, key := range keys {
+			if key == "" || !valid(key) {
+				t.Fatalf("Spread(%d)[%d] = %q, not a valid key", n, i, key)
+			}
+			if i > 0 && keys[i-1] >= key {
+				t.Fatalf("Spread(%d): %q then %q, not distinct and ascending", n, keys[i-1], key)
+			}
+		}
+
+		// Spread keys leave room around them
+		if _, err := Between("", keys[0]); err != nil {
+			t.Errorf("Between before Spread(%d)[0] error = %v", n, err)
+		}
+		if _, err := Between(keys[n-1], ""); err != nil {
+			t.Errorf("Between after Spread(%d)[%d] error = %v", n, n-1, err)
+		}
+	}
+
+	if keys := Spread(0); keys != nil {
+		t.Errorf("Spread(0) = %v, want nil", keys)
+	}
+}
+
+func TestBetweenInvalidBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"equal bounds", "i", "i"},
+		{"descending bounds", "t", "i"},
+		{"lower bound with a trailing zero", "i0", ""},
+		{"upper bound with a trailing zero", "", "i0"},
+		{"uppercase digit", "I", ""},
+		{"character outside the alphabet", "", "i-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if key, err := Between(tt.a, tt.b); err != ErrInvalidRange {
+				t.Errorf("Between(%q, %q) = %q, %v, want ErrInvalidRange", tt.a, tt.b, key, err)
+			}
+		})
+	}
+}
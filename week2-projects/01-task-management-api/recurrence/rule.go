@@ -0,0 +1,225 @@
+package recurrence
+
+import (
+	"fmt"
+	"strings"
+	"task-management-api/models"
+	"time"
+)
+
+// weekdayCodes maps RRULE weekday codes to time.Weekday values
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// NewRule builds a recurrence rule from a request. The due date becomes the
+// first occurrence and the task fields become the template for later ones.
+func NewRule(userID uint, req *models.RecurrenceRequest, dueDate time.Time, title, description string, priority models.TaskPriority) (*models.RecurrenceRule, error) {
+	tz := req.TimeZone
+	if tz == "" {
+		tz = "UTC"
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tz)
+	}
+
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+
+	if req.Until != nil && req.Until.Before(dueDate) {
+		return nil, fmt.Errorf("until must not be before the first due date")
+	}
+
+	var weekdays string
+	if req.Frequency == models.FrequencyWeekly {
+		weekdays = strings.Join(req.Weekdays, ",")
+	}
+
+	var monthDay int
+	if req.Frequency == models.FrequencyMonthly {
+		monthDay = req.MonthDay
+	}
+
+	return &models.RecurrenceRule{
+		UserID:           userID,
+		Frequency:        req.Frequency,
+		Interval:         interval,
+		Weekdays:         weekdays,
+		MonthDay:         monthDay,
+		TimeZone:         tz,
+		StartAt:          dueDate.UTC(),
+		Until:            req.Until,
+		LastOccurrenceAt: dueDate.UTC(),
+		Title:            title,
+		Description:      description,
+		Priority:         priority,
+	}, nil
+}
+
+// NextOccurrence returns the first occurrence of the rule strictly after the
+// given time. The boolean is false once the rule has run past its end date.
+//
+// All calendar arithmetic happens in the rule's time zone using the wall-clock
+// time of the first occurrence, so DST transitions never shift the local time.
+func NextOccurrence(rule *models.RecurrenceRule, after time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(rule.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	interval := rule.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	start := rule.StartAt.In(loc)
+	if after.Before(start) {
+		// The first occurrence itself is always part of the series
+		after = start.Add(-time.Nanosecond)
+	}
+
+	var next time.Time
+	var found bool
+	switch rule.Frequency {
+	case models.FrequencyDaily:
+		next, found = nextDaily(start, after.In(loc), interval, loc)
+	case models.FrequencyWeekly:
+		next, found = nextWeekly(start, after.In(loc), interval, weekdaySet(rule, start), loc)
+	case models.FrequencyMonthly:
+		monthDay := rule.MonthDay
+		if monthDay == 0 {
+			monthDay = start.Day()
+		}
+		next, found = nextMonthly(start, after.In(loc), interval, monthDay, loc)
+	}
+
+	if !found {
+		return time.Time{}, false
+	}
+	if rule.Until != nil && next.After(*rule.Until) {
+		return time.Time{}, false
+	}
+	return next.UTC(), true
+}
+
+// nextDaily finds the next day that is a multiple of interval days from start
+func nextDaily(start, after time.Time, interval int, loc *time.Location) (time.Time, bool) {
+	days := daysBetween(start, after)
+	if days < 0 {
+		days = 0
+	}
+	days -= days % interval
+
+	// At most two steps are needed: the candidate day itself may already be past
+	for i := 0; i < 3; i++ {
+		candidate := atWallClock(start, start.Year(), start.Month(), start.Day()+days, loc)
+		if candidate.After(after) {
+			return candidate, true
+		}
+		days += interval
+	}
+	return time.Time{}, false
+}
+
+// nextWeekly finds the next matching weekday in a week that is a multiple of
+// interval weeks from the week (Monday-based) containing start
+func nextWeekly(start, after time.Time, interval int, weekdays map[time.Weekday]bool, loc *time.Location) (time.Time, bool) {
+	anchor := mondayOf(start)
+	day := dateOf(after)
+
+	// One full cycle of interval weeks plus one week is always enough
+	for i := 0; i <= 7*(interval+1); i++ {
+		current := day.AddDate(0, 0, i)
+		weeks := daysBetween(anchor, current) / 7
+		if weeks%interval != 0 || !weekdays[current.Weekday()] {
+			continue
+		}
+		candidate := atWallClock(start, current.Year(), current.Month(), current.Day(), loc)
+		if candidate.After(after) && !candidate.Before(start) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextMonthly finds the next month that is a multiple of interval months from
+// start and has the requested day. Months that are too short are skipped, as
+// in RFC 5545 (e.g. the 31st only occurs in 31-day months).
+func nextMonthly(start, after time.Time, interval, monthDay int, loc *time.Location) (time.Time, bool) {
+	months := (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+	if months < 0 {
+		months = 0
+	}
+	months -= months % interval
+
+	// Every month day up to 31 occurs within 12 consecutive cycles
+	for i := 0; i <= 12*interval; i += interval {
+		first := time.Date(start.Year(), start.Month()+time.Month(months+i), 1, 0, 0, 0, 0, time.UTC)
+		if monthDay > daysIn(first.Year(), first.Month()) {
+			continue
+		}
+		candidate := atWallClock(start, first.Year(), first.Month(), monthDay, loc)
+		if candidate.After(after) && !candidate.Before(start) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// weekdaySet returns the weekdays a weekly rule fires on, defaulting to the
+// weekday of the first occurrence
+func weekdaySet(rule *models.RecurrenceRule, start time.Time) map[time.Weekday]bool {
+	set := make(map[time.Weekday]bool)
+	for _, code := range rule.WeekdayList() {
+		if wd, ok := weekdayCodes[code]; ok {
+			set[wd] = true
+		}
+	}
+	if len(set) == 0 {
+		set[start.Weekday()] = true
+	}
+	return set
+}
+
+// atWallClock returns the given local date at the time of day of start. A
+// time skipped by a DST change is taken with the offset in effect before the
+// change, as in RFC 5545, so 02:30 on a spring-forward day becomes 03:30.
+func atWallClock(start time.Time, year int, month time.Month, day int, loc *time.Location) time.Time {
+	h, m, s := start.Clock()
+	t := time.Date(year, month, day, h, m, s, 0, loc)
+	if th, tm, ts := t.Clock(); th == h && tm == m && ts == s {
+		return t
+	}
+
+	_, offset := t.AddDate(0, 0, -1).Zone()
+	return time.Date(year, month, day, h, m, s, 0, time.UTC).Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
+// dateOf returns the calendar date of t as midnight UTC, for day arithmetic
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// mondayOf returns the date of the Monday starting the week that contains t
+func mondayOf(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return dateOf(t).AddDate(0, 0, -offset)
+}
+
+// daysBetween returns the number of calendar days from a to b
+func daysBetween(a, b time.Time) int {
+	return int(dateOf(b).Sub(dateOf(a)).Hours() / 24)
+}
+
+// daysIn returns the number of days in the given month
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,143 @@
+package recurrence
+
+import (
+	"task-management-api/models"
+	"testing"
+	"time"
+	_ "time/tzdata" // Time zones do not depend on the host's zoneinfo
+)
+
+func TestNextOccurrence(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      models.RecurrenceRequest
+		first    string   // First due date, RFC 3339
+		expected []string // The whole series, or its first occurrences, in UTC
+	}{
+		{
+			name:  "weekly across the European spring DST change keeps 09:00 local",
+			req:   models.RecurrenceRequest{Frequency: models.FrequencyWeekly, TimeZone: "Europe/Berlin"},
+			first: "2024-03-25T09:00:00+01:00",
+			expected: []string{
+				"2024-03-25T08:00:00Z", // CET
+				"2024-04-01T07:00:00Z", // CEST from March 31
+				"2024-04-08T07:00:00Z",
+			},
+		},
+		{
+			name:  "weekly across the European autumn DST change keeps 09:00 local",
+			req:   models.RecurrenceRequest{Frequency: models.FrequencyWeekly, TimeZone: "Europe/Paris"},
+			first: "2024-10-21T09:00:00+02:00",
+			expected: []string{
+				"2024-10-21T07:00:00Z", // CEST
+				"2024-10-28T08:00:00Z", // CET from October 27
+			},
+		},
+		{
+			name:  "daily at 02:30 on the spring-forward day moves past the gap",
+			req:   models.RecurrenceRequest{Frequency: models.FrequencyDaily, TimeZone: "America/New_York"},
+			first: "2024-03-09T02:30:00-05:00",
+			expected: []string{
+				"2024-03-09T07:30:00Z", // 02:30 EST
+				"2024-03-10T07:30:00Z", // 02:30 does not exist: 03:30 EDT
+				"2024-03-11T06:30:00Z", // 02:30 EDT
+			},
+		},
+		{
+			name:  "monthly on day 31 skips shorter months",
+			req:   models.RecurrenceRequest{Frequency: models.FrequencyMonthly, MonthDay: 31},
+			first: "2024-01-31T10:00:00Z",
+			expected: []string{
+				"2024-01-31T10:00:00Z",
+				"2024-03-31T10:00:00Z",
+				"2024-05-31T10:00:00Z",
+				"2024-07-31T10:00:00Z",
+				"2024-08-31T10:00:00Z",
+				"2024-10-31T10:00:00Z",
+			},
+		},
+		{
+			name:  "weekly with interval 2 on two weekdays",
+			req:   models.RecurrenceRequest{Frequency: models.FrequencyWeekly, Interval: 2, Weekdays: []string{"MO", "TH"}},
+			first: "2024-01-01T09:00:00Z",
+			expected: []string{
+				"2024-01-01T09:00:00Z",
+				"2024-01-04T09:00:00Z",
+				"2024-01-15T09:00:00Z",
+				"2024-01-18T09:00:00Z",
+				"2024-01-29T09:00:00Z",
+			},
+		},
+		{
+			name:  "until includes an occurrence at the bound",
+			req:   models.RecurrenceRequest{Frequency: models.FrequencyDaily, Until: timePtr("2024-01-03T09:00:00Z")},
+			first: "2024-01-01T09:00:00Z",
+			expected: []string{
+				"2024-01-01T09:00:00Z",
+				"2024-01-02T09:00:00Z",
+				"2024-01-03T09:00:00Z",
+			},
+		},
+		{
+			name:  "until before an occurrence ends the series",
+			req:   models.RecurrenceRequest{Frequency: models.FrequencyWeekly, Until: timePtr("2024-01-15T08:59:59Z")},
+			first: "2024-01-01T09:00:00Z",
+			expected: []string{
+				"2024-01-01T09:00:00Z",
+				"2024-01-08T09:00:00Z",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := mustParse(t, tt.first)
+			rule, err := NewRule(1, &tt.req, first, "Task", "", models.PriorityMedium)
+			if err != nil {
+				t.Fatalf("NewRule() error = %v", err)
+			}
+
+			// Series with an end are checked to the end, others as far as listed
+			after := first.Add(-time.Nanosecond)
+			for i, want := range tt.expected {
+				got, ok := NextOccurrence(rule, after)
+				if !ok {
+					t.Fatalf("occurrence %d: series ended, want %s", i+1, want)
+				}
+				if !got.Equal(mustParse(t, want)) {
+					t.Fatalf("occurrence %d = %s, want %s", i+1, got.Format(time.RFC3339), want)
+				}
+				after = got
+			}
+			if tt.req.Until != nil {
+				if got, ok := NextOccurrence(rule, after); ok {
+					t.Errorf("occurrence after the until bound: %s", got.Format(time.RFC3339))
+				}
+			}
+		})
+	}
+}
+
+func TestNewRuleRejectsUntilBeforeFirstOccurrence(t *testing.T) {
+	req := models.RecurrenceRequest{Frequency: models.FrequencyDaily, Until: timePtr("2024-01-01T08:00:00Z")}
+	if _, err := NewRule(1, &req, mustParse(t, "2024-01-01T09:00:00Z"), "Task", "", models.PriorityMedium); err == nil {
+		t.Error("NewRule() accepted an until bound before the first due date")
+	}
+}
+
+func mustParse(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("invalid time %q: %v", value, err)
+	}
+	return parsed
+}
+
+func timePtr(value string) *time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return &parsed
+}
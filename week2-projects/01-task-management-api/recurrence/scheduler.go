@@ -0,0 +1,189 @@
+package recurrence
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"task-management-api/activity"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/webhooks"
+	"task-management-api/workflow"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// openStatuses are the task statuses that count as a pending occurrence
+var openStatuses = []models.TaskStatus{models.StatusTodo, models.StatusInProgress}
+
+// Scheduler periodically materializes upcoming occurrences of every active
+// recurrence rule so that they show up as regular tasks
+type Scheduler struct {
+	db       *gorm.DB
+	events   *events.Broker
+	interval time.Duration
+	horizon  time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler that runs every interval and keeps
+// occurrences materialized up to horizon into the future. Created tasks are
+// published to broker.
+func NewScheduler(db *gorm.DB, broker *events.Broker, interval, horizon time.Duration) *Scheduler {
+	return &Scheduler{
+		db:       db,
+		events:   broker,
+		interval: interval,
+		horizon:  horizon,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs the scheduler in the background until Stop is called
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.runAndLog()
+		for {
+			select {
+			case <-ticker.C:
+				s.runAndLog()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduler to exit and waits for the current run to finish
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// RunOnce materializes occurrences for all active rules and returns how many
+// tasks were created. A rule that fails is logged and skipped, so that it
+// does not hold up the others; it is tried again on the next run.
+func (s *Scheduler) RunOnce() (int, error) {
+	var rules []models.RecurrenceRule
+	if err := s.db.Find(&rules).Error; err != nil {
+		return 0, fmt.Errorf("failed to load recurrence rules: %w", err)
+	}
+
+	until := time.Now().Add(s.horizon)
+	total := 0
+	for i := range rules {
+		db, pending := events.Track(s.db)
+		created, err := Materialize(db, &rules[i], until)
+		if err != nil {
+			slog.Error("failed to materialize recurrence rule", "rule_id", rules[i].ID, "error", err)
+			continue
+		}
+		s.events.PublishPending(pending)
+		total += created
+	}
+	return total, nil
+}
+
+// runAndLog runs the scheduler once and logs the outcome
+func (s *Scheduler) runAndLog() {
+	created, err := s.RunOnce()
+	if err != nil {
+		slog.Error("recurrence scheduler failed", "error", err)
+		return
+	}
+	if created > 0 {
+		slog.Info("recurrence scheduler created tasks", "created", created)
+	}
+}
+
+// Materialize creates a task for every occurrence of the rule after the last
+// materialized one, up to and including until
+func Materialize(db *gorm.DB, rule *models.RecurrenceRule, until time.Time) (int, error) {
+	created := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for {
+			next, ok := NextOccurrence(rule, rule.LastOccurrenceAt)
+			if !ok || next.After(until) {
+				break
+			}
+			if _, err := createOccurrence(tx, rule, next); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	return created, err
+}
+
+// AdvanceAfterCompletion makes sure the rule still has a pending occurrence
+// after one was completed, creating the next one if necessary. It returns the
+// created task, or nil if none was needed or the rule has ended.
+func AdvanceAfterCompletion(tx *gorm.DB, ruleID uint) (*models.Task, error) {
+	var rule models.RecurrenceRule
+	if err := tx.First(&rule, ruleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// The rule was deleted; the series has ended
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load recurrence rule: %w", err)
+	}
+
+	var open int64
+	if err := tx.Model(&models.Task{}).
+		Where("recurrence_rule_id = ? AND status IN ?", rule.ID, openStatuses).
+		Count(&open).Error; err != nil {
+		return nil, fmt.Errorf("failed to count open occurrences: %w", err)
+	}
+	if open > 0 {
+		return nil, nil
+	}
+
+	next, ok := NextOccurrence(&rule, rule.LastOccurrenceAt)
+	if !ok {
+		return nil, nil
+	}
+	return createOccurrence(tx, &rule, next)
+}
+
+// createOccurrence inserts a task for the given occurrence and records it as
+// the rule's latest materialized occurrence
+func createOccurrence(tx *gorm.DB, rule *models.RecurrenceRule, dueDate time.Time) (*models.Task, error) {
+	ruleID := rule.ID
+	task := models.Task{
+		Title:            rule.Title,
+		Description:      rule.Description,
+		Priority:         rule.Priority,
+		Status:           models.StatusTodo,
+		DueDate:          &dueDate,
+		UserID:           rule.UserID,
+		RecurrenceRuleID: &ruleID,
+	}
+	if err := workflow.Place(tx, &task); err != nil {
+		return nil, fmt.Errorf("failed to place occurrence: %w", err)
+	}
+	if err := tx.Create(&task).Error; err != nil {
+		return nil, fmt.Errorf("failed to create occurrence: %w", err)
+	}
+	if err := activity.Record(tx, models.ActionCreated, nil, nil, &task); err != nil {
+		return nil, err
+	}
+	if err := webhooks.Enqueue(tx, task.UserID, models.EventTaskCreated, task); err != nil {
+		return nil, err
+	}
+	events.Stage(tx, task.UserID, events.TaskCreated, task)
+
+	rule.LastOccurrenceAt = dueDate
+	if err := tx.Model(rule).Update("last_occurrence_at", dueDate).Error; err != nil {
+		return nil, fmt.Errorf("failed to update recurrence rule: %w", err)
+	}
+	return &task, nil
+}
@@ -0,0 +1,146 @@
+package reminders
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"task-management-api/models"
+	"task-management-api/webhooks"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KindOverdue is the notification kind for overdue notices
+const KindOverdue = "overdue"
+
+// openStatuses are the task statuses that still need reminders
+var openStatuses = []models.TaskStatus{models.StatusTodo, models.StatusInProgress}
+
+// notSentYet excludes tasks that already got a notification of a kind for their current due date
+const notSentYet = "NOT EXISTS (SELECT 1 FROM task_notifications n WHERE n.task_id = tasks.id AND n.kind = ? AND n.due_at = tasks.due_date)"
+
+// ReminderData is the webhook payload of a task.reminder event
+type ReminderData struct {
+	Task   models.Task `json:"task"`
+	Offset string      `json:"offset"` // How long before the due date the reminder fired, e.g. "1h0m0s"
+}
+
+// Scheduler fires due-date reminders and overdue notices as webhook events
+type Scheduler struct {
+	db       *gorm.DB
+	interval time.Duration
+	offsets  []time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler that runs every interval and sends a
+// reminder at each offset before a task's due date
+func NewScheduler(db *gorm.DB, interval time.Duration, offsets []time.Duration) *Scheduler {
+	sorted := append([]time.Duration(nil), offsets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &Scheduler{
+		db:       db,
+		interval: interval,
+		offsets:  sorted,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs the scheduler in the background until Stop is called
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.RunOnce(time.Now()); err != nil {
+					slog.Error("reminder scheduler failed", "error", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduler to exit and waits for the current run to finish
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// RunOnce sends all reminders and overdue notices that are due at now
+func (s *Scheduler) RunOnce(now time.Time) error {
+	// Reminders for tasks due within each offset. Each offset only covers the
+	// window beyond the next smaller one, so a task created shortly before its
+	// due date gets the closest reminder instead of all of them at once.
+	windowStart := now
+	for _, offset := range s.offsets {
+		kind := "reminder:" + offset.String()
+		windowEnd := now.Add(offset)
+
+		var tasks []models.Task
+		if err := s.db.Where("status IN ? AND due_date IS NOT NULL AND due_date > ? AND due_date <= ?",
+			openStatuses, windowStart, windowEnd).
+			Where(notSentYet, kind).
+			Find(&tasks).Error; err != nil {
+			return fmt.Errorf("failed to load tasks for reminders: %w", err)
+		}
+
+		for i := range tasks {
+			data := ReminderData{Task: tasks[i], Offset: offset.String()}
+			if err := s.notify(&tasks[i], kind, models.EventTaskReminder, data); err != nil {
+				return err
+			}
+		}
+		windowStart = windowEnd
+	}
+
+	// Overdue notices for open tasks past their due date
+	var overdue []models.Task
+	if err := s.db.Where("status IN ? AND due_date IS NOT NULL AND due_date <= ?", openStatuses, now).
+		Where(notSentYet, KindOverdue).
+		Find(&overdue).Error; err != nil {
+		return fmt.Errorf("failed to load overdue tasks: %w", err)
+	}
+	for i := range overdue {
+		if err := s.notify(&overdue[i], KindOverdue, models.EventTaskOverdue, overdue[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notify records a notification for the task's current due date and queues
+// the webhook event, unless that notification was already sent
+func (s *Scheduler) notify(task *models.Task, kind string, event models.WebhookEvent, data interface{}) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.TaskNotification{}).
+			Where("task_id = ? AND kind = ? AND due_at = ?", task.ID, kind, *task.DueDate).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check notifications: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		notification := models.TaskNotification{TaskID: task.ID, Kind: kind, DueAt: *task.DueDate}
+		if err := tx.Create(&notification).Error; err != nil {
+			return fmt.Errorf("failed to record notification: %w", err)
+		}
+
+		slog.Info("sending task notification", "task_id", task.ID, "kind", kind)
+		return webhooks.Enqueue(tx, task.UserID, event, data)
+	})
+}
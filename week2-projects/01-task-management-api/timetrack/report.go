@@ -0,0 +1,259 @@
+package timetrack
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"task-management-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidReport is returned for report parameters that cannot be used
+var ErrInvalidReport = errors.New("invalid time report")
+
+// dateLayout is the layout of report dates and day and week keys
+const dateLayout = "2006-01-02"
+
+// Report summarizes the time the user tracked on their tasks. Time is counted
+// within from/to when given, split at day or week boundaries in the report's
+// time zone, and grouped as requested. Running timers count up to now.
+// Deleted tasks are left out.
+func Report(db *gorm.DB, userID uint, params models.TimeReportParams, now time.Time) (*models.TimeStats, error) {
+	tz := params.TimeZone
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidReport, tz)
+	}
+
+	stats := models.TimeStats{
+		From:     params.From,
+		To:       params.To,
+		TimeZone: tz,
+		GroupBy:  params.GroupBy,
+	}
+
+	// Resolve the range; to is inclusive
+	var from, to *time.Time
+	if params.From != "" {
+		start, err := time.ParseInLocation(dateLayout, params.From, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidReport)
+		}
+		from = &start
+	}
+	if params.To != "" {
+		day, err := time.ParseInLocation(dateLayout, params.To, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidReport)
+		}
+		end := day.AddDate(0, 0, 1)
+		to = &end
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidReport)
+	}
+
+	// Load entries overlapping the range
+	query := db.Model(&models.TimeEntry{}).
+		Joins("JOIN tasks ON tasks.id = time_entries.task_id AND tasks.deleted_at IS NULL").
+		Where("time_entries.user_id = ?", userID)
+	if from != nil {
+		query = query.Where("time_entries.ended_at IS NULL OR time_entries.ended_at > ?", from.UTC())
+	}
+	if to != nil {
+		query = query.Where("time_entries.started_at < ?", to.UTC())
+	}
+	var entries []models.TimeEntry
+	if err := query.Select("time_entries.*").Order("time_entries.started_at asc").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load time entries: %w", err)
+	}
+
+	// Sum time per group
+	totals := make(map[string]int64)
+	for _, entry := range entries {
+		start := entry.StartedAt.In(loc)
+		end := now.In(loc)
+		if entry.EndedAt != nil {
+			end = entry.EndedAt.In(loc)
+		}
+		if from != nil && start.Before(*from) {
+			start = *from
+		}
+		if to != nil && end.After(*to) {
+			end = *to
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		stats.TrackedSeconds += seconds(start, end)
+		switch params.GroupBy {
+		case "day", "week":
+			// Split the entry where a new day or week begins
+			for start.Before(end) {
+				periodStart := startOf(start, params.GroupBy)
+				next := periodStart.AddDate(0, 0, 1)
+				if params.GroupBy == "week" {
+					next = periodStart.AddDate(0, 0, 7)
+				}
+				stop := end
+				if next.Before(stop) {
+					stop = next
+				}
+				totals[periodStart.Format(dateLayout)] += seconds(start, stop)
+				start = stop
+			}
+		case "task":
+			totals[strconv.FormatUint(uint64(entry.TaskID), 10)] += seconds(start, end)
+		}
+	}
+
+	if params.GroupBy != "" {
+		stats.Groups, err = groups(db, totals, params.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stats.RunningTimer, err = Running(db, userID); err != nil {
+		return nil, err
+	}
+	if stats.Estimates, err = estimates(db, userID, now); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// groups turns the per-group totals into report rows. Days and weeks are
+// listed in order, tasks by most time first.
+func groups(db *gorm.DB, totals map[string]int64, groupBy string) ([]models.TimeGroup, error) {
+	rows := make([]models.TimeGroup, 0, len(totals))
+	for key, total := range totals {
+		rows = append(rows, models.TimeGroup{Key: key, Seconds: total})
+	}
+
+	if groupBy != "task" {
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Key < rows[j].Key })
+		return rows, nil
+	}
+
+	// Name the tasks
+	taskIDs := make([]uint, 0, len(rows))
+	for i := range rows {
+		id, _ := strconv.ParseUint(rows[i].Key, 10, 32)
+		rows[i].TaskID = uint(id)
+		taskIDs = append(taskIDs, uint(id))
+	}
+	var tasks []models.Task
+	if len(taskIDs) > 0 {
+		if err := db.Select("id", "title", "estimate_minutes").Where("id IN ?", taskIDs).Find(&tasks).Error; err != nil {
+			return nil, fmt.Errorf("failed to load tasks: %w", err)
+		}
+	}
+	byID := make(map[uint]models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	for i := range rows {
+		task := byID[rows[i].TaskID]
+		rows[i].Title = task.Title
+		rows[i].EstimateMinutes = task.EstimateMinutes
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Seconds != rows[j].Seconds {
+			return rows[i].Seconds > rows[j].Seconds
+		}
+		return rows[i].TaskID < rows[j].TaskID
+	})
+	return rows, nil
+}
+
+// estimates compares the estimates of the user's tasks with all time tracked
+// on them
+func estimates(db *gorm.DB, userID uint, now time.Time) (models.EstimateStats, error) {
+	result := models.EstimateStats{OverEstimate: []models.TaskEstimate{}}
+
+	var tasks []models.Task
+	if err := db.Select("id", "title", "estimate_minutes").
+		Where("user_id = ? AND estimate_minutes > 0", userID).
+		Find(&tasks).Error; err != nil {
+		return result, fmt.Errorf("failed to load estimated tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		return result, nil
+	}
+
+	taskIDs := make([]uint, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = task.ID
+	}
+
+	// Stopped entries have their length stored; running ones count until now
+	var sums []struct {
+		TaskID  uint
+		Seconds int64
+	}
+	if err := db.Model(&models.TimeEntry{}).
+		Select("task_id, SUM(seconds) AS seconds").
+		Where("task_id IN ? AND ended_at IS NOT NULL", taskIDs).
+		Group("task_id").
+		Scan(&sums).Error; err != nil {
+		return result, fmt.Errorf("failed to sum time entries: %w", err)
+	}
+	actual := make(map[uint]int64, len(sums))
+	for _, sum := range sums {
+		actual[sum.TaskID] = sum.Seconds
+	}
+
+	var running []models.TimeEntry
+	if err := db.Where("task_id IN ? AND ended_at IS NULL", taskIDs).Find(&running).Error; err != nil {
+		return result, fmt.Errorf("failed to load running timers: %w", err)
+	}
+	for _, entry := range running {
+		Fill(&entry, now)
+		actual[entry.TaskID] += entry.Seconds
+	}
+
+	for _, task := range tasks {
+		estimate := int64(*task.EstimateMinutes) * 60
+		result.EstimatedTasks++
+		result.EstimatedSeconds += estimate
+		result.ActualSeconds += actual[task.ID]
+		if over := actual[task.ID] - estimate; over > 0 {
+			result.OverEstimate = append(result.OverEstimate, models.TaskEstimate{
+				TaskID:          task.ID,
+				Title:           task.Title,
+				EstimateMinutes: *task.EstimateMinutes,
+				ActualSeconds:   actual[task.ID],
+				OverSeconds:     over,
+			})
+		}
+	}
+	sort.Slice(result.OverEstimate, func(i, j int) bool {
+		return result.OverEstimate[i].OverSeconds > result.OverEstimate[j].OverSeconds
+	})
+	return result, nil
+}
+
+// startOf returns the start of the day, or of the week beginning on Monday,
+// that t falls in, in t's location
+func startOf(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if period == "week" {
+		offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// seconds returns the whole seconds between two times
+func seconds(start, end time.Time) int64 {
+	return int64(end.Sub(start) / time.Second)
+}
@@ -0,0 +1,230 @@
+// Package timetrack records the time users spend on tasks, with start/stop
+// timers and manual entries, and summarizes it for reports.
+package timetrack
+
+import (
+	"errors"
+	"fmt"
+	"task-management-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Errors caused by requests that cannot be recorded
+var (
+	ErrTimerRunning = errors.New("a timer is already running")
+	ErrNoTimer      = errors.New("no timer is running")
+	ErrInvalidEntry = errors.New("invalid time entry")
+)
+
+// MaxEntry is the longest time a manual entry may cover
+const MaxEntry = 24 * time.Hour
+
+// clockSkew is how far in the future a manual entry may end, so that clients
+// with slightly fast clocks can log time up to "now"
+const clockSkew = time.Minute
+
+// Running returns the user's running timer, or nil if none is running
+func Running(db *gorm.DB, userID uint) (*models.TimeEntry, error) {
+	var entries []models.TimeEntry
+	if err := db.Where("user_id = ? AND ended_at IS NULL", userID).Limit(1).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load running timer: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	Fill(&entries[0], time.Now())
+	return &entries[0], nil
+}
+
+// Start starts a timer on the task. Users can run one timer at a time, which
+// a unique index enforces even for concurrent requests; call Start outside of
+// a transaction so that a conflict can be reported as ErrTimerRunning.
+func Start(db *gorm.DB, task *models.Task, note string, now time.Time) (*models.TimeEntry, error) {
+	running, err := Running(db, task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		return nil, fmt.Errorf("%w on task %d; stop it first", ErrTimerRunning, running.TaskID)
+	}
+
+	entry := models.TimeEntry{
+		TaskID:    task.ID,
+		UserID:    task.UserID,
+		StartedAt: now.UTC(),
+		Note:      note,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		// Lost a race against another start
+		if running, _ := Running(db, task.UserID); running != nil {
+			return nil, fmt.Errorf("%w on task %d; stop it first", ErrTimerRunning, running.TaskID)
+		}
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+	Fill(&entry, now)
+	return &entry, nil
+}
+
+// Stop stops the user's timer on the task
+func Stop(db *gorm.DB, userID, taskID uint, now time.Time) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	if err := db.Where("user_id = ? AND task_id = ? AND ended_at IS NULL", userID, taskID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w on this task", ErrNoTimer)
+		}
+		return nil, fmt.Errorf("failed to load timer: %w", err)
+	}
+
+	stopped, err := stop(db, &entry, now)
+	if err != nil {
+		return nil, err
+	}
+	if !stopped {
+		return nil, fmt.Errorf("%w on this task", ErrNoTimer)
+	}
+	return &entry, nil
+}
+
+// StopTask stops any timer running on the task, for example because the
+// task is deleted
+func StopTask(tx *gorm.DB, taskID uint, now time.Time) error {
+	var entries []models.TimeEntry
+	if err := tx.Where("task_id = ? AND ended_at IS NULL", taskID).Find(&entries).Error; err != nil {
+		return fmt.Errorf("failed to load timers: %w", err)
+	}
+	for i := range entries {
+		if _, err := stop(tx, &entries[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stop ends a running entry at now. It reports false if the entry was
+// stopped concurrently.
+func stop(db *gorm.DB, entry *models.TimeEntry, now time.Time) (bool, error) {
+	now = now.UTC()
+	if now.Before(entry.StartedAt) {
+		now = entry.StartedAt
+	}
+	seconds := int64(now.Sub(entry.StartedAt) / time.Second)
+
+	result := db.Model(&models.TimeEntry{}).
+		Where("id = ? AND ended_at IS NULL", entry.ID).
+		Updates(map[string]interface{}{"ended_at": now, "seconds": seconds, "updated_at": now})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to stop timer: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	entry.EndedAt = &now
+	entry.Seconds = seconds
+	entry.UpdatedAt = now
+	entry.Running = false
+	return true, nil
+}
+
+// Log records time spent on the task manually
+func Log(db *gorm.DB, task *models.Task, req *models.CreateTimeEntryRequest, now time.Time) (*models.TimeEntry, error) {
+	start, end, err := span(req.StartedAt, req.EndedAt, req.Minutes, now)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := models.TimeEntry{
+		TaskID:    task.ID,
+		UserID:    task.UserID,
+		StartedAt: start,
+		EndedAt:   &end,
+		Seconds:   int64(end.Sub(start) / time.Second),
+		Note:      req.Note,
+		Manual:    true,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to log time: %w", err)
+	}
+	return &entry, nil
+}
+
+// Update corrects an entry. A running timer can only change its note.
+func Update(db *gorm.DB, entry *models.TimeEntry, req *models.UpdateTimeEntryRequest, now time.Time) error {
+	changesTime := req.StartedAt != nil || req.EndedAt != nil || req.Minutes != nil
+	if entry.EndedAt == nil && changesTime {
+		return fmt.Errorf("%w: stop the timer before changing its times", ErrInvalidEntry)
+	}
+
+	if changesTime {
+		start := entry.StartedAt
+		if req.StartedAt != nil {
+			start = *req.StartedAt
+		}
+
+		// Keep the end unless a new end or duration is given
+		endedAt, minutes := req.EndedAt, req.Minutes
+		if endedAt == nil && minutes == nil {
+			endedAt = entry.EndedAt
+		}
+
+		var end time.Time
+		var err error
+		start, end, err = span(start, endedAt, minutes, now)
+		if err != nil {
+			return err
+		}
+		entry.StartedAt = start
+		entry.EndedAt = &end
+		entry.Seconds = int64(end.Sub(start) / time.Second)
+		entry.Manual = true
+	}
+	if req.Note != nil {
+		entry.Note = *req.Note
+	}
+
+	if err := db.Save(entry).Error; err != nil {
+		return fmt.Errorf("failed to update time entry: %w", err)
+	}
+	Fill(entry, now)
+	return nil
+}
+
+// span resolves the period of a manual entry from its start and either its
+// end or its length in minutes
+func span(start time.Time, end *time.Time, minutes *int, now time.Time) (time.Time, time.Time, error) {
+	if (end == nil) == (minutes == nil) {
+		return start, start, fmt.Errorf("%w: give either ended_at or minutes", ErrInvalidEntry)
+	}
+
+	var stop time.Time
+	if end != nil {
+		stop = *end
+	} else {
+		stop = start.Add(time.Duration(*minutes) * time.Minute)
+	}
+
+	// Store UTC so that times compare correctly in SQLite, which compares
+	// them as text
+	start, stop = start.UTC(), stop.UTC()
+
+	switch {
+	case !stop.After(start):
+		return start, stop, fmt.Errorf("%w: ended_at must be after started_at", ErrInvalidEntry)
+	case stop.Sub(start) > MaxEntry:
+		return start, stop, fmt.Errorf("%w: an entry can cover at most 24 hours", ErrInvalidEntry)
+	case stop.After(now.Add(clockSkew)):
+		return start, stop, fmt.Errorf("%w: time entries cannot end in the future", ErrInvalidEntry)
+	}
+	return start, stop, nil
+}
+
+// Fill sets the running state of an entry and, while it runs, the seconds
+// elapsed so far
+func Fill(entry *models.TimeEntry, now time.Time) {
+	entry.Running = entry.EndedAt == nil
+	if entry.Running && now.After(entry.StartedAt) {
+		entry.Seconds = int64(now.Sub(entry.StartedAt) / time.Second)
+	}
+}
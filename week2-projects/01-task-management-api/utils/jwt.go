@@ -10,18 +10,33 @@ import (
 
 // JWTClaims represents the claims stored in the JWT token
 type JWTClaims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	UserID    uint   `json:"user_id"`
+	SessionID uint   `json:"sid"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a new JWT token for a user
-func GenerateToken(userID uint, username, email, secret string, expiration time.Duration) (string, error) {
+// TicketAudience is the audience of event stream tickets, which are not
+// accepted as access tokens
+const TicketAudience = "events"
+
+// TicketClaims represents the claims of an event stream ticket: a short-lived
+// token for clients that cannot send headers, such as the browser EventSource
+type TicketClaims struct {
+	UserID      uint             `json:"user_id"`
+	SessionID   uint             `json:"sid"`
+	StreamUntil *jwt.NumericDate `json:"stream_until"` // When the stream ends, as the access token expires
+	jwt.RegisteredClaims
+}
+
+// GenerateToken generates a new JWT access token for a user's session
+func GenerateToken(userID, sessionID uint, username, email, secret string, expiration time.Duration) (string, error) {
 	claims := JWTClaims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
+		UserID:    userID,
+		SessionID: sessionID,
+		Username:  username,
+		Email:     email,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -40,14 +55,7 @@ func GenerateToken(userID uint, username, email, secret string, expiration time.
 
 // ValidateToken validates a JWT token and returns the claims
 func ValidateToken(tokenString, secret string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
-
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, signingKey(secret))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
@@ -57,5 +65,61 @@ func ValidateToken(tokenString, secret string) (*JWTClaims, error) {
 		return nil, errors.New("invalid token claims")
 	}
 
+	// Tickets are signed with the same secret but only open event streams
+	if len(claims.Audience) > 0 {
+		return nil, errors.New("not an access token")
+	}
+
+	return claims, nil
+}
+
+// GenerateTicket generates an event stream ticket for a user's session. The
+// ticket can be used until expiration; the stream it opens lasts until
+// streamUntil.
+func GenerateTicket(userID, sessionID uint, secret string, expiration time.Duration, streamUntil time.Time) (string, error) {
+	claims := TicketClaims{
+		UserID:      userID,
+		SessionID:   sessionID,
+		StreamUntil: jwt.NewNumericDate(streamUntil),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{TicketAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign ticket: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// ValidateTicket validates an event stream ticket and returns its claims
+func ValidateTicket(tokenString, secret string) (*TicketClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TicketClaims{}, signingKey(secret), jwt.WithAudience(TicketAudience))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ticket: %w", err)
+	}
+
+	claims, ok := token.Claims.(*TicketClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid ticket claims")
+	}
+
 	return claims, nil
 }
+
+// signingKey returns the key function of tokens signed with secret
+func signingKey(secret string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		// Verify signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	}
+}
@@ -1,6 +1,11 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -17,3 +22,60 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// Password policy limits. bcrypt ignores everything after 72 bytes, so longer
+// passwords are refused instead of being silently truncated.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// commonPasswords are passwords that meet the other rules but are among the
+// first tried by any attacker
+var commonPasswords = map[string]bool{
+	"password1": true, "password123": true, "passw0rd": true, "p@ssw0rd": true,
+	"12345678a": true, "qwerty123": true, "qwertyuiop1": true, "abc12345": true,
+	"abcd1234": true, "iloveyou1": true, "welcome1": true, "welcome123": true,
+	"letmein1": true, "admin123": true, "administrator1": true, "changeme1": true,
+	"football1": true, "baseball1": true, "monkey123": true, "dragon123": true,
+	"sunshine1": true, "princess1": true, "trustno1": true, "1q2w3e4r": true,
+	"1qaz2wsx": true, "zaq12wsx": true, "secret123": true, "test1234": true,
+}
+
+// ValidatePassword checks a new password against the password policy: it
+// must be 8 to 72 bytes long, contain a letter and a digit or symbol, and must
+// not be a common password or contain the username or email name.
+func ValidatePassword(password, username, email string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters long", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes long", MaxPasswordLength)
+	}
+
+	var hasLetter, hasOther bool
+	for _, r := range password {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		} else if !unicode.IsSpace(r) {
+			hasOther = true
+		}
+	}
+	if !hasLetter || !hasOther {
+		return errors.New("password must contain a letter and a digit or symbol")
+	}
+
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return errors.New("password is too common")
+	}
+
+	// Names shorter than 3 characters would reject too many passwords
+	name, _, _ := strings.Cut(strings.ToLower(email), "@")
+	for _, part := range []string{strings.ToLower(username), name} {
+		if len(part) >= 3 && strings.Contains(lower, part) {
+			return errors.New("password must not contain your username or email address")
+		}
+	}
+	return nil
+}
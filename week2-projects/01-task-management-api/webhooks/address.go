@@ -0,0 +1,124 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Reasons a webhook URL is refused
+var (
+	ErrInvalidURL       = errors.New("webhook URL must use http or https")
+	ErrUnresolvableHost = errors.New("webhook host could not be resolved")
+	ErrPrivateAddress   = errors.New("webhook URL must not point to a loopback, link-local or private address")
+)
+
+// lookupTimeout bounds the DNS lookup of a webhook host at registration
+const lookupTimeout = 5 * time.Second
+
+// reservedNetworks are ranges not covered by the net.IP predicates that still
+// lead inside the network: "this network", carrier-grade NAT, IETF protocol
+// assignments, benchmarking, and NAT64, which can embed any IPv4 address
+var reservedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"64:ff9b::/96",
+)
+
+// ValidateURL checks that a webhook URL uses http or https and that its host
+// resolves only to public addresses, so webhooks cannot be used to reach the
+// server's own network. Deliveries are checked again when they connect, as
+// DNS answers can change after registration.
+func ValidateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrInvalidURL
+	}
+
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPublicIP(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrUnresolvableHost
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// newClient returns the HTTP client deliveries are sent with. Every
+// connection, redirects included, is refused unless it goes to a public
+// address, and proxies from the environment are not used, since the check
+// would then only see the proxy.
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: checkDialAddress,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// checkDialAddress refuses connections to addresses that are not public. It
+// runs after DNS resolution, on the address actually dialled.
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+// isPublicIP reports whether an address is reachable on the public internet
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// mustParseCIDRs parses a list of CIDR ranges, panicking on invalid ones
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
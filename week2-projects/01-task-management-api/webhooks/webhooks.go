@@ -0,0 +1,315 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"task-management-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Backoff bounds between delivery attempts
+const (
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 1 * time.Hour
+)
+
+// batchSize limits how many deliveries are attempted per run
+const batchSize = 50
+
+// pruneInterval is how often finished deliveries older than the retention
+// period are deleted
+const pruneInterval = time.Hour
+
+// Envelope is the JSON body posted to webhooks
+type Envelope struct {
+	Event     models.WebhookEvent `json:"event"`
+	CreatedAt time.Time           `json:"created_at"`
+	Data      interface{}         `json:"data"`
+}
+
+// TaskUpdate is the data of a task.updated event
+type TaskUpdate struct {
+	Task    models.Task            `json:"task"`
+	Changes models.ActivityChanges `json:"changes"`
+}
+
+// GenerateSecret generates a random signing secret for a new webhook
+func GenerateSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
+
+// Sign returns the signature of a delivery: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret. Receivers should
+// recompute it and reject old timestamps to prevent replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Enqueue queues an event for every active webhook of the user that
+// subscribes to it. Call it inside the transaction that makes the change, so
+// events are only sent for committed changes and are never lost.
+func Enqueue(tx *gorm.DB, userID uint, event models.WebhookEvent, data interface{}) error {
+	var hooks []models.Webhook
+	if err := tx.Where("user_id = ? AND active = ?", userID, true).Find(&hooks).Error; err != nil {
+		return fmt.Errorf("failed to load webhooks: %w", err)
+	}
+
+	var payload []byte
+	now := time.Now()
+	for _, hook := range hooks {
+		if !hook.Subscribes(event) {
+			continue
+		}
+
+		if payload == nil {
+			var err error
+			payload, err = json.Marshal(Envelope{Event: event, CreatedAt: now, Data: data})
+			if err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+		}
+
+		delivery := models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		}
+		if err := tx.Create(&delivery).Error; err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// Dispatcher delivers queued webhook events in the background, retrying
+// failed deliveries with exponential backoff
+type Dispatcher struct {
+	db          *gorm.DB
+	client      *http.Client
+	interval    time.Duration
+	maxAttempts int
+	concurrency int
+	retention   time.Duration
+	lastPruned  time.Time
+	stop        chan struct{}
+	wg          sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher that checks for due deliveries every
+// interval, sending to up to concurrency webhooks at once. Delivered and
+// failed deliveries are deleted after retention, or kept if it is zero.
+// Deliveries to loopback, link-local or private addresses fail.
+func NewDispatcher(db *gorm.DB, interval, timeout time.Duration, maxAttempts, concurrency int, retention time.Duration) *Dispatcher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Dispatcher{
+		db:          db,
+		client:      newClient(timeout),
+		interval:    interval,
+		maxAttempts: maxAttempts,
+		concurrency: concurrency,
+		retention:   retention,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start runs the dispatcher in the background until Stop is called
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := d.RunOnce(); err != nil {
+					slog.Error("webhook dispatcher failed", "error", err)
+				}
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the dispatcher to exit and waits for the current run to finish
+func (d *Dispatcher) Stop() {
+	close(d.stop)
+	d.wg.Wait()
+}
+
+// RunOnce attempts every pending delivery that is due. Deliveries are sent
+// in order per webhook, and to several webhooks at once, so a slow receiver
+// only holds up its own deliveries.
+func (d *Dispatcher) RunOnce() error {
+	var deliveries []models.WebhookDelivery
+	if err := d.db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Order("next_attempt_at asc").
+		Limit(batchSize).
+		Find(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+
+	var order []uint
+	byWebhook := make(map[uint][]*models.WebhookDelivery)
+	for i := range deliveries {
+		id := deliveries[i].WebhookID
+		if _, ok := byWebhook[id]; !ok {
+			order = append(order, id)
+		}
+		byWebhook[id] = append(byWebhook[id], &deliveries[i])
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, d.concurrency)
+	for _, id := range order {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(queue []*models.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			for _, delivery := range queue {
+				d.attempt(delivery)
+			}
+		}(byWebhook[id])
+	}
+	wg.Wait()
+
+	return d.prune(time.Now())
+}
+
+// attempt sends one delivery and records the outcome. A failure to record it
+// is logged; the delivery stays due and is sent again on the next run.
+func (d *Dispatcher) attempt(delivery *models.WebhookDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	var hook models.Webhook
+	if err := d.db.First(&hook, delivery.WebhookID).Error; err != nil || !hook.Active {
+		// The webhook was deleted or disabled after the event was queued
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = "webhook deleted or inactive"
+		d.save(delivery)
+		return
+	}
+
+	status, err := d.send(&hook, delivery)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		d.save(delivery)
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.maxAttempts {
+		delivery.Status = models.DeliveryFailed
+	} else {
+		delivery.NextAttemptAt = now.Add(backoff(delivery.Attempts))
+	}
+	d.save(delivery)
+}
+
+// send posts the delivery payload to the webhook URL. Any 2xx response counts
+// as success.
+func (d *Dispatcher) send(hook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "task-management-api-webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// save persists the outcome of a delivery attempt, logging a failure
+func (d *Dispatcher) save(delivery *models.WebhookDelivery) {
+	if err := d.db.Save(delivery).Error; err != nil {
+		slog.Error("failed to update webhook delivery", "delivery_id", delivery.ID, "webhook_id", delivery.WebhookID, "error", err)
+	}
+}
+
+// prune deletes delivered and failed deliveries older than the retention
+// period, at most once per pruneInterval
+func (d *Dispatcher) prune(now time.Time) error {
+	if d.retention <= 0 || now.Sub(d.lastPruned) < pruneInterval {
+		return nil
+	}
+	result := d.db.Where("status IN ? AND created_at < ?",
+		[]models.DeliveryStatus{models.DeliverySucceeded, models.DeliveryFailed}, now.Add(-d.retention)).
+		Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to prune webhook deliveries: %w", result.Error)
+	}
+	d.lastPruned = now
+	if result.RowsAffected > 0 {
+		slog.Info("pruned webhook deliveries", "deleted", result.RowsAffected)
+	}
+	return nil
+}
+
+// backoff returns the delay before the next attempt: 30s, 1m, 2m, ... capped at 1h
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
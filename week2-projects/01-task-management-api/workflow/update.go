@@ -0,0 +1,157 @@
+package workflow
+
+import (
+	"fmt"
+	"task-management-api/events"
+	"task-management-api/models"
+
+	"gorm.io/gorm"
+)
+
+// Replace replaces the user's columns and transitions. Columns are matched to
+// the existing ones by key; a column that still holds tasks can neither be
+// removed nor change its category, so task statuses stay consistent.
+func Replace(tx *gorm.DB, userID uint, req *models.UpdateWorkflowRequest) (*models.Workflow, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	workflow, err := Load(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]models.WorkflowColumn)
+	for _, column := range workflow.Columns {
+		existing[column.Key] = column
+	}
+
+	// Remove columns that are no longer listed
+	kept := make(map[string]bool)
+	for _, column := range req.Columns {
+		kept[column.Key] = true
+	}
+	for _, column := range workflow.Columns {
+		if kept[column.Key] {
+			continue
+		}
+		if err := checkEmpty(tx, column); err != nil {
+			return nil, err
+		}
+		// Deleted tasks keep no reference to the removed column
+		if err := tx.Unscoped().Model(&models.Task{}).Where("column_id = ?", column.ID).
+			UpdateColumn("column_id", nil).Error; err != nil {
+			return nil, fmt.Errorf("failed to remove column: %w", err)
+		}
+		if err := tx.Delete(&models.WorkflowColumn{}, column.ID).Error; err != nil {
+			return nil, fmt.Errorf("failed to remove column: %w", err)
+		}
+	}
+
+	// Update kept columns and add new ones in board order
+	columnIDs := make(map[string]uint)
+	for i, req := range req.Columns {
+		column, ok := existing[req.Key]
+		if ok && column.Category != req.Category {
+			if err := checkEmpty(tx, column); err != nil {
+				return nil, err
+			}
+		}
+		column.WorkflowID = workflow.ID
+		column.Key = req.Key
+		column.Name = req.Name
+		column.Category = req.Category
+		column.Position = i
+		if err := tx.Save(&column).Error; err != nil {
+			return nil, fmt.Errorf("failed to save column: %w", err)
+		}
+		columnIDs[column.Key] = column.ID
+	}
+
+	// Replace transitions
+	if err := tx.Where("workflow_id = ?", workflow.ID).Delete(&models.WorkflowTransition{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to replace transitions: %w", err)
+	}
+	seen := make(map[[2]uint]bool)
+	for _, req := range req.Transitions {
+		pair := [2]uint{columnIDs[req.From], columnIDs[req.To]}
+		if seen[pair] {
+			continue
+		}
+		seen[pair] = true
+		transition := models.WorkflowTransition{WorkflowID: workflow.ID, FromColumnID: pair[0], ToColumnID: pair[1]}
+		if err := tx.Create(&transition).Error; err != nil {
+			return nil, fmt.Errorf("failed to replace transitions: %w", err)
+		}
+	}
+
+	// Touch the workflow so updated_at reflects the change. Updating by ID
+	// keeps gorm from saving the stale preloaded columns.
+	if err := tx.Model(&models.Workflow{}).Where("id = ?", workflow.ID).Update("updated_at", tx.NowFunc()).Error; err != nil {
+		return nil, fmt.Errorf("failed to update workflow: %w", err)
+	}
+
+	updated, err := find(tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow: %w", err)
+	}
+	events.Stage(tx, userID, events.WorkflowUpdated, updated)
+	return updated, nil
+}
+
+// validate checks a workflow request on its own: unique keys, a column for
+// new tasks, and transitions between listed columns
+func validate(req *models.UpdateWorkflowRequest) error {
+	keys := make(map[string]bool)
+	hasTodo := false
+	for _, column := range req.Columns {
+		if !validKey(column.Key) {
+			return fmt.Errorf("%w: column key %q may only contain lowercase letters, digits, '-' and '_'", ErrInvalidWorkflow, column.Key)
+		}
+		if keys[column.Key] {
+			return fmt.Errorf("%w: duplicate column key %q", ErrInvalidWorkflow, column.Key)
+		}
+		keys[column.Key] = true
+		if column.Category == models.StatusTodo {
+			hasTodo = true
+		}
+	}
+	if !hasTodo {
+		return fmt.Errorf("%w: at least one column must have the todo category", ErrInvalidWorkflow)
+	}
+
+	for _, transition := range req.Transitions {
+		if !keys[transition.From] {
+			return fmt.Errorf("%w: transition from unknown column %q", ErrInvalidWorkflow, transition.From)
+		}
+		if !keys[transition.To] {
+			return fmt.Errorf("%w: transition to unknown column %q", ErrInvalidWorkflow, transition.To)
+		}
+		if transition.From == transition.To {
+			return fmt.Errorf("%w: transition from %q to itself", ErrInvalidWorkflow, transition.From)
+		}
+	}
+	return nil
+}
+
+// validKey reports whether a column key is a lowercase slug
+func validKey(key string) bool {
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return key != ""
+}
+
+// checkEmpty fails with ErrColumnInUse if the column holds any task
+func checkEmpty(tx *gorm.DB, column models.WorkflowColumn) error {
+	var count int64
+	if err := tx.Model(&models.Task{}).Where("column_id = ?", column.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count column tasks: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: column %q has %d task(s); move them first", ErrColumnInUse, column.Key, count)
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+// Package workflow manages kanban boards: each user's columns, the moves
+// allowed between them, and where every task sits in its column.
+//
+// A task's status always equals the category of its column. Tasks within a
+// column are ordered by a fractional rank (see package rank), so moving a
+// task only rewrites that task.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/rank"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors caused by requests that break the workflow's rules
+var (
+	ErrColumnNotFound       = errors.New("column not found")
+	ErrNoColumnForStatus    = errors.New("the workflow has no column for this status")
+	ErrTransitionNotAllowed = errors.New("the workflow does not allow this move")
+	ErrInvalidPosition      = errors.New("invalid position")
+	ErrInvalidWorkflow      = errors.New("invalid workflow")
+	ErrColumnInUse          = errors.New("column still has tasks")
+)
+
+// defaultColumns is the workflow every user starts with, one column per status
+var defaultColumns = []models.WorkflowColumn{
+	{Key: "todo", Name: "To Do", Category: models.StatusTodo},
+	{Key: "in_progress", Name: "In Progress", Category: models.StatusInProgress},
+	{Key: "completed", Name: "Done", Category: models.StatusCompleted},
+	{Key: "cancelled", Name: "Cancelled", Category: models.StatusCancelled},
+}
+
+// Load returns the user's workflow with its columns in board order, creating
+// the default workflow on first use
+func Load(tx *gorm.DB, userID uint) (*models.Workflow, error) {
+	workflow, err := find(tx, userID)
+	if err == nil {
+		return workflow, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load workflow: %w", err)
+	}
+
+	// Creating it is a no-op if another request created it first
+	if err := create(tx, userID); err != nil {
+		return nil, err
+	}
+	workflow, err = find(tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow: %w", err)
+	}
+	return workflow, nil
+}
+
+// find loads an existing workflow with its columns and transitions
+func find(tx *gorm.DB, userID uint) (*models.Workflow, error) {
+	var workflow models.Workflow
+	err := tx.Preload("Columns", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position asc, id asc")
+	}).Preload("Transitions").Where("user_id = ?", userID).First(&workflow).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if workflow.Transitions == nil {
+		workflow.Transitions = []models.WorkflowTransition{}
+	}
+
+	// Name transition ends by column key
+	for i := range workflow.Transitions {
+		transition := &workflow.Transitions[i]
+		if from := column(&workflow, transition.FromColumnID); from != nil {
+			transition.From = from.Key
+		}
+		if to := column(&workflow, transition.ToColumnID); to != nil {
+			transition.To = to.Key
+		}
+	}
+	return &workflow, nil
+}
+
+// create inserts the default workflow and places the user's existing tasks in
+// its columns. It does nothing if the user already has a workflow.
+func create(tx *gorm.DB, userID uint) error {
+	workflow := models.Workflow{UserID: userID}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&workflow)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create workflow: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	columns := make([]models.WorkflowColumn, len(defaultColumns))
+	for i, column := range defaultColumns {
+		column.WorkflowID = workflow.ID
+		column.Position = i
+		columns[i] = column
+	}
+	if err := tx.Create(&columns).Error; err != nil {
+		return fmt.Errorf("failed to create workflow columns: %w", err)
+	}
+
+	// Tasks created before workflows existed keep their order by creation
+	for i := range columns {
+		var taskIDs []uint
+		if err := tx.Unscoped().Model(&models.Task{}).
+			Where("user_id = ? AND column_id IS NULL AND status = ?", userID, columns[i].Category).
+			Order("created_at asc, id asc").
+			Pluck("id", &taskIDs).Error; err != nil {
+			return fmt.Errorf("failed to place existing tasks: %w", err)
+		}
+		for j, key := range rank.Spread(len(taskIDs)) {
+			if err := tx.Unscoped().Model(&models.Task{}).Where("id = ?", taskIDs[j]).
+				UpdateColumns(map[string]interface{}{"column_id": columns[i].ID, "rank": key}).Error; err != nil {
+				return fmt.Errorf("failed to place existing tasks: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Place puts a new task at the bottom of the first column for its status.
+// Call it before the task is inserted.
+func Place(tx *gorm.DB, task *models.Task) error {
+	workflow, err := Load(tx, task.UserID)
+	if err != nil {
+		return err
+	}
+
+	target := columnFor(workflow, task.Status)
+	if target == nil {
+		return fmt.Errorf("%w: %q", ErrNoColumnForStatus, task.Status)
+	}
+	key, err := positionRank(tx, task, target.ID, nil, nil)
+	if err != nil {
+		return err
+	}
+	task.ColumnID = &target.ID
+	task.Rank = key
+	return nil
+}
+
+// SetStatus changes a task's status. Unless its column already has that
+// category, the task moves to the bottom of the first column for the status.
+func SetStatus(tx *gorm.DB, task *models.Task, status models.TaskStatus) error {
+	workflow, err := Load(tx, task.UserID)
+	if err != nil {
+		return err
+	}
+
+	if task.ColumnID != nil {
+		if current := column(workflow, *task.ColumnID); current != nil && current.Category == status {
+			task.Status = status
+			return nil
+		}
+	}
+
+	target := columnFor(workflow, status)
+	if target == nil {
+		return fmt.Errorf("%w: %q", ErrNoColumnForStatus, status)
+	}
+	return moveTo(tx, workflow, task, target, nil, nil)
+}
+
+// Move moves a task into a column, directly after the task afterID or before
+// the task beforeID, or to the bottom if neither is given. The task takes the
+// status of the column's category.
+func Move(tx *gorm.DB, task *models.Task, columnID uint, afterID, beforeID *uint) error {
+	workflow, err := Load(tx, task.UserID)
+	if err != nil {
+		return err
+	}
+
+	target := column(workflow, columnID)
+	if target == nil {
+		return ErrColumnNotFound
+	}
+	return moveTo(tx, workflow, task, target, afterID, beforeID)
+}
+
+// moveTo checks that the workflow allows the move and places the task
+func moveTo(tx *gorm.DB, workflow *models.Workflow, task *models.Task, target *models.WorkflowColumn, afterID, beforeID *uint) error {
+	if task.ColumnID != nil && !allowed(workflow, *task.ColumnID, target.ID) {
+		from := "its column"
+		if current := column(workflow, *task.ColumnID); current != nil {
+			from = fmt.Sprintf("%q", current.Key)
+		}
+		return fmt.Errorf("%w: tasks cannot move from %s to %q", ErrTransitionNotAllowed, from, target.Key)
+	}
+
+	key, err := positionRank(tx, task, target.ID, afterID, beforeID)
+	if err != nil {
+		return err
+	}
+	task.ColumnID = &target.ID
+	task.Rank = key
+	task.Status = target.Category
+	return nil
+}
+
+// positionRank returns the rank placing the task at the requested position
+// in the column. Ranks that collide or grow too long are fixed by
+// rebalancing the column once.
+func positionRank(tx *gorm.DB, task *models.Task, columnID uint, afterID, beforeID *uint) (string, error) {
+	key, err := neighborRank(tx, task, columnID, afterID, beforeID)
+	if err == nil && len(key) <= rank.MaxLength {
+		return key, nil
+	}
+	if err != nil && !errors.Is(err, rank.ErrInvalidRange) {
+		return "", err
+	}
+
+	if err := Rebalance(tx, task.UserID, columnID); err != nil {
+		return "", err
+	}
+	key, err = neighborRank(tx, task, columnID, afterID, beforeID)
+	if errors.Is(err, rank.ErrInvalidRange) {
+		return "", fmt.Errorf("failed to rank task: %w", err)
+	}
+	return key, err
+}
+
+// neighborRank computes a rank between the task's new neighbors
+func neighborRank(tx *gorm.DB, task *models.Task, columnID uint, afterID, beforeID *uint) (string, error) {
+	// Other tasks of the column, in board order
+	others := func() *gorm.DB {
+		return tx.Model(&models.Task{}).Where("column_id = ? AND id <> ?", columnID, task.ID)
+	}
+
+	var after, before *models.Task
+	var err error
+	if afterID != nil {
+		if after, err = neighbor(tx, task, columnID, *afterID, "after_task_id"); err != nil {
+			return "", err
+		}
+	}
+	if beforeID != nil {
+		if before, err = neighbor(tx, task, columnID, *beforeID, "before_task_id"); err != nil {
+			return "", err
+		}
+	}
+
+	var lower, upper string
+	switch {
+	case after != nil && before != nil:
+		if after.ID == before.ID || after.Rank > before.Rank || (after.Rank == before.Rank && after.ID > before.ID) {
+			return "", fmt.Errorf("%w: after_task_id must come before before_task_id", ErrInvalidPosition)
+		}
+		lower, upper = after.Rank, before.Rank
+	case after != nil:
+		// Directly after: up to the next task in the column
+		lower = after.Rank
+		var next []string
+		if err := others().Where("id <> ? AND rank >= ?", after.ID, after.Rank).
+			Order("rank asc, id asc").Limit(1).Pluck("rank", &next).Error; err != nil {
+			return "", fmt.Errorf("failed to rank task: %w", err)
+		}
+		if len(next) > 0 {
+			upper = next[0]
+		}
+	case before != nil:
+		// Directly before: down to the previous task in the column
+		upper = before.Rank
+		var previous []string
+		if err := others().Where("id <> ? AND rank <= ?", before.ID, before.Rank).
+			Order("rank desc, id desc").Limit(1).Pluck("rank", &previous).Error; err != nil {
+			return "", fmt.Errorf("failed to rank task: %w", err)
+		}
+		if len(previous) > 0 {
+			lower = previous[0]
+		}
+	default:
+		// Bottom of the column
+		var last []string
+		if err := others().Order("rank desc, id desc").Limit(1).Pluck("rank", &last).Error; err != nil {
+			return "", fmt.Errorf("failed to rank task: %w", err)
+		}
+		if len(last) > 0 {
+			lower = last[0]
+		}
+	}
+
+	return rank.Between(lower, upper)
+}
+
+// neighbor loads a task the moved task is placed next to, which must be
+// another task of the same user in the target column
+func neighbor(tx *gorm.DB, task *models.Task, columnID, id uint, field string) (*models.Task, error) {
+	if id == task.ID {
+		return nil, fmt.Errorf("%w: %s cannot be the moved task", ErrInvalidPosition, field)
+	}
+	var other models.Task
+	if err := tx.Where("id = ? AND user_id = ? AND column_id = ?", id, task.UserID, columnID).First(&other).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: task %d of %s is not in the target column", ErrInvalidPosition, id, field)
+		}
+		return nil, fmt.Errorf("failed to load task %d: %w", id, err)
+	}
+	return &other, nil
+}
+
+// Rebalance gives all tasks of a column evenly spaced ranks, keeping their
+// order. Clients are told to reload the column.
+func Rebalance(tx *gorm.DB, userID, columnID uint) error {
+	var taskIDs []uint
+	if err := tx.Model(&models.Task{}).Where("column_id = ?", columnID).
+		Order("rank asc, id asc").Pluck("id", &taskIDs).Error; err != nil {
+		return fmt.Errorf("failed to rebalance column: %w", err)
+	}
+	for i, key := range rank.Spread(len(taskIDs)) {
+		if err := tx.Model(&models.Task{}).Where("id = ?", taskIDs[i]).UpdateColumn("rank", key).Error; err != nil {
+			return fmt.Errorf("failed to rebalance column: %w", err)
+		}
+	}
+	events.Stage(tx, userID, events.ColumnRebalanced, map[string]uint{"column_id": columnID})
+	return nil
+}
+
+// column returns the workflow's column with the given ID, or nil
+func column(workflow *models.Workflow, id uint) *models.WorkflowColumn {
+	for i := range workflow.Columns {
+		if workflow.Columns[i].ID == id {
+			return &workflow.Columns[i]
+		}
+	}
+	return nil
+}
+
+// columnFor returns the first column whose category is status, or nil
+func columnFor(workflow *models.Workflow, status models.TaskStatus) *models.WorkflowColumn {
+	for i := range workflow.Columns {
+		if workflow.Columns[i].Category == status {
+			return &workflow.Columns[i]
+		}
+	}
+	return nil
+}
+
+// allowed reports whether tasks may move between two columns. Moves within a
+// column are always allowed, and a workflow without transitions allows all.
+func allowed(workflow *models.Workflow, from, to uint) bool {
+	if from == to || len(workflow.Transitions) == 0 {
+		return true
+	}
+	for _, transition := range workflow.Transitions {
+		if transition.FromColumnID == from && transition.ToColumnID == to {
+			return true
+		}
+	}
+	return false
+}
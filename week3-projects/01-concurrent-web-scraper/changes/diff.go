@@ -0,0 +1,120 @@
+// Package changes compares two versions of a page's visible text and
+// summarizes what changed between them.
+package changes
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxSamples is how many added and how many removed lines a summary quotes
+	maxSamples = 3
+
+	// maxSampleLength caps the length of a quoted line
+	maxSampleLength = 100
+)
+
+// Summary describes the differences between two versions of a page
+type Summary struct {
+	TitleChanged bool
+	Added        int      // Lines in the new version only
+	Removed      int      // Lines in the old version only
+	Samples      []string // Some added ("+ ") and removed ("- ") lines
+}
+
+// Compare compares the title and text of two versions of a page, line by
+// line. Lines that only moved do not count as changes.
+func Compare(oldTitle, oldText, newTitle, newText string) Summary {
+	summary := Summary{TitleChanged: oldTitle != newTitle}
+
+	remaining := make(map[string]int)
+	for _, line := range lines(oldText) {
+		remaining[line]++
+	}
+
+	var added []string
+	for _, line := range lines(newText) {
+		if remaining[line] > 0 {
+			remaining[line]--
+			continue
+		}
+		summary.Added++
+		if len(added) < maxSamples {
+			added = append(added, "+ "+shorten(line))
+		}
+	}
+
+	// Lines of the old version left over were removed, in their old order
+	var removed []string
+	for _, line := range lines(oldText) {
+		if remaining[line] == 0 {
+			continue
+		}
+		remaining[line]--
+		summary.Removed++
+		if len(removed) < maxSamples {
+			removed = append(removed, "- "+shorten(line))
+		}
+	}
+
+	summary.Samples = append(added, removed...)
+	return summary
+}
+
+// Changed reports whether the text or title differ
+func (s Summary) Changed() bool {
+	return s.TitleChanged || s.Added > 0 || s.Removed > 0
+}
+
+// String returns a one-line description followed by the sample lines, such
+// as "title changed, 4 lines added, 1 removed"
+func (s Summary) String() string {
+	var parts []string
+	if s.TitleChanged {
+		parts = append(parts, "title changed")
+	}
+	if s.Added > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s added", s.Added, plural(s.Added, "line")))
+	}
+	if s.Removed > 0 {
+		if s.Added > 0 {
+			parts = append(parts, fmt.Sprintf("%d removed", s.Removed))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d %s removed", s.Removed, plural(s.Removed, "line")))
+		}
+	}
+	if len(parts) == 0 {
+		return "markup changed, text unchanged"
+	}
+
+	return strings.Join(append([]string{strings.Join(parts, ", ")}, s.Samples...), "\n")
+}
+
+// lines splits text into its non-empty, trimmed lines
+func lines(text string) []string {
+	var result []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// shorten cuts a line down to maxSampleLength characters
+func shorten(line string) string {
+	runes := []rune(line)
+	if len(runes) <= maxSampleLength {
+		return line
+	}
+	return string(runes[:maxSampleLength]) + "..."
+}
+
+// plural returns word, with an s unless n is 1
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"regexp"
 )
 
 // Config holds all configuration for the scraper
@@ -13,8 +15,18 @@ type Config struct {
 	// Rate limiting configuration (requests per second)
 	RateLimit float64 `json:"rate_limit"`
 
-	// Retry configuration
-	MaxRetries int `json:"max_retries"`
+	// Politeness configuration: the request rate and requests in flight
+	// allowed per host, under the overall RateLimit
+	HostRateLimit   float64 `json:"host_rate_limit"`
+	HostConcurrency int     `json:"host_concurrency"`
+
+	// Retry configuration: retryable errors are retried after an exponential
+	// backoff from RetryDelay (seconds), and a host failing BreakerThreshold
+	// times in a row is paused for BreakerCooldown (seconds)
+	MaxRetries       int     `json:"max_retries"`
+	RetryDelay       float64 `json:"retry_delay"`
+	BreakerThreshold int     `json:"breaker_threshold"`
+	BreakerCooldown  int     `json:"breaker_cooldown"`
 
 	// Timeout configuration (seconds)
 	RequestTimeout int `json:"request_timeout"`
@@ -30,19 +42,44 @@ type Config struct {
 
 	// Follow redirects
 	FollowRedirects bool `json:"follow_redirects"`
+
+	// Crawl configuration: links are followed up to MaxDepth hops from the
+	// seed URLs (0 scrapes only the seeds), within the scope and page budget
+	MaxDepth        int      `json:"max_depth"`
+	MaxPages        int      `json:"max_pages"`        // 0 for no limit
+	Scope           string   `json:"scope"`            // host, domain or any
+	IncludePatterns []string `json:"include_patterns"` // Followed URLs must match one, if any are set
+	ExcludePatterns []string `json:"exclude_patterns"` // Followed URLs must match none
+
+	// Hosts whose robots.txt is not obeyed, for sites we own
+	IgnoreRobots []string `json:"ignore_robots"`
+
+	// Extraction rules file: fields to pull out of the pages of each site
+	RulesFile string `json:"rules_file"`
+
+	// Keep the raw responses of pages for WARC export
+	ArchiveResponses bool `json:"archive_responses"`
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		WorkerCount:     5,
-		RateLimit:       2.0, // 2 requests per second
-		MaxRetries:      3,
-		RequestTimeout:  30,
-		DatabasePath:    "./data/scraper.db",
-		URLsFile:        "./urls.json",
-		UserAgent:       "GoWebScraper/1.0",
-		FollowRedirects: true,
+		WorkerCount:      5,
+		RateLimit:        2.0, // 2 requests per second
+		HostRateLimit:    1.0, // 1 request per second to each host
+		HostConcurrency:  2,
+		MaxRetries:       3,
+		RetryDelay:       1.0,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30,
+		RequestTimeout:   30,
+		DatabasePath:     "./data/scraper.db",
+		URLsFile:         "./urls.json",
+		UserAgent:        "GoWebScraper/1.0",
+		FollowRedirects:  true,
+		MaxDepth:         0,
+		MaxPages:         0,
+		Scope:            "host",
 	}
 }
 
@@ -87,6 +124,24 @@ func (c *Config) Validate() error {
 		c.RateLimit = 100
 	}
 
+	// Unset values in config files get the defaults
+	if c.HostRateLimit <= 0 {
+		c.HostRateLimit = 1.0
+	}
+	if c.HostRateLimit < 0.1 {
+		c.HostRateLimit = 0.1
+	}
+	if c.HostRateLimit > 100 {
+		c.HostRateLimit = 100
+	}
+
+	if c.HostConcurrency < 1 {
+		c.HostConcurrency = 2
+	}
+	if c.HostConcurrency > 20 {
+		c.HostConcurrency = 20
+	}
+
 	if c.MaxRetries < 0 {
 		c.MaxRetries = 0
 	}
@@ -94,6 +149,30 @@ func (c *Config) Validate() error {
 		c.MaxRetries = 10
 	}
 
+	if c.RetryDelay <= 0 {
+		c.RetryDelay = 1.0
+	}
+	if c.RetryDelay < 0.1 {
+		c.RetryDelay = 0.1
+	}
+	if c.RetryDelay > 60 {
+		c.RetryDelay = 60
+	}
+
+	if c.BreakerThreshold < 1 {
+		c.BreakerThreshold = 5
+	}
+	if c.BreakerThreshold > 100 {
+		c.BreakerThreshold = 100
+	}
+
+	if c.BreakerCooldown < 1 {
+		c.BreakerCooldown = 30
+	}
+	if c.BreakerCooldown > 3600 {
+		c.BreakerCooldown = 3600
+	}
+
 	if c.RequestTimeout < 1 {
 		c.RequestTimeout = 10
 	}
@@ -113,6 +192,31 @@ func (c *Config) Validate() error {
 		c.UserAgent = "GoWebScraper/1.0"
 	}
 
+	if c.MaxDepth < 0 {
+		c.MaxDepth = 0
+	}
+	if c.MaxDepth > 20 {
+		c.MaxDepth = 20
+	}
+
+	if c.MaxPages < 0 {
+		c.MaxPages = 0
+	}
+
+	switch c.Scope {
+	case "":
+		c.Scope = "host"
+	case "host", "domain", "any":
+	default:
+		return fmt.Errorf("invalid scope %q: must be host, domain or any", c.Scope)
+	}
+
+	for _, pattern := range append(c.IncludePatterns, c.ExcludePatterns...) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid URL pattern %q: %w", pattern, err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,160 @@
+// Package export writes stored pages and links in formats other tools read:
+// CSV and JSON Lines for analysis, sitemap.xml for search engines and WARC
+// for web archives. Rows are streamed from the database, so exports of any
+// size run in constant memory.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/web-scraper/models"
+	"github.com/user/web-scraper/storage"
+)
+
+// Formats supported by Pages and Links
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// pageColumns are the CSV columns of a page
+var pageColumns = []string{
+	"id", "url", "status_code", "title", "description", "link_count", "depth", "parent_url",
+	"error", "skip_reason", "retry_count", "canonical", "extract_site", "extracted", "metadata",
+	"content_hash", "version", "change_status", "changed_at", "scraped_at", "duration_ms",
+}
+
+// linkColumns are the CSV columns of a link
+var linkColumns = []string{"page_url", "url", "text"}
+
+// pageRecord is the JSON form of a page: the extracted fields and metadata,
+// stored as JSON strings, are embedded as objects
+type pageRecord struct {
+	*models.ScrapedPage
+	Extracted json.RawMessage `json:"extracted,omitempty"`
+	Metadata  json.RawMessage `json:"metadata,omitempty"`
+}
+
+// Pages writes the pages the filter selects as CSV or JSON Lines and returns
+// how many it wrote
+func Pages(db *storage.Database, w io.Writer, format string, filter *models.PageFilter) (int, error) {
+	count := 0
+
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(pageColumns); err != nil {
+			return 0, err
+		}
+		err := db.EachPage(filter, func(page *models.ScrapedPage) error {
+			count++
+			return writer.Write(safeCells(pageRow(page)))
+		})
+		writer.Flush()
+		if err != nil {
+			return count, err
+		}
+		return count, writer.Error()
+
+	case FormatJSONL:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		err := db.EachPage(filter, func(page *models.ScrapedPage) error {
+			count++
+			return encoder.Encode(pageRecord{
+				ScrapedPage: page,
+				Extracted:   json.RawMessage(page.Extracted),
+				Metadata:    json.RawMessage(page.Metadata),
+			})
+		})
+		return count, err
+
+	default:
+		return 0, fmt.Errorf("unsupported format %q: must be %s or %s", format, FormatCSV, FormatJSONL)
+	}
+}
+
+// Links writes the links found on the pages the filter selects as CSV or
+// JSON Lines and returns how many it wrote
+func Links(db *storage.Database, w io.Writer, format string, filter *models.PageFilter) (int, error) {
+	count := 0
+
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(linkColumns); err != nil {
+			return 0, err
+		}
+		err := db.EachLink(filter, func(link *models.PageLink) error {
+			count++
+			return writer.Write(safeCells([]string{link.PageURL, link.URL, link.Text}))
+		})
+		writer.Flush()
+		if err != nil {
+			return count, err
+		}
+		return count, writer.Error()
+
+	case FormatJSONL:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		err := db.EachLink(filter, func(link *models.PageLink) error {
+			count++
+			return encoder.Encode(link)
+		})
+		return count, err
+
+	default:
+		return 0, fmt.Errorf("unsupported format %q: must be %s or %s", format, FormatCSV, FormatJSONL)
+	}
+}
+
+// pageRow returns the CSV cells of a page, in the order of pageColumns
+func pageRow(page *models.ScrapedPage) []string {
+	changedAt := ""
+	if page.ChangedAt != nil {
+		changedAt = page.ChangedAt.Format(time.RFC3339)
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(page.ID), 10),
+		page.URL,
+		strconv.Itoa(page.StatusCode),
+		page.Title,
+		page.Description,
+		strconv.Itoa(page.LinkCount),
+		strconv.Itoa(page.Depth),
+		page.ParentURL,
+		page.Error,
+		page.SkipReason,
+		strconv.Itoa(page.RetryCount),
+		page.Canonical,
+		page.ExtractSite,
+		page.Extracted,
+		page.Metadata,
+		page.ContentHash,
+		strconv.Itoa(page.Version),
+		page.ChangeStatus,
+		changedAt,
+		page.ScrapedAt.Format(time.RFC3339),
+		strconv.FormatInt(page.Duration, 10),
+	}
+}
+
+// safeCells prefixes cells that spreadsheets would run as formulas with a
+// quote. Titles and link texts come from the scraped sites, so they are not
+// trusted.
+func safeCells(cells []string) []string {
+	for i, cell := range cells {
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			cells[i] = "'" + cell
+		}
+	}
+	return cells
+}
@@ -0,0 +1,264 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/web-scraper/models"
+	"github.com/user/web-scraper/scraper"
+	"github.com/user/web-scraper/storage"
+)
+
+// MaxSitemapURLs is the most URLs a sitemap may list, per sitemaps.org
+const MaxSitemapURLs = 50000
+
+// sitemapURL is a <url> entry of a sitemap
+type sitemapURL struct {
+	XMLName xml.Name `xml:"url"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// sitemapEntry is a <sitemap> entry of a sitemap index
+type sitemapEntry struct {
+	XMLName xml.Name `xml:"sitemap"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// Sitemap writes a sitemap.xml listing the successful pages the filter
+// selects and returns how many it listed, however many that is. Use
+// SitemapFiles to split big sitemaps.
+func Sitemap(db *storage.Database, w io.Writer, filter *models.PageFilter) (int, error) {
+	set, err := newURLSet(w)
+	if err != nil {
+		return 0, err
+	}
+	if err := sitemapURLs(db, filter, set.add); err != nil {
+		return set.count, err
+	}
+	return set.count, set.close()
+}
+
+// SitemapFiles writes the sitemap of the successful pages the filter selects
+// to path and returns how many URLs and files it wrote. Up to MaxSitemapURLs
+// URLs make one sitemap; more are split into numbered sitemaps next to path
+// (sitemap-1.xml, sitemap-2.xml, ...) and path becomes their sitemap index.
+// The index locates them under baseURL, or else at the root of the host of
+// the first URL.
+func SitemapFiles(db *storage.Database, path, baseURL string, filter *models.PageFilter) (int, int, error) {
+	dir := filepath.Dir(path)
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(filepath.Base(path), ext)
+
+	var parts []string
+	var part *sitemapFile
+	count := 0
+	err := sitemapURLs(db, filter, func(entry sitemapURL) error {
+		if part == nil || part.set.count == MaxSitemapURLs {
+			if part != nil {
+				if err := part.close(); err != nil {
+					return err
+				}
+			}
+			name := fmt.Sprintf("%s-%d%s", stem, len(parts)+1, ext)
+			var err error
+			if part, err = createSitemapFile(filepath.Join(dir, name)); err != nil {
+				return err
+			}
+			parts = append(parts, name)
+		}
+		if baseURL == "" {
+			baseURL = siteRoot(entry.Loc)
+		}
+		count++
+		return part.set.add(entry)
+	})
+	if part != nil {
+		if closeErr := part.close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return count, len(parts), err
+	}
+
+	// A single sitemap needs no index
+	switch len(parts) {
+	case 0:
+		part, err := createSitemapFile(path)
+		if err != nil {
+			return 0, 0, err
+		}
+		return 0, 1, part.close()
+	case 1:
+		return count, 1, os.Rename(filepath.Join(dir, parts[0]), path)
+	}
+
+	if err := writeSitemapIndex(path, baseURL, parts); err != nil {
+		return count, len(parts), err
+	}
+	return count, len(parts), nil
+}
+
+// sitemapURLs calls fn with the sitemap entry of every successful page the
+// filter selects. Pages are listed under the URL they were fetched from after
+// redirects, once, and left out when their canonical URL points elsewhere, as
+// search engines index the canonical page. The last modification is when the
+// content last changed, or else when it was scraped.
+func sitemapURLs(db *storage.Database, filter *models.PageFilter, fn func(sitemapURL) error) error {
+	success := models.PageFilter{Status: "success"}
+	if filter != nil {
+		success.Host, success.Since, success.Until = filter.Host, filter.Since, filter.Until
+	}
+
+	seen := make(map[string]bool)
+	return db.EachPage(&success, func(page *models.ScrapedPage) error {
+		loc, err := scraper.NormalizeURL(finalURL(page))
+		if err != nil || seen[loc] {
+			return nil
+		}
+		if page.Canonical != "" {
+			if canonical, err := scraper.NormalizeURL(page.Canonical); err == nil && canonical != loc {
+				return nil
+			}
+		}
+		seen[loc] = true
+
+		lastMod := page.ScrapedAt
+		if page.ChangedAt != nil {
+			lastMod = *page.ChangedAt
+		}
+		return fn(sitemapURL{Loc: loc, LastMod: lastMod.UTC().Format(time.RFC3339)})
+	})
+}
+
+// finalURL returns the URL a page was fetched from, after its redirects
+func finalURL(page *models.ScrapedPage) string {
+	if page.Redirects == "" {
+		return page.URL
+	}
+	var hops []models.Redirect
+	if err := json.Unmarshal([]byte(page.Redirects), &hops); err != nil || len(hops) == 0 {
+		return page.URL
+	}
+	return hops[len(hops)-1].URL
+}
+
+// siteRoot returns the root URL of the host of rawURL
+func siteRoot(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/"
+}
+
+// urlSet writes the <url> entries of one sitemap
+type urlSet struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	count   int
+}
+
+// newURLSet starts a sitemap on w
+func newURLSet(w io.Writer) (*urlSet, error) {
+	if _, err := io.WriteString(w, xml.Header+`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+"\n"); err != nil {
+		return nil, err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("  ", "  ")
+	return &urlSet{w: w, encoder: encoder}, nil
+}
+
+// add writes an entry
+func (s *urlSet) add(entry sitemapURL) error {
+	s.count++
+	return s.encoder.Encode(entry)
+}
+
+// close ends the sitemap
+func (s *urlSet) close() error {
+	closing := "</urlset>\n"
+	if s.count > 0 {
+		closing = "\n" + closing
+	}
+	_, err := io.WriteString(s.w, closing)
+	return err
+}
+
+// sitemapFile is a sitemap being written to a file
+type sitemapFile struct {
+	file     *os.File
+	buffered *bufio.Writer
+	set      *urlSet
+}
+
+// createSitemapFile creates a file and starts a sitemap in it
+func createSitemapFile(path string) (*sitemapFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	buffered := bufio.NewWriter(file)
+	set, err := newURLSet(buffered)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &sitemapFile{file: file, buffered: buffered, set: set}, nil
+}
+
+// close ends the sitemap and closes its file
+func (f *sitemapFile) close() error {
+	err := f.set.close()
+	if err == nil {
+		err = f.buffered.Flush()
+	}
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeSitemapIndex writes a sitemap index at path listing the sitemap files
+// parts, found under baseURL
+func writeSitemapIndex(path, baseURL string, parts []string) error {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+	if _, err := io.WriteString(buffered, xml.Header+`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+"\n"); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(buffered)
+	encoder.Indent("  ", "  ")
+	lastMod := time.Now().UTC().Format(time.RFC3339)
+	for _, part := range parts {
+		if err := encoder.Encode(sitemapEntry{Loc: baseURL + url.PathEscape(part), LastMod: lastMod}); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(buffered, "\n</sitemapindex>\n"); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
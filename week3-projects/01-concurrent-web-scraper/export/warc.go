@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/user/web-scraper/models"
+	"github.com/user/web-scraper/storage"
+)
+
+// WARC writes the archived responses of the pages the filter selects as a
+// WARC 1.1 file and returns how many it wrote. The file starts with a
+// warcinfo record naming the software; each response is a response record.
+// Compressed files gzip every record separately, as .warc.gz readers expect.
+func WARC(db *storage.Database, w io.Writer, filter *models.PageFilter, software string, compress bool) (int, error) {
+	archive := &warcWriter{w: w, compress: compress}
+
+	info := fmt.Sprintf("software: %s\r\nformat: WARC File Format 1.1\r\n", software)
+	infoID, err := archive.write(map[string]string{
+		"WARC-Type":    "warcinfo",
+		"WARC-Date":    warcDate(time.Now()),
+		"Content-Type": "application/warc-fields",
+	}, []byte(info))
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = db.EachResponse(filter, func(response *models.RawResponse) error {
+		block := make([]byte, 0, len(response.Header)+2+len(response.Body))
+		block = append(block, response.Header...)
+		block = append(block, "\r\n"...)
+		block = append(block, response.Body...)
+
+		// The record is of the URL that answered, after redirects; responses
+		// archived before final URLs were kept are stored under it
+		target := response.FinalURL
+		if target == "" {
+			target = response.URL
+		}
+
+		_, err := archive.write(map[string]string{
+			"WARC-Type":           "response",
+			"WARC-Date":           warcDate(response.FetchedAt),
+			"WARC-Target-URI":     target,
+			"WARC-Warcinfo-ID":    infoID,
+			"WARC-Payload-Digest": digest(response.Body),
+			"WARC-Block-Digest":   digest(block),
+			"Content-Type":        "application/http;msgtype=response",
+		}, block)
+		if err == nil {
+			count++
+		}
+		return err
+	})
+
+	return count, err
+}
+
+// warcHeaders is the order WARC header fields are written in
+var warcHeaders = []string{
+	"WARC-Type", "WARC-Record-ID", "WARC-Date", "WARC-Target-URI", "WARC-Warcinfo-ID",
+	"WARC-Payload-Digest", "WARC-Block-Digest", "Content-Type", "Content-Length",
+}
+
+// warcWriter writes WARC records, each gzipped on its own when compressing
+type warcWriter struct {
+	w        io.Writer
+	compress bool
+}
+
+// write writes a record with the given header fields and block, adding its
+// ID and length, and returns the ID
+func (ww *warcWriter) write(fields map[string]string, block []byte) (string, error) {
+	id, err := recordID()
+	if err != nil {
+		return "", err
+	}
+	fields["WARC-Record-ID"] = id
+	fields["Content-Length"] = strconv.Itoa(len(block))
+
+	var record bytes.Buffer
+	record.WriteString("WARC/1.1\r\n")
+	for _, name := range warcHeaders {
+		if value, ok := fields[name]; ok {
+			fmt.Fprintf(&record, "%s: %s\r\n", name, value)
+		}
+	}
+	record.WriteString("\r\n")
+	record.Write(block)
+	record.WriteString("\r\n\r\n")
+
+	if !ww.compress {
+		_, err := ww.w.Write(record.Bytes())
+		return id, err
+	}
+
+	gz := gzip.NewWriter(ww.w)
+	if _, err := gz.Write(record.Bytes()); err != nil {
+		return "", err
+	}
+	return id, gz.Close()
+}
+
+// recordID returns a new record ID: a random (version 4) UUID URN
+func recordID() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", fmt.Errorf("failed to generate record ID: %w", err)
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]), nil
+}
+
+// digest returns the SHA-1 digest of data in base 32, as WARC tools write it
+func digest(data []byte) string {
+	sum := sha1.Sum(data)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// warcDate formats a time as WARC dates are written: UTC, to the second
+func warcDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
@@ -0,0 +1,177 @@
+// Package extract pulls named fields out of HTML pages using declarative,
+// per-site rules: a CSS selector picks the elements, an attribute or their
+// text gives the value, and an optional regular expression trims it down.
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// Rules is a set of extraction rules for several sites
+type Rules struct {
+	Sites []*Site `json:"sites"`
+}
+
+// Site holds the fields to extract from pages whose URL matches Match
+type Site struct {
+	Name   string   `json:"name"`
+	Match  string   `json:"match"` // Regular expression on the page URL
+	Fields []*Field `json:"fields"`
+
+	match *regexp.Regexp
+}
+
+// Field describes how to extract one named value
+type Field struct {
+	Name     string `json:"name"`
+	Selector string `json:"selector"`           // CSS selector
+	Attr     string `json:"attr,omitempty"`     // Attribute to read; the element's text when empty
+	Regex    string `json:"regex,omitempty"`    // Keeps the first group, or the whole match, of the value
+	Multiple bool   `json:"multiple,omitempty"` // Extract every match as a list instead of the first
+	Required bool   `json:"required,omitempty"` // Report pages where the field is missing
+
+	selector cascadia.Selector
+	regex    *regexp.Regexp
+}
+
+// Result holds the values extracted from a page by one site's rules
+type Result struct {
+	Site    string                 `json:"site"`
+	Fields  map[string]interface{} `json:"fields"`            // A string, or a list of strings for multiple fields
+	Missing []string               `json:"missing,omitempty"` // Required fields not found
+}
+
+// LoadRules loads and compiles extraction rules from a JSON file
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid rules file: %w", err)
+	}
+
+	if err := rules.compile(); err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+// compile checks the rules and compiles their patterns and selectors
+func (r *Rules) compile() error {
+	for i, site := range r.Sites {
+		if site.Name == "" {
+			return fmt.Errorf("site %d: missing name", i+1)
+		}
+
+		var err error
+		if site.match, err = regexp.Compile(site.Match); err != nil {
+			return fmt.Errorf("site %s: invalid match pattern %q: %w", site.Name, site.Match, err)
+		}
+
+		names := make(map[string]bool)
+		for _, field := range site.Fields {
+			if field.Name == "" || field.Selector == "" {
+				return fmt.Errorf("site %s: every field needs a name and a selector", site.Name)
+			}
+			if names[field.Name] {
+				return fmt.Errorf("site %s: duplicate field %q", site.Name, field.Name)
+			}
+			names[field.Name] = true
+
+			if field.selector, err = cascadia.Compile(field.Selector); err != nil {
+				return fmt.Errorf("site %s, field %s: invalid selector %q: %w", site.Name, field.Name, field.Selector, err)
+			}
+			if field.Regex != "" {
+				if field.regex, err = regexp.Compile(field.Regex); err != nil {
+					return fmt.Errorf("site %s, field %s: invalid regex %q: %w", site.Name, field.Name, field.Regex, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// For returns the first site whose pattern matches pageURL, or nil
+func (r *Rules) For(pageURL string) *Site {
+	if r == nil {
+		return nil
+	}
+	for _, site := range r.Sites {
+		if site.match.MatchString(pageURL) {
+			return site
+		}
+	}
+	return nil
+}
+
+// Extract runs the site's rules against a parsed page
+func (s *Site) Extract(doc *goquery.Document) *Result {
+	result := &Result{
+		Site:   s.Name,
+		Fields: make(map[string]interface{}),
+	}
+
+	for _, field := range s.Fields {
+		values := field.extract(doc)
+		if len(values) == 0 {
+			if field.Required {
+				result.Missing = append(result.Missing, field.Name)
+			}
+			continue
+		}
+
+		if field.Multiple {
+			result.Fields[field.Name] = values
+		} else {
+			result.Fields[field.Name] = values[0]
+		}
+	}
+
+	return result
+}
+
+// extract returns the non-empty values of the elements a field selects,
+// only the first one unless the field is multiple
+func (f *Field) extract(doc *goquery.Document) []string {
+	var values []string
+
+	doc.FindMatcher(f.selector).EachWithBreak(func(i int, sel *goquery.Selection) bool {
+		var value string
+		if f.Attr != "" {
+			value, _ = sel.Attr(f.Attr)
+		} else {
+			value = sel.Text()
+		}
+		value = strings.Join(strings.Fields(value), " ")
+
+		if f.regex != nil {
+			match := f.regex.FindStringSubmatch(value)
+			switch {
+			case match == nil:
+				value = ""
+			case len(match) > 1:
+				value = match[1]
+			default:
+				value = match[0]
+			}
+		}
+
+		if value != "" {
+			values = append(values, value)
+		}
+		return f.Multiple || len(values) == 0
+	})
+
+	return values
+}
@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"sync"
+	"time"
+
+	"github.com/user/web-scraper/ratelimiter"
+	"github.com/user/web-scraper/robots"
+)
+
+// maxCrawlDelay caps the Crawl-delay a robots.txt can ask for, as the
+// scraper does
+const maxCrawlDelay = 30 * time.Second
+
+// hostRetryDelay is how long a checker waits for a host at its concurrency
+// cap before asking again
+const hostRetryDelay = 50 * time.Millisecond
+
+// Checker requests link targets that were not scraped, with the same
+// politeness as the scraper: robots.txt, per-host pacing and an overall rate
+type Checker struct {
+	client      *http.Client
+	userAgent   string
+	workers     int
+	rateLimiter *ratelimiter.RateLimiter
+	hosts       *ratelimiter.HostLimiter
+	robots      *robots.Cache
+}
+
+// NewChecker creates a link checker running workers requests at a time.
+// robots.txt is obeyed on every host except those in ignoreRobots.
+func NewChecker(timeout time.Duration, userAgent string, workers int, rateLimiter *ratelimiter.RateLimiter, hosts *ratelimiter.HostLimiter, ignoreRobots []string) *Checker {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &Checker{
+		client:      &http.Client{Timeout: timeout},
+		userAgent:   userAgent,
+		workers:     workers,
+		rateLimiter: rateLimiter,
+		hosts:       hosts,
+		robots:      robots.NewCache(&http.Client{Timeout: timeout}, userAgent, ignoreRobots),
+	}
+}
+
+// Check requests each URL and returns what it gave. URLs robots.txt forbids,
+// and those left when ctx is cancelled, are not in the result. Hosts whose
+// robots.txt cannot be fetched are still checked.
+func (c *Checker) Check(ctx context.Context, urls []string) map[string]Status {
+	results := make(map[string]Status, len(urls))
+	var mu sync.Mutex
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				status, ok := c.check(ctx, url)
+				if !ok {
+					continue
+				}
+				if class := status.Class(); class != "" {
+					log.Printf("✗ Broken link target: %s (%s)", url, status)
+				}
+				mu.Lock()
+				results[url] = status
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, url := range urls {
+		select {
+		case jobs <- url:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// check requests a URL with HEAD, or GET when the server does not support
+// HEAD. It returns false if the URL was not requested.
+func (c *Checker) check(ctx context.Context, rawURL string) (Status, bool) {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return Status{}, false
+	}
+
+	rules, err := c.robots.Rules(ctx, u)
+	if err != nil {
+		return Status{}, false
+	}
+	// A host whose robots.txt is unreachable gets one request anyway: the
+	// host itself may be down, which is what the check is for
+	if allowed, _ := rules.Allowed(u); !allowed && !rules.Unavailable() {
+		return Status{}, false
+	}
+	if delay := rules.CrawlDelay(); delay > 0 {
+		if delay > maxCrawlDelay {
+			delay = maxCrawlDelay
+		}
+		c.hosts.SetCrawlDelay(u.Host, delay)
+	}
+
+	if !c.acquire(ctx, u.Host) {
+		return Status{}, false
+	}
+	defer c.hosts.Release(u.Host)
+
+	status := c.request(ctx, http.MethodHead, rawURL)
+	if status.StatusCode == http.StatusMethodNotAllowed || status.StatusCode == http.StatusNotImplemented {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return Status{}, false
+		}
+		status = c.request(ctx, http.MethodGet, rawURL)
+	}
+	if ctx.Err() != nil {
+		return Status{}, false
+	}
+	return status, true
+}
+
+// acquire waits until the overall rate and the host's pace allow a request
+func (c *Checker) acquire(ctx context.Context, host string) bool {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return false
+	}
+	for {
+		wait, ok := c.hosts.TryAcquire(host)
+		if ok {
+			return true
+		}
+		if wait == 0 {
+			wait = hostRetryDelay
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// request sends one request and returns its status; redirects are followed
+func (c *Checker) request(ctx context.Context, method, url string) Status {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return Status{Error: err.Error()}
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		return Status{Error: err.Error(), Timeout: errors.As(err, &netErr) && netErr.Timeout()}
+	}
+	resp.Body.Close()
+
+	return Status{StatusCode: resp.StatusCode}
+}
@@ -0,0 +1,175 @@
+// Package graph analyses the link graph of the stored pages: which pages are
+// linked to most, which are linked to by none, which redirect, and which
+// links are broken.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/user/web-scraper/models"
+	"github.com/user/web-scraper/scraper"
+	"github.com/user/web-scraper/storage"
+)
+
+// PageRank parameters
+const (
+	damping       = 0.85
+	maxIterations = 100
+	tolerance     = 1e-9 // Total change in rank at which the iteration stops
+)
+
+// Graph is the link graph between the successfully scraped pages
+type Graph struct {
+	Pages []string // Page URLs; a page's index is its node
+	Out   [][]int  // Distinct pages each page links to, without itself
+
+	nodes   map[string]int    // Node of each page URL and of the URLs redirecting to it
+	targets map[string]Status // What fetching each stored page gave, failed ones included
+	chains  []Chain           // Pages that were redirected
+}
+
+// Build reads the stored pages and links into a graph. Link targets are
+// normalized like the frontier's URLs, and a link to a URL that redirected
+// points to the page it redirected to.
+func Build(db *storage.Database) (*Graph, error) {
+	g := &Graph{
+		nodes:   make(map[string]int),
+		targets: make(map[string]Status),
+	}
+
+	// Every page is a possible link target; successful ones are nodes
+	finals := make(map[string]string) // Final URL of each redirected page
+	err := db.EachPage(nil, func(page *models.ScrapedPage) error {
+		if page.SkipReason == "" {
+			timeout := page.ErrorClass == models.ErrorTimeout || (page.ErrorClass == "" && isTimeout(page.Error))
+			g.targets[page.URL] = Status{StatusCode: page.StatusCode, Error: page.Error, Timeout: timeout}
+		}
+
+		hops, err := decodeRedirects(page)
+		if err != nil {
+			return err
+		}
+		if len(hops) > 0 {
+			g.chains = append(g.chains, Chain{URL: page.URL, Hops: hops})
+		}
+
+		if page.StatusCode == 200 && page.Error == "" && page.SkipReason == "" {
+			g.nodes[page.URL] = len(g.Pages)
+			g.Pages = append(g.Pages, page.URL)
+			if len(hops) > 0 {
+				if final, err := scraper.NormalizeURL(hops[len(hops)-1].URL); err == nil {
+					finals[page.URL] = final
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pages: %w", err)
+	}
+
+	// The URL a page was redirected to leads to the page, unless it was
+	// scraped on its own
+	for page, final := range finals {
+		if _, ok := g.nodes[final]; !ok {
+			g.nodes[final] = g.nodes[page]
+		}
+	}
+
+	g.Out = make([][]int, len(g.Pages))
+	seen := make(map[[2]int]bool)
+	err = db.EachLink(nil, func(link *models.PageLink) error {
+		target, err := scraper.NormalizeURL(link.URL)
+		if err != nil {
+			return nil // Not an HTTP(S) link
+		}
+
+		from, ok := g.nodes[link.PageURL]
+		if !ok {
+			return nil
+		}
+		to, ok := g.nodes[target]
+		if !ok || to == from || seen[[2]int{from, to}] {
+			return nil
+		}
+		seen[[2]int{from, to}] = true
+		g.Out[from] = append(g.Out[from], to)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+
+	return g, nil
+}
+
+// InDegree returns how many pages link to each page
+func (g *Graph) InDegree() []int {
+	in := make([]int, len(g.Pages))
+	for _, targets := range g.Out {
+		for _, to := range targets {
+			in[to]++
+		}
+	}
+	return in
+}
+
+// PageRank returns the PageRank of each page; the ranks add up to 1. The
+// rank of pages without links is spread over all pages.
+func (g *Graph) PageRank() []float64 {
+	n := len(g.Pages)
+	if n == 0 {
+		return nil
+	}
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+
+	next := make([]float64, n)
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		dangling := 0.0
+		for i, targets := range g.Out {
+			if len(targets) == 0 {
+				dangling += rank[i]
+			}
+		}
+
+		base := (1-damping)/float64(n) + damping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, targets := range g.Out {
+			share := damping * rank[i] / float64(len(targets))
+			for _, to := range targets {
+				next[to] += share
+			}
+		}
+
+		change := 0.0
+		for i := range rank {
+			change += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if change < tolerance {
+			break
+		}
+	}
+
+	return rank
+}
+
+// decodeRedirects decodes the redirect chain stored with a page
+func decodeRedirects(page *models.ScrapedPage) ([]models.Redirect, error) {
+	if page.Redirects == "" {
+		return nil, nil
+	}
+	var hops []models.Redirect
+	if err := json.Unmarshal([]byte(page.Redirects), &hops); err != nil {
+		return nil, fmt.Errorf("invalid redirects of %s: %w", page.URL, err)
+	}
+	return hops, nil
+}
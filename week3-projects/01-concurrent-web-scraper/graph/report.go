@@ -0,0 +1,234 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/user/web-scraper/models"
+	"github.com/user/web-scraper/scraper"
+	"github.com/user/web-scraper/storage"
+)
+
+// Classes of broken links
+const (
+	ClassClientError = "4xx"
+	ClassServerError = "5xx"
+	ClassTimeout     = "timeout"
+	ClassError       = "error" // Connection refused, DNS failure, redirect loop...
+)
+
+// Status is what requesting a link target gave
+type Status struct {
+	StatusCode int
+	Error      string
+	Timeout    bool
+}
+
+// Class returns the class of a broken target, or "" if it works
+func (s Status) Class() string {
+	switch {
+	case s.Timeout:
+		return ClassTimeout
+	case s.StatusCode >= 500:
+		return ClassServerError
+	case s.StatusCode >= 400:
+		return ClassClientError
+	case s.StatusCode >= 300:
+		return "" // A redirect the scraper was told not to follow
+	case s.Error != "":
+		return ClassError
+	}
+	return ""
+}
+
+// String returns the status code and text of a status, or its error
+func (s Status) String() string {
+	if s.StatusCode != 0 {
+		return fmt.Sprintf("%d %s", s.StatusCode, http.StatusText(s.StatusCode))
+	}
+	return s.Error
+}
+
+// PageScore is how central a page is in the link graph
+type PageScore struct {
+	URL      string
+	InDegree int     // Pages linking to it
+	PageRank float64 // Share of the total rank, 0 to 1
+}
+
+// Chain is a page that was redirected, with the pages linking to its old URL
+type Chain struct {
+	URL        string
+	Hops       []models.Redirect
+	LinkedFrom int // Pages linking to the URL instead of its final one
+}
+
+// BrokenLink is a link to a target that failed
+type BrokenLink struct {
+	URL    string
+	Text   string
+	Status Status
+	Class  string
+}
+
+// BrokenPage is a page with the broken links it contains
+type BrokenPage struct {
+	URL   string
+	Links []BrokenLink
+}
+
+// Report is the analysis of the link graph
+type Report struct {
+	Pages     int            // Successfully scraped pages, the nodes of the graph
+	Links     int            // Distinct links between them
+	Ranked    []PageScore    // Highest PageRank first
+	Orphans   []string       // Pages no other page links to
+	Chains    []Chain        // Longest chain first
+	Broken    []BrokenPage   // Most broken links first
+	ByClass   map[string]int // Broken links by class
+	Checked   int            // Targets requested by the link checker
+	Unchecked int            // Targets neither scraped nor checked
+}
+
+// Analyze builds the link graph of the stored pages and reports on it. Link
+// targets that were not scraped, such as external links, are requested with
+// checker; without one they are counted as unchecked.
+func Analyze(ctx context.Context, db *storage.Database, checker *Checker) (*Report, error) {
+	g, err := Build(db)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Pages:   len(g.Pages),
+		ByClass: make(map[string]int),
+	}
+
+	// Centrality of each page
+	in := g.InDegree()
+	rank := g.PageRank()
+	for i, url := range g.Pages {
+		report.Links += len(g.Out[i])
+		report.Ranked = append(report.Ranked, PageScore{URL: url, InDegree: in[i], PageRank: rank[i]})
+		if in[i] == 0 {
+			report.Orphans = append(report.Orphans, url)
+		}
+	}
+	sort.SliceStable(report.Ranked, func(i, j int) bool {
+		return report.Ranked[i].PageRank > report.Ranked[j].PageRank
+	})
+
+	// Check the targets that were not scraped
+	statuses := g.targets
+	if checker != nil {
+		var unknown []string
+		seen := make(map[string]bool)
+		err := eachTarget(db, func(page, target, text string) {
+			if _, scraped := g.targets[target]; scraped || seen[target] {
+				return
+			}
+			if _, node := g.nodes[target]; node {
+				return
+			}
+			seen[target] = true
+			unknown = append(unknown, target)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		checked := checker.Check(ctx, unknown)
+		report.Checked = len(checked)
+		statuses = make(map[string]Status, len(g.targets)+len(checked))
+		for url, status := range g.targets {
+			statuses[url] = status
+		}
+		for url, status := range checked {
+			statuses[url] = status
+		}
+	}
+
+	// Broken links, by the page they are on, and links to redirected URLs
+	chains := make(map[string]*Chain, len(g.chains))
+	for i := range g.chains {
+		chains[g.chains[i].URL] = &g.chains[i]
+	}
+	broken := make(map[string]*BrokenPage)
+	var brokenOrder []string
+	reported := make(map[[2]string]bool)
+	linkedFrom := make(map[[2]string]bool)
+	unchecked := make(map[string]bool)
+
+	err = eachTarget(db, func(page, target, text string) {
+		if chain, ok := chains[target]; ok && !linkedFrom[[2]string{page, target}] {
+			linkedFrom[[2]string{page, target}] = true
+			chain.LinkedFrom++
+		}
+
+		status, known := statuses[target]
+		if !known {
+			if _, node := g.nodes[target]; !node {
+				unchecked[target] = true
+			}
+			return
+		}
+		class := status.Class()
+		if class == "" || reported[[2]string{page, target}] {
+			return
+		}
+		reported[[2]string{page, target}] = true
+
+		entry, ok := broken[page]
+		if !ok {
+			entry = &BrokenPage{URL: page}
+			broken[page] = entry
+			brokenOrder = append(brokenOrder, page)
+		}
+		entry.Links = append(entry.Links, BrokenLink{URL: target, Text: text, Status: status, Class: class})
+		report.ByClass[class]++
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Unchecked = len(unchecked)
+
+	for _, page := range brokenOrder {
+		report.Broken = append(report.Broken, *broken[page])
+	}
+	sort.SliceStable(report.Broken, func(i, j int) bool {
+		return len(report.Broken[i].Links) > len(report.Broken[j].Links)
+	})
+
+	report.Chains = g.chains
+	sort.SliceStable(report.Chains, func(i, j int) bool {
+		return len(report.Chains[i].Hops) > len(report.Chains[j].Hops)
+	})
+
+	return report, nil
+}
+
+// eachTarget calls fn with every link and its normalized target. Links that
+// are not HTTP(S), such as mailto: links, are left out.
+func eachTarget(db *storage.Database, fn func(page, target, text string)) error {
+	err := db.EachLink(nil, func(link *models.PageLink) error {
+		if target, err := scraper.NormalizeURL(link.URL); err == nil {
+			fn(link.PageURL, target, link.Text)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read links: %w", err)
+	}
+	return nil
+}
+
+// isTimeout reports whether a stored error is a timeout, for pages scraped
+// before errors were classified
+func isTimeout(err string) bool {
+	return strings.Contains(err, "Client.Timeout exceeded") ||
+		strings.Contains(err, "context deadline exceeded") ||
+		strings.Contains(err, "i/o timeout")
+}
@@ -1,17 +1,26 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/user/web-scraper/config"
+	"github.com/user/web-scraper/export"
+	"github.com/user/web-scraper/extract"
+	"github.com/user/web-scraper/graph"
+	"github.com/user/web-scraper/models"
 	"github.com/user/web-scraper/ratelimiter"
 	"github.com/user/web-scraper/scraper"
 	"github.com/user/web-scraper/storage"
@@ -21,25 +30,95 @@ var (
 	// Command line flags
 	workersFlag   = flag.Int("workers", 5, "Number of concurrent workers")
 	rateFlag      = flag.Float64("rate", 2.0, "Requests per second")
+	hostRateFlag  = flag.Float64("host-rate", 1.0, "Requests per second to each host")
+	hostConcFlag  = flag.Int("host-concurrency", 2, "Maximum requests in flight to each host")
 	retriesFlag   = flag.Int("retries", 3, "Maximum retry attempts")
+	retryDelay    = flag.Float64("retry-delay", 1.0, "Base delay before a retry in seconds, doubled on each attempt")
+	breakerFlag   = flag.Int("breaker-threshold", 5, "Consecutive failures that pause a host")
+	cooldownFlag  = flag.Int("breaker-cooldown", 30, "Seconds a failing host is first paused for")
 	timeoutFlag   = flag.Int("timeout", 30, "Request timeout in seconds")
 	dbPathFlag    = flag.String("db", "./data/scraper.db", "Database file path")
 	urlsFileFlag  = flag.String("urls", "./urls.json", "URLs file path")
 	configFlag    = flag.String("config", "", "Config file path (overrides other flags)")
 	statsFlag     = flag.Bool("stats", false, "Show database statistics and exit")
 	clearFlag     = flag.Bool("clear", false, "Clear all data from database")
+	resumeFlag    = flag.Bool("resume", false, "Continue the interrupted run saved in the database")
+	rulesFlag     = flag.String("rules", "", "Extraction rules file (JSON)")
+	testRuleFlag  = flag.String("test-rule", "", "Run the extraction rules against a saved HTML file and exit")
+	testURLFlag   = flag.String("test-url", "", "URL the -test-rule page was saved from, to pick the site's rules")
+	changesFlag   = flag.Bool("changes", false, "Show what changed in the last run and exit")
+	archiveFlag   = flag.Bool("archive", false, "Keep raw responses for WARC export")
+	exportFlag    = flag.String("export", "", "Export stored data and exit: csv, jsonl, sitemap or warc")
+	exportLinks   = flag.Bool("export-links", false, "Export links instead of pages (csv and jsonl)")
+	outputFlag    = flag.String("output", "", "Export file path (standard output if empty; .gz compresses WARC)")
+	sitemapBase   = flag.String("sitemap-url", "", "URL the sitemap files are published under, for the index of a split sitemap (the site root if empty)")
+	statusFilter  = flag.String("status", "", "Export only pages with this status: success, failed, skipped or a status code")
+	hostFilter    = flag.String("host", "", "Export only pages on this host")
+	sinceFilter   = flag.String("since", "", "Export only pages scraped on or after this date (YYYY-MM-DD or RFC 3339)")
+	untilFilter   = flag.String("until", "", "Export only pages scraped before this time, or up to the end of this date")
+	analyzeFlag   = flag.Bool("analyze", false, "Analyze the link graph, report broken links and exit")
+	checkLinks    = flag.Bool("check-links", false, "With -analyze, also request link targets that were not scraped, such as external links")
 	userAgentFlag = flag.String("user-agent", "GoWebScraper/1.0", "User agent string")
+	depthFlag     = flag.Int("depth", 0, "Maximum link depth to crawl from the seed URLs (0 scrapes only the seeds)")
+	maxPagesFlag  = flag.Int("max-pages", 0, "Maximum number of pages to scrape (0 for no limit)")
+	scopeFlag     = flag.String("scope", "host", "Links to follow: host (seed hosts), domain (seed domains) or any")
+	includeFlag   stringList
+	excludeFlag   stringList
+	ignoreRobots  stringList
 )
 
+// maxSchemaTypes is how many of the most common schema.org types the
+// statistics list
+const maxSchemaTypes = 10
+
+// maxListed is how many pages each section of the link analysis lists
+const maxListed = 20
+
+func init() {
+	flag.Var(&includeFlag, "include", "Only follow URLs matching this regular expression (repeatable)")
+	flag.Var(&excludeFlag, "exclude", "Never follow URLs matching this regular expression (repeatable)")
+	flag.Var(&ignoreRobots, "ignore-robots", "Do not obey robots.txt on this host, for sites you own (repeatable)")
+}
+
+// stringList is a flag that can be given several times
+type stringList []string
+
+func (p *stringList) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *stringList) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
 func main() {
 	flag.Parse()
 
-	// Print banner
-	printBanner()
+	// Print banner; exports may go to standard output
+	if *exportFlag == "" {
+		printBanner()
+	}
 
 	// Load or create configuration
 	cfg := loadConfiguration()
 
+	// Load extraction rules
+	var rules *extract.Rules
+	if cfg.RulesFile != "" {
+		var err error
+		rules, err = extract.LoadRules(cfg.RulesFile)
+		if err != nil {
+			log.Fatalf("Failed to load extraction rules from %s: %v", cfg.RulesFile, err)
+		}
+	}
+
+	// Handle test-rule flag
+	if *testRuleFlag != "" {
+		testRule(rules, *testRuleFlag, *testURLFlag)
+		return
+	}
+
 	// Initialize database
 	db, err := storage.NewDatabase(cfg.DatabasePath)
 	if err != nil {
@@ -53,6 +132,24 @@ func main() {
 		return
 	}
 
+	// Handle changes flag
+	if *changesFlag {
+		showChanges(db)
+		return
+	}
+
+	// Handle export flag
+	if *exportFlag != "" {
+		exportData(db, cfg)
+		return
+	}
+
+	// Handle analyze flag
+	if *analyzeFlag {
+		analyzeLinks(db, cfg)
+		return
+	}
+
 	// Handle clear flag
 	if *clearFlag {
 		if err := db.DeleteAllPages(); err != nil {
@@ -76,10 +173,34 @@ func main() {
 	fmt.Printf("⚙️  Configuration:\n")
 	fmt.Printf("   Workers: %d\n", cfg.WorkerCount)
 	fmt.Printf("   Rate Limit: %.1f req/s\n", cfg.RateLimit)
-	fmt.Printf("   Max Retries: %d\n", cfg.MaxRetries)
+	fmt.Printf("   Per Host: %.1f req/s, %d concurrent\n", cfg.HostRateLimit, cfg.HostConcurrency)
+	fmt.Printf("   Max Retries: %d (backoff from %.1fs)\n", cfg.MaxRetries, cfg.RetryDelay)
+	fmt.Printf("   Circuit Breaker: %d failures, %ds cooldown\n", cfg.BreakerThreshold, cfg.BreakerCooldown)
 	fmt.Printf("   Timeout: %ds\n", cfg.RequestTimeout)
+	if cfg.MaxDepth > 0 {
+		fmt.Printf("   Crawl: depth %d, scope %s", cfg.MaxDepth, cfg.Scope)
+		if cfg.MaxPages > 0 {
+			fmt.Printf(", max %d pages", cfg.MaxPages)
+		}
+		fmt.Println()
+	}
+	if len(cfg.IgnoreRobots) > 0 {
+		fmt.Printf("   Ignoring robots.txt on: %s\n", strings.Join(cfg.IgnoreRobots, ", "))
+	}
+	if rules != nil {
+		fmt.Printf("   Extraction Rules: %s (%d sites)\n", cfg.RulesFile, len(rules.Sites))
+	}
+	if cfg.ArchiveResponses {
+		fmt.Printf("   Archiving responses for WARC export\n")
+	}
 	fmt.Printf("   Database: %s\n\n", cfg.DatabasePath)
 
+	// Links are followed within the scope of the seed URLs
+	scope, err := scraper.NewScope(cfg.Scope, urls, cfg.IncludePatterns, cfg.ExcludePatterns)
+	if err != nil {
+		log.Fatalf("Invalid crawl scope: %v", err)
+	}
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -97,28 +218,72 @@ func main() {
 	rateLimiter := ratelimiter.NewRateLimiter(ctx, cfg.RateLimit)
 	defer rateLimiter.Stop()
 
-	// Create scraper
+	// Create per-host limiter; robots.txt Crawl-delays slow hosts down further
+	// and failing hosts are paused by the circuit breaker
+	hostLimiter := ratelimiter.NewHostLimiter(cfg.HostRateLimit, cfg.HostConcurrency,
+		cfg.BreakerThreshold, time.Duration(cfg.BreakerCooldown)*time.Second)
+
+	// Create scraper; when crawling, redirects stay within the scope too
+	var redirectScope *scraper.Scope
+	if cfg.MaxDepth > 0 {
+		redirectScope = scope
+	}
 	scraperInstance := scraper.NewScraper(
 		time.Duration(cfg.RequestTimeout)*time.Second,
 		cfg.UserAgent,
 		cfg.MaxRetries,
+		time.Duration(cfg.RetryDelay*float64(time.Second)),
 		cfg.FollowRedirects,
+		redirectScope,
+		cfg.IgnoreRobots,
+		hostLimiter,
+		rules,
+		cfg.ArchiveResponses,
 	)
 	defer scraperInstance.Close()
 
 	// Create worker pool
-	pool := scraper.NewWorkerPool(ctx, cfg.WorkerCount, scraperInstance, db, rateLimiter)
+	pool := scraper.NewWorkerPool(ctx, cfg.WorkerCount, scraperInstance, db, rateLimiter, hostLimiter, scraper.CrawlOptions{
+		MaxDepth: cfg.MaxDepth,
+		MaxPages: cfg.MaxPages,
+		Scope:    scope,
+	})
+
+	// Pick up the frontier of an interrupted run, or start afresh
+	if *resumeFlag {
+		left, err := pool.Resume()
+		if err != nil {
+			log.Fatalf("Failed to resume: %v", err)
+		}
+		fmt.Printf("↻ Resuming interrupted run: %d URLs left, %d known\n", left, pool.Visited())
+	} else if err := pool.Reset(); err != nil {
+		log.Fatalf("Failed to reset frontier: %v", err)
+	}
+
+	// Pages saved from now on belong to this run, for the change report
+	run, err := db.StartRun(*resumeFlag)
+	if err != nil {
+		log.Fatalf("Failed to start run: %v", err)
+	}
+	log.Printf("Run #%d", run.ID)
 
 	// Start worker pool
 	pool.Start()
 
-	// Add jobs
-	fmt.Println("🚀 Starting scraping...\n")
+	// Add jobs; a resumed run already has its seeds
+	fmt.Print("🚀 Starting scraping...\n\n")
 	startTime := time.Now()
-	pool.AddJobs(urls)
+	if pool.Visited() == 0 {
+		pool.AddJobs(urls)
+	}
 
 	// Wait for completion or cancellation
 	pool.Wait()
+	if ctx.Err() == nil {
+		if err := db.FinishRun(); err != nil {
+			log.Printf("Failed to finish run: %v", err)
+		}
+	}
 
 	// Print final statistics
 	fmt.Println("\n✓ Scraping completed!")
@@ -143,18 +308,33 @@ func loadConfiguration() *config.Config {
 		if err != nil {
 			log.Fatalf("Failed to load config from %s: %v", *configFlag, err)
 		}
-		fmt.Printf("✓ Loaded configuration from %s\n", *configFlag)
+		if *exportFlag == "" {
+			fmt.Printf("✓ Loaded configuration from %s\n", *configFlag)
+		}
 	} else {
 		// Use flags
 		cfg = &config.Config{
-			WorkerCount:     *workersFlag,
-			RateLimit:       *rateFlag,
-			MaxRetries:      *retriesFlag,
-			RequestTimeout:  *timeoutFlag,
-			DatabasePath:    *dbPathFlag,
-			URLsFile:        *urlsFileFlag,
-			UserAgent:       *userAgentFlag,
-			FollowRedirects: true,
+			WorkerCount:      *workersFlag,
+			RateLimit:        *rateFlag,
+			HostRateLimit:    *hostRateFlag,
+			HostConcurrency:  *hostConcFlag,
+			MaxRetries:       *retriesFlag,
+			RetryDelay:       *retryDelay,
+			BreakerThreshold: *breakerFlag,
+			BreakerCooldown:  *cooldownFlag,
+			RequestTimeout:   *timeoutFlag,
+			DatabasePath:     *dbPathFlag,
+			URLsFile:         *urlsFileFlag,
+			UserAgent:        *userAgentFlag,
+			FollowRedirects:  true,
+			MaxDepth:         *depthFlag,
+			MaxPages:         *maxPagesFlag,
+			Scope:            *scopeFlag,
+			IncludePatterns:  includeFlag,
+			ExcludePatterns:  excludeFlag,
+			IgnoreRobots:     ignoreRobots,
+			RulesFile:        *rulesFlag,
+			ArchiveResponses: *archiveFlag,
 		}
 	}
 
@@ -178,7 +358,16 @@ func showStatistics(db *storage.Database) {
 	fmt.Printf("Total Pages:      %d\n", stats.TotalPages)
 	fmt.Printf("Successful:       %d\n", stats.SuccessfulPages)
 	fmt.Printf("Failed:           %d\n", stats.FailedPages)
+	printErrorClasses(stats.ErrorClasses)
+	fmt.Printf("Skipped:          %d\n", stats.SkippedPages)
+	if stats.ExtractedPages > 0 {
+		fmt.Printf("Extracted:        %d\n", stats.ExtractedPages)
+	}
+	if stats.QueuedURLs > 0 {
+		fmt.Printf("Queued:           %d (continue with -resume)\n", stats.QueuedURLs)
+	}
 	fmt.Printf("Total Links:      %d\n", stats.TotalLinks)
+	printMetadataStatistics(stats.Metadata)
 	if stats.TotalPages > 0 {
 		fmt.Printf("Average Duration: %s\n", stats.AverageDuration)
 		fmt.Printf("Total Duration:   %s\n", stats.TotalDuration)
@@ -192,6 +381,362 @@ func showStatistics(db *storage.Database) {
 	fmt.Println(strings.Repeat("=", 60))
 }
 
+// showChanges displays what changed in the last run
+func showChanges(db *storage.Database) {
+	report, err := db.GetChangeReport()
+	if err != nil {
+		log.Printf("Failed to get changes: %v", err)
+		return
+	}
+
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("Changes in run #%d (started %s)\n", report.Run.ID, report.Run.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("New:              %d\n", len(report.New))
+	fmt.Printf("Changed:          %d\n", len(report.Changed))
+	fmt.Printf("Unchanged:        %d\n", report.Unchanged)
+	fmt.Printf("Failing:          %d\n", len(report.Failing))
+
+	if len(report.New) > 0 {
+		fmt.Println("\nNew pages:")
+		for _, version := range report.New {
+			fmt.Printf("  + %s (%s)\n", version.URL, truncateText(version.Title, 50))
+		}
+	}
+
+	if len(report.Changed) > 0 {
+		fmt.Println("\nChanged pages:")
+		for _, version := range report.Changed {
+			summary := strings.Split(version.DiffSummary, "\n")
+			fmt.Printf("  ~ %s (version %d): %s\n", version.URL, version.Version, summary[0])
+			for _, line := range summary[1:] {
+				fmt.Printf("      %s\n", line)
+			}
+		}
+	}
+
+	if len(report.Failing) > 0 {
+		fmt.Println("\nFailing pages (last good version kept):")
+		for _, page := range report.Failing {
+			fmt.Printf("  ✗ %s: %s\n", page.URL, page.Error)
+		}
+	}
+	fmt.Println(strings.Repeat("=", 60))
+}
+
+// analyzeLinks builds the link graph of the stored pages and prints the most
+// linked pages, orphans, redirect chains and broken links
+func analyzeLinks(db *storage.Database, cfg *config.Config) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Link targets that were not scraped are requested as politely as pages
+	var checker *graph.Checker
+	if *checkLinks {
+		rateLimiter := ratelimiter.NewRateLimiter(ctx, cfg.RateLimit)
+		defer rateLimiter.Stop()
+		hostLimiter := ratelimiter.NewHostLimiter(cfg.HostRateLimit, cfg.HostConcurrency,
+			cfg.BreakerThreshold, time.Duration(cfg.BreakerCooldown)*time.Second)
+		checker = graph.NewChecker(time.Duration(cfg.RequestTimeout)*time.Second, cfg.UserAgent, cfg.WorkerCount, rateLimiter, hostLimiter, cfg.IgnoreRobots)
+		fmt.Println("🔗 Checking link targets that were not scraped...")
+	}
+
+	report, err := graph.Analyze(ctx, db, checker)
+	if err != nil {
+		log.Fatalf("Failed to analyze links: %v", err)
+	}
+
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Println("Link Analysis")
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("Pages:            %d\n", report.Pages)
+	fmt.Printf("Internal Links:   %d\n", report.Links)
+	fmt.Printf("Orphans:          %d\n", len(report.Orphans))
+	fmt.Printf("Redirected:       %d\n", len(report.Chains))
+	brokenLinks := 0
+	for _, page := range report.Broken {
+		brokenLinks += len(page.Links)
+	}
+	fmt.Printf("Broken Links:     %d on %d pages", brokenLinks, len(report.Broken))
+	if brokenLinks > 0 {
+		fmt.Printf(" (4xx %d, 5xx %d, timeouts %d, errors %d)", report.ByClass[graph.ClassClientError],
+			report.ByClass[graph.ClassServerError], report.ByClass[graph.ClassTimeout], report.ByClass[graph.ClassError])
+	}
+	fmt.Println()
+	if checker != nil {
+		fmt.Printf("Checked:          %d link targets\n", report.Checked)
+	}
+	if report.Unchecked > 0 && checker == nil {
+		fmt.Printf("Unchecked:        %d link targets (check them with -check-links)\n", report.Unchecked)
+	} else if report.Unchecked > 0 {
+		fmt.Printf("Unchecked:        %d link targets (disallowed by robots.txt or interrupted)\n", report.Unchecked)
+	}
+
+	if len(report.Ranked) > 0 {
+		fmt.Println("\nMost linked pages (PageRank, pages linking):")
+		for _, page := range report.Ranked[:min(len(report.Ranked), maxListed)] {
+			fmt.Printf("  %.4f %4d  %s\n", page.PageRank, page.InDegree, page.URL)
+		}
+	}
+
+	if len(report.Orphans) > 0 {
+		fmt.Println("\nOrphan pages (no scraped page links to them):")
+		for _, url := range report.Orphans[:min(len(report.Orphans), maxListed)] {
+			fmt.Printf("  %s\n", url)
+		}
+		if len(report.Orphans) > maxListed {
+			fmt.Printf("  ... and %d more\n", len(report.Orphans)-maxListed)
+		}
+	}
+
+	if len(report.Chains) > 0 {
+		fmt.Println("\nRedirect chains:")
+		for _, chain := range report.Chains[:min(len(report.Chains), maxListed)] {
+			fmt.Printf("  %s\n", chain.URL)
+			for _, hop := range chain.Hops {
+				fmt.Printf("    → %d %s\n", hop.StatusCode, hop.URL)
+			}
+			if chain.LinkedFrom > 0 {
+				fmt.Printf("    %d pages link here: link to the final URL instead\n", chain.LinkedFrom)
+			}
+		}
+		if len(report.Chains) > maxListed {
+			fmt.Printf("  ... and %d more\n", len(report.Chains)-maxListed)
+		}
+	}
+
+	if len(report.Broken) > 0 {
+		fmt.Println("\nBroken links by page:")
+		for _, page := range report.Broken {
+			fmt.Printf("  %s\n", page.URL)
+			for _, link := range page.Links {
+				fmt.Printf("    ✗ %s (%s)", link.URL, link.Status)
+				if link.Text != "" {
+					fmt.Printf(" %q", truncateText(link.Text, 40))
+				}
+				fmt.Println()
+			}
+		}
+	}
+	fmt.Println(strings.Repeat("=", 60))
+}
+
+// exportData writes the stored pages, links or responses the filter flags
+// select to the output file, or standard output
+func exportData(db *storage.Database, cfg *config.Config) {
+	filter, err := parseFilter()
+	if err != nil {
+		log.Fatalf("Invalid export filter: %v", err)
+	}
+
+	// Sitemaps over the size limit are split into several files
+	if *exportFlag == "sitemap" && *outputFlag != "" {
+		count, files, err := export.SitemapFiles(db, *outputFlag, *sitemapBase, filter)
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		if files > 1 {
+			log.Printf("✓ Exported %d URLs to %d sitemaps, indexed in %s", count, files, *outputFlag)
+			return
+		}
+		log.Printf("✓ Exported %d URLs to %s", count, *outputFlag)
+		return
+	}
+
+	var out io.Writer = os.Stdout
+	if *outputFlag != "" {
+		file, err := os.Create(*outputFlag)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *outputFlag, err)
+		}
+		defer file.Close()
+		buffered := bufio.NewWriter(file)
+		defer buffered.Flush()
+		out = buffered
+	}
+
+	var count int
+	what := "pages"
+	switch *exportFlag {
+	case export.FormatCSV, export.FormatJSONL:
+		if *exportLinks {
+			what = "links"
+			count, err = export.Links(db, out, *exportFlag, filter)
+		} else {
+			count, err = export.Pages(db, out, *exportFlag, filter)
+		}
+	case "sitemap":
+		what = "URLs"
+		count, err = export.Sitemap(db, out, filter)
+		if count > export.MaxSitemapURLs {
+			log.Printf("⚠ The sitemap lists %d URLs; search engines read at most %d per file, so write it with -output to split it", count, export.MaxSitemapURLs)
+		}
+	case "warc":
+		what = "responses"
+		count, err = export.WARC(db, out, filter, cfg.UserAgent, strings.HasSuffix(*outputFlag, ".gz"))
+		if err == nil && count == 0 {
+			log.Printf("⚠ No archived responses: crawl with -archive to keep them")
+		}
+	default:
+		log.Fatalf("Unknown export format %q: must be csv, jsonl, sitemap or warc", *exportFlag)
+	}
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	destination := *outputFlag
+	if destination == "" {
+		destination = "standard output"
+	}
+	log.Printf("✓ Exported %d %s to %s", count, what, destination)
+}
+
+// parseFilter builds the export filter from the filter flags. Dates without
+// a time cover the whole day.
+func parseFilter() (*models.PageFilter, error) {
+	filter := &models.PageFilter{
+		Status: *statusFilter,
+		Host:   *hostFilter,
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if *sinceFilter != "" {
+		if filter.Since, _, err = parseDate(*sinceFilter); err != nil {
+			return nil, err
+		}
+	}
+	if *untilFilter != "" {
+		var dateOnly bool
+		if filter.Until, dateOnly, err = parseDate(*untilFilter); err != nil {
+			return nil, err
+		}
+		if dateOnly {
+			filter.Until = filter.Until.AddDate(0, 0, 1)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseDate parses a date (in local time) or an RFC 3339 time, and reports
+// whether it was a date. Times are returned in local time: SQLite compares
+// scraped_at as text, written with the local offset, so a filter time with
+// another offset would select the wrong pages.
+func parseDate(value string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", value)
+	}
+	return t.Local(), false, nil
+}
+
+// truncateText truncates a string to a maximum length
+func truncateText(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
+
+// printErrorClasses prints how many pages failed with each class of error,
+// most common first
+func printErrorClasses(classes map[string]int) {
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if classes[names[i]] != classes[names[j]] {
+			return classes[names[i]] > classes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		kind := "permanent"
+		if models.IsRetryable(name) {
+			kind = "retryable"
+		}
+		fmt.Printf("  %-15s %d (%s)\n", name+":", classes[name], kind)
+	}
+}
+
+// printMetadataStatistics prints how many pages declare each kind of metadata
+// and the most common schema.org types
+func printMetadataStatistics(meta models.MetadataStats) {
+	if meta.Canonical+meta.Hreflang+meta.OpenGraph+meta.Twitter+meta.JSONLD+meta.Microdata == 0 {
+		return
+	}
+
+	fmt.Printf("Metadata:         canonical %d, hreflang %d, OpenGraph %d, Twitter %d, JSON-LD %d, microdata %d\n",
+		meta.Canonical, meta.Hreflang, meta.OpenGraph, meta.Twitter, meta.JSONLD, meta.Microdata)
+
+	types := make([]string, 0, len(meta.SchemaTypes))
+	for t := range meta.SchemaTypes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if meta.SchemaTypes[types[i]] != meta.SchemaTypes[types[j]] {
+			return meta.SchemaTypes[types[i]] > meta.SchemaTypes[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	if len(types) > maxSchemaTypes {
+		types = types[:maxSchemaTypes]
+	}
+
+	if len(types) > 0 {
+		counted := make([]string, len(types))
+		for i, t := range types {
+			counted[i] = fmt.Sprintf("%s (%d)", t, meta.SchemaTypes[t])
+		}
+		fmt.Printf("Schema Types:     %s\n", strings.Join(counted, ", "))
+	}
+}
+
+// testRule runs the extraction rules against a saved HTML file and prints
+// the result. The site is picked by pageURL, or is the only one in the rules.
+func testRule(rules *extract.Rules, htmlFile, pageURL string) {
+	if rules == nil {
+		log.Fatal("-test-rule needs an extraction rules file (-rules)")
+	}
+
+	var site *extract.Site
+	switch {
+	case pageURL != "":
+		if site = rules.For(pageURL); site == nil {
+			log.Fatalf("No site in the rules matches %s", pageURL)
+		}
+	case len(rules.Sites) == 1:
+		site = rules.Sites[0]
+	default:
+		log.Fatal("The rules have several sites: pick one with -test-url")
+	}
+
+	file, err := os.Open(htmlFile)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", htmlFile, err)
+	}
+	defer file.Close()
+
+	doc, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", htmlFile, err)
+	}
+
+	output, err := json.MarshalIndent(site.Extract(doc), "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode result: %v", err)
+	}
+	fmt.Println(string(output))
+}
+
 // printBanner prints the application banner
 func printBanner() {
 	banner := `
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,19 +11,167 @@ import (
 
 // ScrapedPage represents a scraped web page stored in the database
 type ScrapedPage struct {
-	ID          uint           `gorm:"primarykey" json:"id"`
-	URL         string         `gorm:"uniqueIndex;not null" json:"url"`
-	Title       string         `gorm:"type:text" json:"title"`
-	Description string         `gorm:"type:text" json:"description"`
-	LinkCount   int            `json:"link_count"`
-	StatusCode  int            `json:"status_code"`
-	Error       string         `gorm:"type:text" json:"error,omitempty"`
-	RetryCount  int            `json:"retry_count"`
-	ScrapedAt   time.Time      `json:"scraped_at"`
-	Duration    int64          `json:"duration_ms"` // Duration in milliseconds
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint           `gorm:"primarykey" json:"id"`
+	URL          string         `gorm:"uniqueIndex;not null" json:"url"`
+	Title        string         `gorm:"type:text" json:"title"`
+	Description  string         `gorm:"type:text" json:"description"`
+	LinkCount    int            `json:"link_count"`
+	StatusCode   int            `json:"status_code"`
+	Error        string         `gorm:"type:text" json:"error,omitempty"`
+	ErrorClass   string         `gorm:"type:text;not null;default:''" json:"error_class,omitempty"` // Kind of error, which decides whether it is retried
+	SkipReason   string         `gorm:"type:text;not null;default:''" json:"skip_reason,omitempty"` // Why the page was not fetched, such as robots.txt
+	RetryCount   int            `json:"retry_count"`
+	Depth        int            `json:"depth"`                                                    // Links followed from a seed URL
+	ParentURL    string         `gorm:"type:text" json:"parent_url,omitempty"`                    // Page the URL was found on
+	Redirects    string         `gorm:"type:text;not null;default:''" json:"redirects,omitempty"` // Redirect hops as JSON, empty if not redirected
+	ExtractSite  string         `gorm:"type:text" json:"extract_site,omitempty"`                  // Extraction rules applied to the page
+	Extracted    string         `gorm:"type:text" json:"extracted,omitempty"`                     // Extracted fields as a JSON object
+	Canonical    string         `gorm:"type:text" json:"canonical,omitempty"`                     // Canonical URL the page declares
+	Metadata     string         `gorm:"type:text;not null;default:''" json:"metadata,omitempty"`  // PageMetadata as JSON
+	ETag         string         `gorm:"column:etag;type:text" json:"etag,omitempty"`              // Cache validators sent back on re-crawls
+	LastModified string         `gorm:"type:text" json:"last_modified,omitempty"`
+	ContentHash  string         `gorm:"type:text" json:"content_hash,omitempty"`  // SHA-256 of the last body
+	Version      int            `json:"version"`                                  // Content versions seen so far
+	ChangeStatus string         `gorm:"type:text" json:"change_status,omitempty"` // How the content changed in the last run that fetched it
+	ChangedAt    *time.Time     `json:"changed_at,omitempty"`
+	RunID        uint           `gorm:"index" json:"run_id"` // Last run that checked the page
+	ScrapedAt    time.Time      `json:"scraped_at"`
+	Duration     int64          `json:"duration_ms"` // Duration in milliseconds
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// PageMetadata is the structured metadata a page declares about itself
+type PageMetadata struct {
+	Canonical string            `json:"canonical,omitempty"`
+	Hreflang  map[string]string `json:"hreflang,omitempty"`   // Alternate URL by language
+	OpenGraph map[string]string `json:"open_graph,omitempty"` // og: properties, first value of each
+	Twitter   map[string]string `json:"twitter,omitempty"`    // twitter: card tags, first value of each
+	JSONLD    []json.RawMessage `json:"json_ld,omitempty"`    // Valid JSON-LD blocks as found
+	Microdata []*MicrodataItem  `json:"microdata,omitempty"`  // Top-level items
+	Types     []string          `json:"types,omitempty"`      // schema.org types declared by JSON-LD and microdata
+}
+
+// IsEmpty reports whether the page declares no metadata at all
+func (m *PageMetadata) IsEmpty() bool {
+	return m.Canonical == "" && len(m.Hreflang) == 0 && len(m.OpenGraph) == 0 &&
+		len(m.Twitter) == 0 && len(m.JSONLD) == 0 && len(m.Microdata) == 0
+}
+
+// MicrodataItem is an itemscope element with its properties. Property values
+// are strings, or nested items.
+type MicrodataItem struct {
+	Type       string                   `json:"type,omitempty"`
+	Properties map[string][]interface{} `json:"properties"`
+}
+
+// Error classes of failed pages. Retryable errors are transient: the same
+// request may succeed later. Permanent errors are not retried.
+const (
+	ErrorTimeout     = "timeout"      // Retryable: the request or a 408 timed out
+	ErrorConnection  = "connection"   // Retryable: refused, reset or closed early
+	ErrorRateLimited = "rate_limited" // Retryable: 429 Too Many Requests
+	ErrorServer      = "server"       // Retryable: 5xx
+	ErrorDNS         = "dns"          // Permanent: the host does not exist
+	ErrorTLS         = "tls"          // Permanent: certificate or handshake failure
+	ErrorRedirect    = "redirect"     // Permanent: too many redirects, or one not followed
+	ErrorClient      = "client"       // Permanent: 4xx other than 408 and 429
+	ErrorInvalid     = "invalid"      // Permanent: the URL or the response cannot be handled
+	ErrorCanceled    = "canceled"     // Not a result: the scraper stopped mid-request
+)
+
+// IsRetryable reports whether an error class is worth retrying
+func IsRetryable(class string) bool {
+	switch class {
+	case ErrorTimeout, ErrorConnection, ErrorRateLimited, ErrorServer:
+		return true
+	}
+	return false
+}
+
+// Change statuses of a page in a run
+const (
+	ChangeNew       = "new"       // First version of the page
+	ChangeChanged   = "changed"   // Content differs from the last version
+	ChangeUnchanged = "unchanged" // Not modified (304) or same content hash
+)
+
+// PageVersion is one version of a page's content, kept each time it changes
+type PageVersion struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	URL         string    `gorm:"index:idx_page_version,unique;not null" json:"url"`
+	Version     int       `gorm:"index:idx_page_version,unique" json:"version"`
+	RunID       uint      `gorm:"index" json:"run_id"`
+	ContentHash string    `gorm:"type:text" json:"content_hash"`
+	Title       string    `gorm:"type:text" json:"title"`
+	Text        string    `gorm:"type:text" json:"-"`                      // Visible text the next version is compared with
+	DiffSummary string    `gorm:"type:text" json:"diff_summary,omitempty"` // What changed from the previous version
+	ScrapedAt   time.Time `json:"scraped_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CrawlRun is one run of the scraper; a resumed run continues the same one
+type CrawlRun struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ChangeReport lists what changed in a run compared with the previous ones
+type ChangeReport struct {
+	Run       CrawlRun
+	New       []PageVersion // First versions of pages
+	Changed   []PageVersion // New versions of known pages
+	Unchanged int
+	Failing   []ScrapedPage // Known pages that could not be fetched this run
+}
+
+// Validators are the cache validators of a stored page, sent back as
+// If-None-Match and If-Modified-Since
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// RawResponse is the raw HTTP response of a page, kept when archiving for
+// WARC export. Only the latest response of each URL is kept, under the URL
+// that was requested, like its page.
+type RawResponse struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	URL        string    `gorm:"uniqueIndex;not null" json:"url"`
+	FinalURL   string    `gorm:"not null;default:''" json:"final_url"` // URL the response came from after redirects
+	StatusCode int       `json:"status_code"`
+	Header     string    `gorm:"type:text" json:"header"` // Status line and headers as sent on the wire
+	Body       []byte    `json:"-"`                       // Decoded body, up to the size limit
+	FetchedAt  time.Time `json:"fetched_at"`
+}
+
+// PageFilter selects the pages an export covers; zero fields match all
+type PageFilter struct {
+	Status string    // success, failed, skipped or a status code
+	Host   string    // Host of the page URL, with the port if it has one
+	Since  time.Time // Scraped at or after
+	Until  time.Time // Scraped before
+}
+
+// Validate checks the status of a filter
+func (f *PageFilter) Validate() error {
+	switch f.Status {
+	case "", "success", "failed", "skipped":
+		return nil
+	}
+	if _, err := strconv.Atoi(f.Status); err != nil {
+		return fmt.Errorf("invalid status %q: must be success, failed, skipped or a status code", f.Status)
+	}
+	return nil
+}
+
+// PageLink is a link with the URL of the page it was found on
+type PageLink struct {
+	PageURL string `json:"page_url"`
+	URL     string `json:"url"`
+	Text    string `json:"text"`
 }
 
 // Link represents a link found on a scraped page
@@ -32,10 +183,38 @@ type Link struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Frontier states of a URL
+const (
+	FrontierQueued   = "queued"    // Waiting to be scraped
+	FrontierInFlight = "in_flight" // Leased to a worker
+	FrontierDone     = "done"      // Scraped or skipped
+	FrontierFailed   = "failed"    // Out of retries
+)
+
+// FrontierURL is a URL in the crawl frontier. The frontier is persisted so an
+// interrupted run can resume where it stopped.
+type FrontierURL struct {
+	ID           uint       `gorm:"primarykey" json:"id"`
+	URL          string     `gorm:"uniqueIndex;not null" json:"url"`
+	State        string     `gorm:"index;not null" json:"state"`
+	Priority     int        `json:"priority"` // Higher is scraped first
+	RetryCount   int        `json:"retry_count"`
+	NotBefore    *time.Time `json:"not_before,omitempty"` // Retries wait for their backoff
+	Depth        int        `json:"depth"`
+	ParentURL    string     `gorm:"type:text" json:"parent_url,omitempty"`
+	LeaseExpires *time.Time `json:"lease_expires,omitempty"` // Set while in flight
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
 // ScrapeJob represents a job to be processed by workers
 type ScrapeJob struct {
 	URL        string
 	RetryCount int
+	Depth      int       // 0 for seed URLs
+	ParentURL  string    // Page the URL was found on, empty for seeds
+	Priority   int       // Higher is scraped first
+	NotBefore  time.Time // A retry is not scraped before its backoff is over
 }
 
 // ScrapeResult represents the result of a scraping operation
@@ -46,9 +225,41 @@ type ScrapeResult struct {
 	Links       []LinkData
 	StatusCode  int
 	Error       error
+	ErrorClass  string        // Kind of Error, which decides whether it is retried
+	RetryAfter  time.Duration // How long a 429 or 503 response asks clients to wait
+	SkipReason  string        // Set when the URL was deliberately not fetched
 	Duration    time.Duration
-	RetryCount  int
-	ScrapedAt   time.Time
+	Redirects   []Redirect // Hops from the URL to the page fetched, if redirected
+
+	// Metadata the page declares, nil for non-HTML pages
+	Metadata *PageMetadata
+
+	// Change detection
+	ETag         string
+	LastModified string
+	ContentHash  string // SHA-256 of the body
+	Text         string // Visible text of HTML pages
+	NotModified  bool   // The server answered 304 to a conditional request
+
+	// Raw response for the WARC archive, nil unless archiving
+	Response *RawResponse
+
+	// Structured data extracted by the rules of the page's site
+	ExtractSite   string
+	Extracted     map[string]interface{}
+	MissingFields []string // Required fields not found
+
+	RetryCount int
+	Depth      int
+	ParentURL  string
+	ScrapedAt  time.Time
+}
+
+// Redirect is one hop of a redirect chain: the URL redirected to and the
+// status code of the redirect
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
 }
 
 // LinkData represents a link found during scraping
@@ -57,14 +268,30 @@ type LinkData struct {
 	Text string
 }
 
+// MetadataStats counts the pages declaring each kind of metadata
+type MetadataStats struct {
+	Canonical   int            `json:"canonical"`
+	Hreflang    int            `json:"hreflang"`
+	OpenGraph   int            `json:"open_graph"`
+	Twitter     int            `json:"twitter"`
+	JSONLD      int            `json:"json_ld"`
+	Microdata   int            `json:"microdata"`
+	SchemaTypes map[string]int `json:"schema_types"` // Pages declaring each schema.org type
+}
+
 // Statistics holds scraping statistics
 type Statistics struct {
-	TotalPages      int           `json:"total_pages"`
-	SuccessfulPages int           `json:"successful_pages"`
-	FailedPages     int           `json:"failed_pages"`
-	TotalLinks      int           `json:"total_links"`
-	TotalDuration   time.Duration `json:"total_duration"`
-	AverageDuration time.Duration `json:"average_duration"`
-	StartTime       time.Time     `json:"start_time"`
-	EndTime         time.Time     `json:"end_time"`
+	TotalPages      int            `json:"total_pages"`
+	SuccessfulPages int            `json:"successful_pages"`
+	FailedPages     int            `json:"failed_pages"`
+	SkippedPages    int            `json:"skipped_pages"`
+	QueuedURLs      int            `json:"queued_urls"`     // Left in the frontier by an interrupted run
+	ErrorClasses    map[string]int `json:"error_classes"`   // Failed pages by error class
+	ExtractedPages  int            `json:"extracted_pages"` // Pages with structured data
+	Metadata        MetadataStats  `json:"metadata"`
+	TotalLinks      int            `json:"total_links"`
+	TotalDuration   time.Duration  `json:"total_duration"`
+	AverageDuration time.Duration  `json:"average_duration"`
+	StartTime       time.Time      `json:"start_time"`
+	EndTime         time.Time      `json:"end_time"`
 }
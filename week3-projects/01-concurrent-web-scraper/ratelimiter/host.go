@@ -0,0 +1,235 @@
+package ratelimiter
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCooldown caps how long the circuit breaker pauses a host that keeps
+// failing
+const maxCooldown = 10 * time.Minute
+
+// HostLimiter paces requests per host: each host has its own token bucket and
+// a cap on requests in flight, so a crawl never hammers a single site and one
+// slow site does not hold up the others. It never blocks; a scheduler asks it
+// which host may go next.
+//
+// It is also a circuit breaker: after threshold consecutive failures a host is
+// paused for the cooldown, then probed with one request at a time. A success
+// closes the circuit; a failure pauses the host again, twice as long.
+type HostLimiter struct {
+	rate          float64
+	maxConcurrent int
+	threshold     int           // Consecutive failures that open the circuit
+	cooldown      time.Duration // First pause of an open circuit
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// hostState is the token bucket and in-flight count of one host
+type hostState struct {
+	tokens   float64
+	burst    float64
+	interval time.Duration // Time to earn one token
+	last     time.Time     // Last refill
+	inFlight int
+
+	pausedUntil time.Time // No request before, for Retry-After or an open circuit
+	failures    int       // Consecutive failures
+	trips       int       // Times the circuit opened since the last success
+	probing     bool      // The circuit was open: one request at a time
+}
+
+// NewHostLimiter creates a limiter allowing rate requests per second and at
+// most maxConcurrent requests in flight to each host. A host failing
+// threshold times in a row is paused for cooldown.
+func NewHostLimiter(rate float64, maxConcurrent int, threshold int, cooldown time.Duration) *HostLimiter {
+	if rate <= 0 {
+		rate = 1.0
+	}
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if threshold < 1 {
+		threshold = 1
+	}
+	if cooldown <= 0 {
+		cooldown = time.Second
+	}
+
+	return &HostLimiter{
+		rate:          rate,
+		maxConcurrent: maxConcurrent,
+		threshold:     threshold,
+		cooldown:      cooldown,
+		hosts:         make(map[string]*hostState),
+	}
+}
+
+// TryAcquire takes a token and an in-flight slot for host if both are free.
+// Otherwise it returns how long until the next token; a zero wait with ok
+// false means the host is at its concurrency cap until a Release.
+func (hl *HostLimiter) TryAcquire(host string) (wait time.Duration, ok bool) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	h := hl.state(host)
+	now := time.Now()
+	if now.Before(h.pausedUntil) {
+		return h.pausedUntil.Sub(now), false
+	}
+	if h.inFlight >= hl.maxConcurrent || (h.probing && h.inFlight > 0) {
+		return 0, false
+	}
+
+	h.refill(now)
+	if h.tokens < 1 {
+		return time.Duration((1 - h.tokens) * float64(h.interval)), false
+	}
+
+	h.tokens--
+	h.inFlight++
+	if h.trips > 0 {
+		h.probing = true // The cooldown is over: this request tests the host
+	}
+	return 0, true
+}
+
+// Release frees the in-flight slot taken by a successful TryAcquire
+func (hl *HostLimiter) Release(host string) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	if h := hl.state(host); h.inFlight > 0 {
+		h.inFlight--
+	}
+}
+
+// SetCrawlDelay slows a host down to one request per delay, as its
+// robots.txt asks. Delays shorter than the host's current pace are ignored.
+func (hl *HostLimiter) SetCrawlDelay(host string, delay time.Duration) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	h := hl.state(host)
+	if delay <= h.interval {
+		return
+	}
+
+	// Start the slower pace now, without a burst
+	h.refill(time.Now())
+	h.interval = delay
+	h.burst = 1
+	if h.tokens > 0 {
+		h.tokens = 0
+	}
+}
+
+// Pause stops requests to host for a while, as a Retry-After header asks.
+// A shorter pause than the current one is ignored.
+func (hl *HostLimiter) Pause(host string, d time.Duration) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	h := hl.state(host)
+	if until := time.Now().Add(d); until.After(h.pausedUntil) {
+		h.pausedUntil = until
+	}
+}
+
+// PausedFor returns how much longer host is paused, by a Retry-After header
+// or its open circuit, or 0
+func (hl *HostLimiter) PausedFor(host string) time.Duration {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	if d := time.Until(hl.state(host).pausedUntil); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Failure records a failed request to host. It returns how long the host is
+// paused for if this failure opened the circuit, or 0.
+func (hl *HostLimiter) Failure(host string) time.Duration {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	h := hl.state(host)
+	now := time.Now()
+
+	// Requests sent before the circuit opened may still fail
+	if h.trips > 0 && !h.probing {
+		return 0
+	}
+
+	h.failures++
+	if !h.probing && h.failures < hl.threshold {
+		return 0
+	}
+
+	// Open the circuit, for twice as long as the last time
+	pause := hl.cooldown << h.trips
+	if pause > maxCooldown || pause <= 0 {
+		pause = maxCooldown
+	}
+	h.trips++
+	h.probing = false
+	if until := now.Add(pause); until.After(h.pausedUntil) {
+		h.pausedUntil = until
+	}
+	return pause
+}
+
+// Success records a request to host that got an answer, closing its circuit
+func (hl *HostLimiter) Success(host string) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	h := hl.state(host)
+	h.failures = 0
+	h.trips = 0
+	h.probing = false
+}
+
+// GetRate returns the per-host rate limit (requests per second)
+func (hl *HostLimiter) GetRate() float64 {
+	return hl.rate
+}
+
+// GetMaxConcurrent returns the per-host cap on requests in flight
+func (hl *HostLimiter) GetMaxConcurrent() int {
+	return hl.maxConcurrent
+}
+
+// state returns the state of a host, creating it with a full bucket; the
+// caller holds the lock
+func (hl *HostLimiter) state(host string) *hostState {
+	host = strings.ToLower(host)
+	h, ok := hl.hosts[host]
+	if !ok {
+		burst := float64(int(hl.rate))
+		if burst < 1 {
+			burst = 1
+		}
+		h = &hostState{
+			tokens:   burst,
+			burst:    burst,
+			interval: time.Duration(float64(time.Second) / hl.rate),
+			last:     time.Now(),
+		}
+		hl.hosts[host] = h
+	}
+	return h
+}
+
+// refill adds the tokens earned since the last refill
+func (h *hostState) refill(now time.Time) {
+	h.tokens += float64(now.Sub(h.last)) / float64(h.interval)
+	if h.tokens > h.burst {
+		h.tokens = h.burst
+	}
+	h.last = now
+}
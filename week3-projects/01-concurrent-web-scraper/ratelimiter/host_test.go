@@ -0,0 +1,147 @@
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHostLimiterBreaker(t *testing.T) {
+	const host = "example.com"
+
+	// A step records a failure (and the pause it should return), a success,
+	// a request refused while the host is paused, or the end of the pause
+	// followed by a probe: one request, with a second refused meanwhile
+	type step struct {
+		event string
+		pause time.Duration
+	}
+	fail := func(pause time.Duration) step { return step{"fail", pause} }
+	success := step{event: "success"}
+	blocked := step{event: "blocked"}
+	probe := step{event: "probe"}
+
+	tests := []struct {
+		name       string
+		cooldown   time.Duration
+		steps      []step
+		wantPaused bool
+	}{
+		{
+			name:       "failures below the threshold keep the circuit closed",
+			cooldown:   time.Second,
+			steps:      []step{fail(0), fail(0)},
+			wantPaused: false,
+		},
+		{
+			name:       "the threshold opens the circuit",
+			cooldown:   time.Second,
+			steps:      []step{fail(0), fail(0), fail(time.Second), blocked},
+			wantPaused: true,
+		},
+		{
+			name:       "a success resets the count",
+			cooldown:   time.Second,
+			steps:      []step{fail(0), fail(0), success, fail(0), fail(0)},
+			wantPaused: false,
+		},
+		{
+			name:       "failures of requests sent before the circuit opened are not counted",
+			cooldown:   time.Second,
+			steps:      []step{fail(0), fail(0), fail(time.Second), fail(0), fail(0)},
+			wantPaused: true,
+		},
+		{
+			name:       "a failed probe reopens the circuit for twice as long",
+			cooldown:   time.Second,
+			steps:      []step{fail(0), fail(0), fail(time.Second), probe, fail(2 * time.Second), blocked, probe, fail(4 * time.Second)},
+			wantPaused: true,
+		},
+		{
+			name:       "a successful probe closes the circuit",
+			cooldown:   time.Second,
+			steps:      []step{fail(0), fail(0), fail(time.Second), probe, success, fail(0), fail(0), fail(time.Second)},
+			wantPaused: true,
+		},
+		{
+			name:       "pauses are capped",
+			cooldown:   6 * time.Minute,
+			steps:      []step{fail(0), fail(0), fail(6 * time.Minute), probe, fail(maxCooldown), probe, fail(maxCooldown)},
+			wantPaused: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hl := NewHostLimiter(1000, 4, 3, tt.cooldown)
+
+			for i, s := range tt.steps {
+				switch s.event {
+				case "fail":
+					if pause := hl.Failure(host); pause != s.pause {
+						t.Fatalf("step %d: Failure() = %s, want %s", i, pause, s.pause)
+					}
+				case "success":
+					hl.Success(host)
+				case "blocked":
+					if wait, ok := hl.TryAcquire(host); ok || wait <= 0 {
+						t.Fatalf("step %d: TryAcquire() = %s, %v on a paused host", i, wait, ok)
+					}
+				case "probe":
+					endPause(hl, host)
+					if _, ok := hl.TryAcquire(host); !ok {
+						t.Fatalf("step %d: probe refused after the pause", i)
+					}
+					if wait, ok := hl.TryAcquire(host); ok || wait != 0 {
+						t.Fatalf("step %d: second request while probing = %s, %v, want refused", i, wait, ok)
+					}
+					hl.Release(host)
+				}
+			}
+
+			if paused := hl.PausedFor(host) > 0; paused != tt.wantPaused {
+				t.Errorf("paused = %v, want %v", paused, tt.wantPaused)
+			}
+		})
+	}
+}
+
+func TestHostLimiterConcurrency(t *testing.T) {
+	hl := NewHostLimiter(1000, 2, 3, time.Second)
+
+	for i := 0; i < 2; i++ {
+		if _, ok := hl.TryAcquire("Example.com"); !ok {
+			t.Fatalf("request %d refused below the concurrency cap", i+1)
+		}
+	}
+	if wait, ok := hl.TryAcquire("example.com"); ok || wait != 0 {
+		t.Fatalf("TryAcquire() at the cap = %s, %v, want refused with no wait", wait, ok)
+	}
+	if _, ok := hl.TryAcquire("other.com"); !ok {
+		t.Fatal("another host was held up by a busy one")
+	}
+
+	hl.Release("example.com")
+	if _, ok := hl.TryAcquire("example.com"); !ok {
+		t.Fatal("TryAcquire() refused after a Release")
+	}
+}
+
+func TestHostLimiterPause(t *testing.T) {
+	hl := NewHostLimiter(1000, 2, 3, time.Second)
+
+	hl.Pause("example.com", time.Minute)
+	hl.Pause("example.com", time.Second) // Shorter pauses are ignored
+	if paused := hl.PausedFor("example.com"); paused <= 30*time.Second {
+		t.Errorf("PausedFor() = %s, want about a minute", paused)
+	}
+	if wait, ok := hl.TryAcquire("example.com"); ok || wait <= 30*time.Second {
+		t.Errorf("TryAcquire() on a paused host = %s, %v", wait, ok)
+	}
+}
+
+// endPause ends the pause of a host as if its cooldown had passed
+func endPause(hl *HostLimiter, host string) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+	hl.state(host).pausedUntil = time.Time{}
+}
@@ -0,0 +1,149 @@
+package robots
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSize is the most of a robots.txt file that is parsed
+	maxSize = 500 << 10
+
+	// rulesTTL is how long fetched rules are reused
+	rulesTTL = 24 * time.Hour
+
+	// errorTTL is how long an unreachable robots.txt blocks its host before
+	// it is fetched again
+	errorTTL = 10 * time.Minute
+)
+
+// Cache fetches robots.txt once per host and keeps the parsed rules
+type Cache struct {
+	client    *http.Client
+	userAgent string
+	ignore    map[string]bool // Hosts whose robots.txt is not consulted
+
+	mu      sync.Mutex
+	entries map[string]*entry // By scheme and host
+}
+
+// entry holds the rules of one host. ready is closed once they are fetched,
+// so concurrent requests for a host share one fetch.
+type entry struct {
+	ready   chan struct{}
+	rules   *Rules
+	expires time.Time
+}
+
+// NewCache creates a cache fetching robots.txt with client as userAgent.
+// Hosts in ignore, such as sites we own, are never checked.
+func NewCache(client *http.Client, userAgent string, ignore []string) *Cache {
+	c := &Cache{
+		client:    client,
+		userAgent: userAgent,
+		ignore:    make(map[string]bool, len(ignore)),
+		entries:   make(map[string]*entry),
+	}
+	for _, host := range ignore {
+		c.ignore[strings.ToLower(strings.TrimSpace(host))] = true
+	}
+	return c
+}
+
+// Rules returns the rules for the host of u, fetching its robots.txt if
+// needed. A fetch cut off by the cancellation of ctx is not cached.
+func (c *Cache) Rules(ctx context.Context, u *url.URL) (*Rules, error) {
+	if c.ignore[strings.ToLower(u.Host)] || c.ignore[strings.ToLower(u.Hostname())] {
+		return AllowAll(), nil
+	}
+
+	key := u.Scheme + "://" + strings.ToLower(u.Host)
+
+	for {
+		c.mu.Lock()
+		e, ok := c.entries[key]
+		if !ok || (isReady(e) && time.Now().After(e.expires)) {
+			e = &entry{ready: make(chan struct{})}
+			c.entries[key] = e
+			c.mu.Unlock()
+
+			rules, expires, err := c.fetch(ctx, key)
+			if err != nil {
+				c.mu.Lock()
+				if c.entries[key] == e {
+					delete(c.entries, key)
+				}
+				c.mu.Unlock()
+				close(e.ready)
+				return nil, err
+			}
+			e.rules, e.expires = rules, expires
+			close(e.ready)
+			return e.rules, nil
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-e.ready:
+			if e.rules != nil {
+				return e.rules, nil
+			}
+			// The fetch we waited for was cancelled; try again
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// fetch downloads and parses the robots.txt of a site. As RFC 9309 asks, a
+// missing file (4xx) allows everything and an unreachable one (5xx, network
+// errors) blocks everything. It only fails when ctx is cancelled, as that
+// says nothing about the site.
+func (c *Cache) fetch(ctx context.Context, site string) (*Rules, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return DisallowAll(fmt.Sprintf("robots.txt unavailable: %v", err)), time.Now().Add(errorTTL), nil
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, time.Time{}, ctx.Err()
+		}
+		return DisallowAll(fmt.Sprintf("robots.txt unreachable: %v", err)), time.Now().Add(errorTTL), nil
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, time.Time{}, ctx.Err()
+			}
+			return DisallowAll(fmt.Sprintf("robots.txt unreadable: %v", err)), time.Now().Add(errorTTL), nil
+		}
+		return Parse(data, c.userAgent), time.Now().Add(rulesTTL), nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return AllowAll(), time.Now().Add(rulesTTL), nil
+	default:
+		return DisallowAll(fmt.Sprintf("robots.txt unavailable: status %d", resp.StatusCode)), time.Now().Add(errorTTL), nil
+	}
+}
+
+// isReady reports whether an entry has finished fetching
+func isReady(e *entry) bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
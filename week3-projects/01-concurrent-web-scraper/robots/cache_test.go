@@ -0,0 +1,49 @@
+package robots
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCacheDoesNotKeepCancelledFetch(t *testing.T) {
+	var requests atomic.Int32
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first fetch hangs until its client gives up
+		if requests.Add(1) == 1 {
+			close(started)
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	}))
+	defer server.Close()
+
+	cache := NewCache(server.Client(), "GoWebScraper/1.0", nil)
+	u, _ := url.Parse(server.URL + "/private")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	if _, err := cache.Rules(ctx, u); err == nil {
+		t.Fatal("Rules with a cancelled fetch returned no error")
+	}
+
+	rules, err := cache.Rules(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Rules after a cancelled fetch error = %v", err)
+	}
+	if rules.Unavailable() {
+		t.Fatal("the cancelled fetch was cached as unreachable")
+	}
+	if allowed, _ := rules.Allowed(u); allowed {
+		t.Error("Allowed(/private) = true, want the fetched rules to block it")
+	}
+}
@@ -0,0 +1,214 @@
+// Package robots parses robots.txt files and decides which URLs a crawler
+// may fetch, following RFC 9309: the group naming the crawler's product token
+// applies, or else the "*" group, and the longest matching rule wins.
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rules are the robots.txt rules that apply to one crawler on one host
+type Rules struct {
+	rules       []rule
+	crawlDelay  time.Duration
+	disallowAll bool   // robots.txt could not be fetched
+	reason      string // Why everything is disallowed
+}
+
+// rule is an Allow or Disallow line
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+func (r rule) String() string {
+	if r.allow {
+		return "Allow: " + r.pattern
+	}
+	return "Disallow: " + r.pattern
+}
+
+// group is a set of user agents with their rules
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+// AllowAll returns rules that allow every URL, for hosts without a robots.txt
+func AllowAll() *Rules {
+	return &Rules{}
+}
+
+// DisallowAll returns rules that block every URL, for hosts whose robots.txt
+// is unreachable
+func DisallowAll(reason string) *Rules {
+	return &Rules{disallowAll: true, reason: reason}
+}
+
+// Parse parses a robots.txt file and returns the rules for the crawler
+// identified by userAgent, such as "GoWebScraper/1.0"
+func Parse(data []byte, userAgent string) *Rules {
+	groups := parseGroups(data)
+	token := ProductToken(userAgent)
+
+	// Rules of all groups naming the crawler apply; without any, the "*" groups
+	var matched, wildcard []group
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == token {
+				matched = append(matched, g)
+				break
+			}
+			if agent == "*" {
+				wildcard = append(wildcard, g)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		matched = wildcard
+	}
+
+	rules := &Rules{}
+	for _, g := range matched {
+		rules.rules = append(rules.rules, g.rules...)
+		if g.crawlDelay > rules.crawlDelay {
+			rules.crawlDelay = g.crawlDelay
+		}
+	}
+	return rules
+}
+
+// parseGroups splits a robots.txt file into groups. Consecutive user-agent
+// lines open one group; unknown lines and lines outside a group are ignored.
+func parseGroups(data []byte) []group {
+	var groups []group
+	current := -1 // Index of the open group
+	inAgents := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				groups = append(groups, group{})
+				current = len(groups) - 1
+			}
+			groups[current].agents = append(groups[current].agents, ProductToken(value))
+			inAgents = true
+			continue
+		case "allow", "disallow":
+			// An empty Disallow allows everything, which is the default
+			if current >= 0 && value != "" {
+				groups[current].rules = append(groups[current].rules, rule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 && current >= 0 {
+				groups[current].crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+		inAgents = false
+	}
+	return groups
+}
+
+// ProductToken returns the lowercase name a crawler is matched by in
+// robots.txt: "GoWebScraper/1.0 (+https://example.com)" becomes "gowebscraper"
+func ProductToken(userAgent string) string {
+	token := strings.TrimSpace(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	return strings.ToLower(token)
+}
+
+// Allowed reports whether the crawler may fetch u and, when it may not, why
+func (r *Rules) Allowed(u *url.URL) (bool, string) {
+	if r.disallowAll {
+		return false, r.reason
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true, ""
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	// The most specific (longest) matching rule wins; Allow wins a tie
+	var best *rule
+	for i := range r.rules {
+		candidate := &r.rules[i]
+		if !match(candidate.pattern, path) {
+			continue
+		}
+		if best == nil || len(candidate.pattern) > len(best.pattern) ||
+			(len(candidate.pattern) == len(best.pattern) && candidate.allow) {
+			best = candidate
+		}
+	}
+
+	if best == nil || best.allow {
+		return true, ""
+	}
+	return false, fmt.Sprintf("disallowed by robots.txt (%s)", best)
+}
+
+// Unavailable reports whether the rules block everything because robots.txt
+// could not be fetched, rather than because it says so
+func (r *Rules) Unavailable() bool {
+	return r.disallowAll
+}
+
+// CrawlDelay returns the delay the host asks for between requests
+func (r *Rules) CrawlDelay() time.Duration {
+	return r.crawlDelay
+}
+
+// match reports whether a rule pattern matches a path. "*" matches any
+// sequence of characters and a trailing "$" anchors the pattern at the end.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+
+	for i, part := range parts[1:] {
+		// The last part of an anchored pattern must end the path
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+
+	return !anchored || rest == ""
+}
@@ -0,0 +1,189 @@
+package robots
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/", "/", true},
+		{"/", "/anything", true},
+		{"/private", "/private", true},
+		{"/private", "/private/page", true},
+		{"/private", "/privately", true},
+		{"/private", "/public", false},
+		{"/private/", "/private", false},
+		{"/*.php", "/index.php", true},
+		{"/*.php", "/dir/index.php?x=1", true},
+		{"/*.php", "/index.html", false},
+		{"/*.php$", "/index.php", true},
+		{"/*.php$", "/index.php?x=1", false},
+		{"/*.php$", "/index.phps", false},
+		{"/a*b*c", "/a-b-c", true},
+		{"/a*b*c", "/a-c-b", false},
+		{"/a*bc*c$", "/abc", false},
+		{"/a*bc*c$", "/abcc", true},
+		{"/page$", "/page", true},
+		{"/page$", "/page/", false},
+		{"*", "/anything", true},
+		{"/*", "/", true},
+	}
+
+	for _, tt := range tests {
+		if got := match(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	const robotsTxt = `
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Allow: /tie
+Disallow: /tie
+Disallow: /search?
+Allow: /search?q=
+`
+	rules := Parse([]byte(robotsTxt), "GoWebScraper/1.0")
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/", true},
+		{"https://example.com/private", false},
+		{"https://example.com/private/page", false},
+		{"https://example.com/private/public", true},       // The longer Allow wins
+		{"https://example.com/private/public/x", true},     // Prefixes match
+		{"https://example.com/files/report.pdf", false},    // "*" and "$"
+		{"https://example.com/files/report.pdf?v=2", true}, // "$" anchors at the end of the query
+		{"https://example.com/tie", true},                  // Allow wins a tie
+		{"https://example.com/search?page=2", false},       // Queries are matched
+		{"https://example.com/search?q=go", true},
+		{"https://example.com/robots.txt", true}, // Always allowed
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		allowed, reason := rules.Allowed(u)
+		if allowed != tt.want {
+			t.Errorf("Allowed(%s) = %v (%s), want %v", tt.url, allowed, reason, tt.want)
+		}
+		if !allowed && reason == "" {
+			t.Errorf("Allowed(%s) gave no reason", tt.url)
+		}
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	const robotsTxt = `
+# Comments and unknown lines are ignored
+Sitemap: https://example.com/sitemap.xml
+
+User-agent: *
+Disallow: /everyone
+Crawl-delay: 1
+
+User-agent: OtherBot
+User-agent: GoWebScraper
+Disallow: /first   # A trailing comment
+Crawl-delay: 2
+
+User-agent: gowebscraper/2.0
+Disallow: /second
+Crawl-delay: 0.5
+`
+
+	tests := []struct {
+		name      string
+		userAgent string
+		allowed   []string
+		blocked   []string
+		delay     time.Duration
+	}{
+		{
+			// Both groups naming the crawler apply, merged, and not the "*" group
+			name:      "groups naming the crawler are merged",
+			userAgent: "GoWebScraper/1.0 (+https://example.com)",
+			allowed:   []string{"/everyone"},
+			blocked:   []string{"/first", "/second"},
+			delay:     2 * time.Second,
+		},
+		{
+			name:      "a user-agent line among several opens one group",
+			userAgent: "OtherBot",
+			allowed:   []string{"/everyone", "/second"},
+			blocked:   []string{"/first"},
+			delay:     2 * time.Second,
+		},
+		{
+			name:      "other crawlers get the * group",
+			userAgent: "SomeBot/3.1",
+			allowed:   []string{"/first", "/second"},
+			blocked:   []string{"/everyone"},
+			delay:     time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := Parse([]byte(robotsTxt), tt.userAgent)
+			for _, path := range tt.allowed {
+				if allowed, reason := rules.Allowed(&url.URL{Path: path}); !allowed {
+					t.Errorf("%s blocked (%s), want allowed", path, reason)
+				}
+			}
+			for _, path := range tt.blocked {
+				if allowed, _ := rules.Allowed(&url.URL{Path: path}); allowed {
+					t.Errorf("%s allowed, want blocked", path)
+				}
+			}
+			if got := rules.CrawlDelay(); got != tt.delay {
+				t.Errorf("CrawlDelay() = %s, want %s", got, tt.delay)
+			}
+		})
+	}
+}
+
+func TestCrawlDelay(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"2", 2 * time.Second},
+		{"0.25", 250 * time.Millisecond},
+		{"0", 0},
+		{"-1", 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		rules := Parse([]byte("User-agent: *\nCrawl-delay: "+tt.value+"\n"), "GoWebScraper/1.0")
+		if got := rules.CrawlDelay(); got != tt.want {
+			t.Errorf("Crawl-delay: %s gives %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDisallowAll(t *testing.T) {
+	rules := DisallowAll("robots.txt unreachable")
+	allowed, reason := rules.Allowed(&url.URL{Path: "/robots.txt"})
+	if allowed || reason != "robots.txt unreachable" {
+		t.Errorf("Allowed(/robots.txt) = %v, %q, want blocked as unreachable", allowed, reason)
+	}
+	if !rules.Unavailable() {
+		t.Error("Unavailable() = false, want true")
+	}
+}
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/web-scraper/models"
+)
+
+// Errors that stop a chain of redirects
+var (
+	errTooManyRedirects = fmt.Errorf("stopped after %d redirects", maxRedirects)
+	errHostUnavailable  = errors.New("redirect target host unavailable")
+)
+
+// classifyError returns the error class of a failed request or body read. A
+// request cancelled because the scraper is stopping is not a result at all.
+func classifyError(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return models.ErrorCanceled
+	case errors.Is(err, errTooManyRedirects):
+		return models.ErrorRedirect
+	case errors.Is(err, errHostUnavailable):
+		return models.ErrorRateLimited
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return models.ErrorDNS
+		case dnsErr.IsTimeout:
+			return models.ErrorTimeout
+		}
+		return models.ErrorConnection // A lookup that may succeed next time
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return models.ErrorTimeout
+	}
+
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		recordErr    tls.RecordHeaderError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return models.ErrorTLS
+	}
+
+	if strings.Contains(err.Error(), "unsupported protocol scheme") {
+		return models.ErrorInvalid
+	}
+
+	return models.ErrorConnection
+}
+
+// classifyStatus returns the error class of a response other than 200 OK
+func classifyStatus(code int) string {
+	switch {
+	case code == http.StatusRequestTimeout:
+		return models.ErrorTimeout
+	case code == http.StatusTooManyRequests:
+		return models.ErrorRateLimited
+	case code >= 500:
+		return models.ErrorServer
+	case code >= 400:
+		return models.ErrorClient
+	case code >= 300:
+		return models.ErrorRedirect
+	}
+	return models.ErrorInvalid
+}
+
+// retryAfter returns how long a 429 or 503 response asks clients to wait,
+// given in seconds or as a date, or 0 if it does not say
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
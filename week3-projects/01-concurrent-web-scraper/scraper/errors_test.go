@@ -0,0 +1,105 @@
+package scraper
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/user/web-scraper/models"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		status int
+		value  string
+		want   time.Duration
+	}{
+		{"seconds", http.StatusTooManyRequests, "120", 2 * time.Minute},
+		{"seconds with spaces", http.StatusServiceUnavailable, " 5 ", 5 * time.Second},
+		{"zero seconds", http.StatusTooManyRequests, "0", 0},
+		{"negative seconds", http.StatusTooManyRequests, "-30", 0},
+		{"HTTP date", http.StatusServiceUnavailable, now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{"HTTP date in the past", http.StatusTooManyRequests, now.Add(-time.Hour).Format(http.TimeFormat), 0},
+		{"unparsable", http.StatusTooManyRequests, "soon", 0},
+		{"missing", http.StatusTooManyRequests, "", 0},
+		{"other status", http.StatusInternalServerError, "120", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.value != "" {
+				resp.Header.Set("Retry-After", tt.value)
+			}
+			if got := retryAfter(resp, now); got != tt.want {
+				t.Errorf("retryAfter(%d, %q) = %s, want %s", tt.status, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	// Errors reach classifyError wrapped the way http.Client returns them
+	wrap := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://example.com/", Err: err}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"cancelled", wrap(context.Canceled), models.ErrorCanceled},
+		{"deadline", wrap(context.DeadlineExceeded), models.ErrorTimeout},
+		{"too many redirects", wrap(errTooManyRedirects), models.ErrorRedirect},
+		{"redirect host unavailable", wrap(errHostUnavailable), models.ErrorRateLimited},
+		{"host not found", wrap(&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}), models.ErrorDNS},
+		{"lookup timeout", wrap(&net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}), models.ErrorTimeout},
+		{"lookup failure", wrap(&net.DNSError{Err: "server misbehaving", Name: "example.com"}), models.ErrorConnection},
+		{"unknown authority", wrap(x509.UnknownAuthorityError{}), models.ErrorTLS},
+		{"wrong host certificate", wrap(x509.HostnameError{Host: "example.com"}), models.ErrorTLS},
+		{"unsupported scheme", wrap(errors.New(`unsupported protocol scheme "ftp"`)), models.ErrorInvalid},
+		{"connection refused", wrap(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}), models.ErrorConnection},
+		{"cancelled body read", fmt.Errorf("failed to read body: %w", context.Canceled), models.ErrorCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want string
+	}{
+		{http.StatusRequestTimeout, models.ErrorTimeout},
+		{http.StatusTooManyRequests, models.ErrorRateLimited},
+		{http.StatusInternalServerError, models.ErrorServer},
+		{http.StatusServiceUnavailable, models.ErrorServer},
+		{http.StatusNotFound, models.ErrorClient},
+		{http.StatusForbidden, models.ErrorClient},
+		{http.StatusMovedPermanently, models.ErrorRedirect},
+		{http.StatusNoContent, models.ErrorInvalid},
+	}
+
+	for _, tt := range tests {
+		if got := classifyStatus(tt.code); got != tt.want {
+			t.Errorf("classifyStatus(%d) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
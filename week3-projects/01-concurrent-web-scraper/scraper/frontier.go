@@ -0,0 +1,364 @@
+package scraper
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/web-scraper/models"
+	"github.com/user/web-scraper/ratelimiter"
+	"github.com/user/web-scraper/storage"
+)
+
+// Frontier is the queue of URLs waiting to be scraped. It remembers every URL
+// it has admitted, so each page is visited once, and stops admitting new URLs
+// when the page budget is spent. Unlike a channel it never blocks producers:
+// pages can discover any number of links while workers are busy.
+//
+// Jobs are queued per host and handed out by host politeness: Next returns a
+// job from whichever host the host limiter lets go first, taking turns among
+// hosts that are ready, so a slow or rate-limited host never stalls the rest.
+// Among ready hosts, and within a host, higher priority jobs go first.
+//
+// Retries may be delayed: a job with a NotBefore time waits aside until then.
+//
+// Every change is mirrored in the database, where each URL is queued, in
+// flight, done or failed. A job handed out is leased for a limited time, so
+// a run that is killed can be resumed once its leases have expired.
+type Frontier struct {
+	mu       sync.Mutex
+	queues   map[string][]models.ScrapeJob // Queued jobs by host, by priority
+	hosts    []string                      // Hosts with queued jobs, in turn order
+	delayed  []models.ScrapeJob            // Jobs waiting for their NotBefore time, soonest first
+	turn     int                           // Index in hosts where the next search starts
+	limiter  *ratelimiter.HostLimiter
+	db       *storage.Database
+	lease    time.Duration // How long a worker may hold a job
+	visited  map[string]bool
+	maxPages int           // 0 for no limit
+	pending  int           // Jobs queued or being scraped
+	closed   bool          // No more seed URLs will be added
+	changed  chan struct{} // Closed and replaced whenever the state changes
+}
+
+// NewFrontier creates a frontier admitting at most maxPages URLs (0 for no
+// limit), pacing each host with limiter and persisting its state in db. Jobs
+// are leased to workers for the lease duration.
+func NewFrontier(maxPages int, limiter *ratelimiter.HostLimiter, db *storage.Database, lease time.Duration) *Frontier {
+	return &Frontier{
+		queues:   make(map[string][]models.ScrapeJob),
+		limiter:  limiter,
+		db:       db,
+		lease:    lease,
+		visited:  make(map[string]bool),
+		maxPages: maxPages,
+		changed:  make(chan struct{}),
+	}
+}
+
+// Add queues a job for a URL that was not seen before. It returns false when
+// the URL is invalid, already visited or over the page budget.
+func (f *Frontier) Add(job models.ScrapeJob) bool {
+	normalized, err := NormalizeURL(job.URL)
+	if err != nil {
+		return false
+	}
+	job.URL = normalized
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.visited[job.URL] {
+		return false
+	}
+	if f.maxPages > 0 && len(f.visited) >= f.maxPages {
+		return false
+	}
+
+	if err := f.db.EnqueueURL(job); err != nil {
+		log.Printf("Failed to persist frontier: %v", err)
+	}
+	f.visited[job.URL] = true
+	f.push(job)
+	return true
+}
+
+// Reset forgets the frontier of any previous run, for a fresh start
+func (f *Frontier) Reset() error {
+	return f.db.ResetFrontier()
+}
+
+// Resume restores the frontier of an interrupted run and returns the number
+// of URLs it had left. URLs it had in flight are queued again, once their
+// leases have expired in case that run is still going.
+func (f *Frontier) Resume(ctx context.Context) (int, error) {
+	entries, err := f.db.LoadFrontier()
+	if err != nil {
+		return 0, err
+	}
+
+	var leasedUntil time.Time
+	for _, entry := range entries {
+		if entry.State == models.FrontierInFlight && entry.LeaseExpires != nil && entry.LeaseExpires.After(leasedUntil) {
+			leasedUntil = *entry.LeaseExpires
+		}
+	}
+	if wait := time.Until(leasedUntil); wait > 0 {
+		log.Printf("Waiting %s for the leases of the interrupted run to expire", wait.Round(time.Second))
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	if _, err := f.db.ReleaseLeases(); err != nil {
+		return 0, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	left := 0
+	for _, entry := range entries {
+		f.visited[entry.URL] = true
+		if entry.State != models.FrontierQueued && entry.State != models.FrontierInFlight {
+			continue
+		}
+		job := models.ScrapeJob{
+			URL:        entry.URL,
+			RetryCount: entry.RetryCount,
+			Depth:      entry.Depth,
+			ParentURL:  entry.ParentURL,
+			Priority:   entry.Priority,
+		}
+		if entry.NotBefore != nil {
+			job.NotBefore = *entry.NotBefore
+		}
+		f.push(job)
+		left++
+	}
+	return left, nil
+}
+
+// Suspend puts the jobs still in flight back in the queue, so a run that is
+// stopped early can be resumed at once
+func (f *Frontier) Suspend() {
+	released, err := f.db.ReleaseLeases()
+	if err != nil {
+		log.Printf("Failed to persist frontier: %v", err)
+		return
+	}
+	if released > 0 {
+		log.Printf("Returned %d unfinished URLs to the frontier", released)
+	}
+}
+
+// Retry queues a job again, bypassing the visited set. A job with a NotBefore
+// time is not handed out before then.
+func (f *Frontier) Retry(job models.ScrapeJob) {
+	if err := f.db.RequeueURL(job); err != nil {
+		log.Printf("Failed to persist frontier: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.push(job)
+}
+
+// push adds a job to the frontier, delayed until its NotBefore time if that
+// is in the future; the caller holds the lock
+func (f *Frontier) push(job models.ScrapeJob) {
+	if time.Now().Before(job.NotBefore) {
+		i := sort.Search(len(f.delayed), func(i int) bool { return f.delayed[i].NotBefore.After(job.NotBefore) })
+		f.delayed = append(f.delayed, models.ScrapeJob{})
+		copy(f.delayed[i+1:], f.delayed[i:])
+		f.delayed[i] = job
+	} else {
+		f.enqueue(job)
+	}
+
+	f.pending++
+	f.notify()
+}
+
+// enqueue inserts a job in its host's queue after the jobs of the same or
+// higher priority; the caller holds the lock
+func (f *Frontier) enqueue(job models.ScrapeJob) {
+	host := hostOf(job.URL)
+	queue := f.queues[host]
+	if len(queue) == 0 {
+		f.hosts = append(f.hosts, host)
+	}
+
+	i := sort.Search(len(queue), func(i int) bool { return queue[i].Priority < job.Priority })
+	queue = append(queue, models.ScrapeJob{})
+	copy(queue[i+1:], queue[i:])
+	queue[i] = job
+	f.queues[host] = queue
+}
+
+// Next blocks until a host with queued jobs is allowed another request and
+// returns its first job, leased to the caller. It returns false when ctx is done or the crawl is
+// over: the frontier is closed and no job is queued or being scraped. Every
+// job returned must be given back to Release once fetched and finished with
+// Done once its result is processed.
+func (f *Frontier) Next(ctx context.Context) (models.ScrapeJob, bool) {
+	for {
+		f.mu.Lock()
+		job, wait, ok := f.pop()
+		if ok {
+			f.mu.Unlock()
+			return job, true
+		}
+		if f.closed && f.pending == 0 {
+			f.mu.Unlock()
+			return models.ScrapeJob{}, false
+		}
+		changed := f.changed
+		f.mu.Unlock()
+
+		// Sleep until the state changes or the first host earns a token
+		var timer *time.Timer
+		var ready <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			ready = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-ready:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return models.ScrapeJob{}, false
+		}
+	}
+}
+
+// pop removes the next job from the ready host with the highest priority job,
+// taking turns from the host served last on ties. When no host is ready it
+// returns the shortest wait for a token or a delayed job, or 0 if every host
+// is at its concurrency cap. The caller holds the lock.
+func (f *Frontier) pop() (models.ScrapeJob, time.Duration, bool) {
+	// Delayed jobs whose time has come join their host's queue
+	now := time.Now()
+	due := 0
+	for due < len(f.delayed) && !now.Before(f.delayed[due].NotBefore) {
+		f.enqueue(f.delayed[due])
+		due++
+	}
+	f.delayed = f.delayed[due:]
+
+	var shortest time.Duration
+	if len(f.delayed) > 0 {
+		shortest = max(f.delayed[0].NotBefore.Sub(now), time.Millisecond)
+	}
+
+	order := make([]int, len(f.hosts))
+	for i := range order {
+		order[i] = (f.turn + i) % len(f.hosts)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return f.queues[f.hosts[order[a]]][0].Priority > f.queues[f.hosts[order[b]]][0].Priority
+	})
+
+	for _, idx := range order {
+		host := f.hosts[idx]
+
+		wait, ok := f.limiter.TryAcquire(host)
+		if !ok {
+			if wait > 0 && (shortest == 0 || wait < shortest) {
+				shortest = wait
+			}
+			continue
+		}
+
+		queue := f.queues[host]
+		job := queue[0]
+		if len(queue) == 1 {
+			delete(f.queues, host)
+			f.hosts = append(f.hosts[:idx], f.hosts[idx+1:]...)
+			f.turn = idx
+		} else {
+			f.queues[host] = queue[1:]
+			f.turn = idx + 1
+		}
+		if len(f.hosts) > 0 {
+			f.turn %= len(f.hosts)
+		}
+
+		if err := f.db.LeaseURL(job.URL, time.Now().Add(f.lease)); err != nil {
+			log.Printf("Failed to persist frontier: %v", err)
+		}
+		return job, 0, true
+	}
+	return models.ScrapeJob{}, shortest, false
+}
+
+// Release frees the host slot taken by a job returned by Next, once the job's
+// request is over
+func (f *Frontier) Release(job models.ScrapeJob) {
+	f.limiter.Release(hostOf(job.URL))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notify()
+}
+
+// Done marks a job returned by Next as finished, in its final state: done,
+// failed, or queued if it was retried. Links found on the page and retries
+// must be added before, so the crawl does not end early.
+func (f *Frontier) Done(url, state string) {
+	if state != models.FrontierQueued {
+		if err := f.db.FinishURL(url, state); err != nil {
+			log.Printf("Failed to persist frontier: %v", err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending--
+	f.notify()
+}
+
+// Close tells the frontier that no more seed URLs will be added. The crawl
+// ends once all queued jobs, and the links they discover, are done.
+func (f *Frontier) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	f.notify()
+}
+
+// Visited returns the number of URLs admitted so far
+func (f *Frontier) Visited() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.visited)
+}
+
+// notify wakes goroutines waiting in Next; the caller holds the lock
+func (f *Frontier) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// hostOf returns the lowercase host (and port) of a URL, which requests are
+// paced by
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
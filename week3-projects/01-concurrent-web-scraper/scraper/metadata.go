@@ -0,0 +1,232 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/user/web-scraper/models"
+)
+
+// ParseMetadata extracts the metadata a page declares: canonical and hreflang
+// links, OpenGraph and Twitter card tags, JSON-LD blocks and microdata items
+func (p *Parser) ParseMetadata(doc *goquery.Document, pageURL string) *models.PageMetadata {
+	baseURL := p.documentBase(doc, pageURL)
+	meta := &models.PageMetadata{}
+
+	// Canonical URL and alternate language versions
+	if href, exists := doc.Find("link[rel~='canonical'][href]").First().Attr("href"); exists {
+		meta.Canonical = p.resolveURL(href, baseURL)
+	}
+	doc.Find("link[rel~='alternate'][hreflang][href]").Each(func(i int, s *goquery.Selection) {
+		lang, _ := s.Attr("hreflang")
+		href, _ := s.Attr("href")
+		if meta.Hreflang == nil {
+			meta.Hreflang = make(map[string]string)
+		}
+		if _, seen := meta.Hreflang[lang]; !seen {
+			meta.Hreflang[lang] = p.resolveURL(href, baseURL)
+		}
+	})
+
+	// OpenGraph uses property, Twitter cards name (or property on some sites)
+	meta.OpenGraph = metaTags(doc, "meta[property^='og:']", "property")
+	meta.Twitter = metaTags(doc, "meta[name^='twitter:']", "name")
+	for key, value := range metaTags(doc, "meta[property^='twitter:']", "property") {
+		if meta.Twitter == nil {
+			meta.Twitter = make(map[string]string)
+		}
+		if _, seen := meta.Twitter[key]; !seen {
+			meta.Twitter[key] = value
+		}
+	}
+
+	// JSON-LD blocks; invalid ones are skipped
+	doc.Find("script[type='application/ld+json']").Each(func(i int, s *goquery.Selection) {
+		var block bytes.Buffer
+		if err := json.Compact(&block, []byte(strings.TrimSpace(s.Text()))); err != nil {
+			return
+		}
+		meta.JSONLD = append(meta.JSONLD, block.Bytes())
+	})
+
+	// Top-level microdata items: their nested items are properties
+	doc.Find("[itemscope]:not([itemprop])").Each(func(i int, s *goquery.Selection) {
+		meta.Microdata = append(meta.Microdata, p.microdataItem(s, baseURL))
+	})
+
+	meta.Types = schemaTypes(meta)
+	return meta
+}
+
+// metaTags collects the content of meta tags keyed by an attribute, keeping
+// the first value of repeated keys
+func metaTags(doc *goquery.Document, selector, keyAttr string) map[string]string {
+	var tags map[string]string
+	doc.Find(selector).Each(func(i int, s *goquery.Selection) {
+		key, _ := s.Attr(keyAttr)
+		content, exists := s.Attr("content")
+		if !exists {
+			return
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		if _, seen := tags[key]; !seen {
+			tags[key] = strings.TrimSpace(content)
+		}
+	})
+	return tags
+}
+
+// microdataItem reads the properties of an itemscope element. Properties of
+// nested items belong to them, not to this item.
+func (p *Parser) microdataItem(scope *goquery.Selection, baseURL string) *models.MicrodataItem {
+	item := &models.MicrodataItem{Properties: make(map[string][]interface{})}
+	item.Type, _ = scope.Attr("itemtype")
+
+	var walk func(*goquery.Selection)
+	walk = func(parent *goquery.Selection) {
+		parent.Children().Each(func(i int, s *goquery.Selection) {
+			_, scoped := s.Attr("itemscope")
+			prop, hasProp := s.Attr("itemprop")
+
+			if hasProp {
+				var value interface{}
+				if scoped {
+					value = p.microdataItem(s, baseURL)
+				} else {
+					value = p.microdataValue(s, baseURL)
+				}
+				for _, name := range strings.Fields(prop) {
+					item.Properties[name] = append(item.Properties[name], value)
+				}
+			}
+
+			// A nested scope, property or not, is a separate item
+			if !scoped {
+				walk(s)
+			}
+		})
+	}
+	walk(scope)
+
+	return item
+}
+
+// microdataValue returns the value of an itemprop element, which depends on
+// the element as the HTML spec defines
+func (p *Parser) microdataValue(s *goquery.Selection, baseURL string) string {
+	attr := ""
+	resolve := false
+	switch goquery.NodeName(s) {
+	case "meta":
+		attr = "content"
+	case "a", "area", "link":
+		attr, resolve = "href", true
+	case "img", "audio", "video", "source", "iframe", "embed", "track":
+		attr, resolve = "src", true
+	case "object":
+		attr, resolve = "data", true
+	case "time":
+		attr = "datetime"
+	case "data", "meter":
+		attr = "value"
+	}
+
+	if attr != "" {
+		if value, exists := s.Attr(attr); exists {
+			if resolve {
+				return p.resolveURL(value, baseURL)
+			}
+			return strings.TrimSpace(value)
+		}
+	}
+	return p.SanitizeText(s.Text())
+}
+
+// schemaTypes lists the schema.org types a page declares, without the
+// https://schema.org/ prefix
+func schemaTypes(meta *models.PageMetadata) []string {
+	var types []string
+	seen := make(map[string]bool)
+	add := func(t string) {
+		t = strings.TrimSpace(t)
+		for _, prefix := range []string{"https://schema.org/", "http://schema.org/", "schema:"} {
+			t = strings.TrimPrefix(t, prefix)
+		}
+		if t != "" && !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+
+	for _, block := range meta.JSONLD {
+		var data interface{}
+		if err := json.Unmarshal(block, &data); err == nil {
+			jsonLDTypes(data, add)
+		}
+	}
+
+	var microdata func(*models.MicrodataItem)
+	microdata = func(item *models.MicrodataItem) {
+		for _, t := range strings.Fields(item.Type) {
+			add(t)
+		}
+		names := make([]string, 0, len(item.Properties))
+		for name := range item.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, value := range item.Properties[name] {
+				if nested, ok := value.(*models.MicrodataItem); ok {
+					microdata(nested)
+				}
+			}
+		}
+	}
+	for _, item := range meta.Microdata {
+		microdata(item)
+	}
+
+	return types
+}
+
+// jsonLDTypes calls add with every @type in a JSON-LD value, including those
+// of nested nodes and @graph entries
+func jsonLDTypes(data interface{}, add func(string)) {
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			jsonLDTypes(item, add)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value := v[key]
+			if key != "@type" {
+				jsonLDTypes(value, add)
+				continue
+			}
+			switch t := value.(type) {
+			case string:
+				add(t)
+			case []interface{}:
+				for _, name := range t {
+					if s, ok := name.(string); ok {
+						add(s)
+					}
+				}
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"net/url"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -23,6 +24,12 @@ func (p *Parser) ParseHTML(html string, baseURL string) (string, string, []model
 		return "", "", nil, err
 	}
 
+	title, description, links := p.ParseDocument(doc, baseURL)
+	return title, description, links, nil
+}
+
+// ParseDocument extracts title, description, and links from a parsed page
+func (p *Parser) ParseDocument(doc *goquery.Document, baseURL string) (string, string, []models.LinkData) {
 	// Extract title
 	title := p.extractTitle(doc)
 
@@ -32,7 +39,7 @@ func (p *Parser) ParseHTML(html string, baseURL string) (string, string, []model
 	// Extract links
 	links := p.extractLinks(doc, baseURL)
 
-	return title, description, links, nil
+	return title, description, links
 }
 
 // extractTitle extracts the page title
@@ -90,6 +97,8 @@ func (p *Parser) extractLinks(doc *goquery.Document, baseURL string) []models.Li
 	links := []models.LinkData{}
 	seen := make(map[string]bool)
 
+	baseURL = p.documentBase(doc, baseURL)
+
 	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
 		href, exists := s.Attr("href")
 		if !exists || href == "" {
@@ -109,14 +118,17 @@ func (p *Parser) extractLinks(doc *goquery.Document, baseURL string) []models.Li
 			text = href
 		}
 
-		// Avoid duplicates
-		if seen[href] {
+		// Resolve relative URLs
+		absoluteURL := p.resolveURL(href, baseURL)
+		if !strings.HasPrefix(absoluteURL, "http://") && !strings.HasPrefix(absoluteURL, "https://") {
 			return
 		}
-		seen[href] = true
 
-		// Resolve relative URLs
-		absoluteURL := p.resolveURL(href, baseURL)
+		// Avoid duplicates
+		if seen[absoluteURL] {
+			return
+		}
+		seen[absoluteURL] = true
 
 		links = append(links, models.LinkData{
 			URL:  absoluteURL,
@@ -127,22 +139,30 @@ func (p *Parser) extractLinks(doc *goquery.Document, baseURL string) []models.Li
 	return links
 }
 
-// resolveURL converts relative URLs to absolute URLs
+// documentBase returns the URL relative links on a page resolve against:
+// its <base href> when it has one, or else the page URL
+func (p *Parser) documentBase(doc *goquery.Document, pageURL string) string {
+	if base, exists := doc.Find("base[href]").First().Attr("href"); exists {
+		return p.resolveURL(base, pageURL)
+	}
+	return pageURL
+}
+
+// resolveURL converts relative URLs to absolute URLs, without the fragment
 func (p *Parser) resolveURL(href, baseURL string) string {
-	// If already absolute, return as is
-	if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
-		return href
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
 	}
 
-	// If protocol-relative URL
-	if strings.HasPrefix(href, "//") {
-		return "https:" + href
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return ref.String()
 	}
 
-	// For relative URLs, we'd need proper URL parsing
-	// For simplicity, just return the href
-	// In production, use url.Parse and url.ResolveReference
-	return href
+	resolved := base.ResolveReference(ref)
+	resolved.Fragment = ""
+	return resolved.String()
 }
 
 // SanitizeText cleans and normalizes text
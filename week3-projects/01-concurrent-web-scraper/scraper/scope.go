@@ -0,0 +1,148 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Scope modes
+const (
+	ScopeHost   = "host"   // Follow links to the hosts of the seed URLs
+	ScopeDomain = "domain" // Follow links to the registrable domains of the seed URLs
+	ScopeAny    = "any"    // Follow links to any host
+)
+
+// Scope decides which discovered links a crawl follows
+type Scope struct {
+	mode    string
+	allowed map[string]bool // Seed hosts or domains, depending on the mode
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// NewScope creates a scope for a crawl starting at the seed URLs
+func NewScope(mode string, seeds []string, include, exclude []string) (*Scope, error) {
+	s := &Scope{mode: mode, allowed: make(map[string]bool)}
+
+	switch mode {
+	case ScopeHost, ScopeDomain, ScopeAny:
+	default:
+		return nil, fmt.Errorf("invalid scope %q", mode)
+	}
+
+	for _, seed := range seeds {
+		u, err := url.Parse(seed)
+		if err != nil {
+			continue
+		}
+		s.allowed[s.key(u.Hostname())] = true
+	}
+
+	var err error
+	if s.include, err = compilePatterns(include); err != nil {
+		return nil, err
+	}
+	if s.exclude, err = compilePatterns(exclude); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Allows reports whether a discovered link should be followed
+func (s *Scope) Allows(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+
+	if s.mode != ScopeAny && !s.allowed[s.key(u.Hostname())] {
+		return false
+	}
+
+	if len(s.include) > 0 && !matchAny(s.include, rawURL) {
+		return false
+	}
+	return !matchAny(s.exclude, rawURL)
+}
+
+// key returns the host or registrable domain a host is compared by
+func (s *Scope) key(host string) string {
+	host = strings.ToLower(host)
+	if s.mode != ScopeDomain {
+		return host
+	}
+
+	// IP addresses and single-label hosts have no registrable domain
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// compilePatterns compiles URL regular expressions
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// matchAny reports whether any of the patterns matches s
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeURL returns the canonical form of an absolute HTTP(S) URL, so the
+// same page is visited once however it is linked: the scheme and host are
+// lowercased, default ports and the fragment are dropped, and an empty path
+// becomes "/"
+func NormalizeURL(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("missing host in %q", rawURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		host = host + ":" + port
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+	u.Host = host
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u.String(), nil
+}
@@ -1,46 +1,115 @@
 package scraper
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	neturl "net/url"
+	"strconv"
 	"time"
 
+	"github.com/PuerkitoBio/goquery"
+	"github.com/user/web-scraper/extract"
 	"github.com/user/web-scraper/models"
+	"github.com/user/web-scraper/ratelimiter"
+	"github.com/user/web-scraper/robots"
 )
 
+// maxBodySize caps how much of a response is read, so a huge or endless
+// response cannot exhaust memory
+const maxBodySize = 10 << 20
+
+// maxCrawlDelay caps the Crawl-delay a robots.txt can ask for, so one host
+// cannot slow its crawl to a standstill
+const maxCrawlDelay = 30 * time.Second
+
+// maxRedirects is how many redirects a request follows, as many as the
+// default http.Client
+const maxRedirects = 10
+
+// maxHopWait caps how long a redirect waits for its new host to allow a
+// request, and hostPollInterval how often a host at its concurrency cap is
+// asked again
+const (
+	maxHopWait       = maxCrawlDelay
+	hostPollInterval = 100 * time.Millisecond
+)
+
+// redirectKey is the context key of the redirectState of a request
+type redirectKey struct{}
+
+// redirectState is what checkRedirect keeps while following the redirects of
+// one request
+type redirectState struct {
+	skipReason string   // Why the chain stopped at its last response
+	hosts      []string // Hosts whose in-flight slot is held until the request is done
+}
+
 // Scraper handles web scraping operations
 type Scraper struct {
-	client      *http.Client
-	parser      *Parser
-	userAgent   string
-	maxRetries  int
+	client     *http.Client
+	parser     *Parser
+	userAgent  string
+	maxRetries int
+	retryDelay time.Duration // Base of the exponential backoff between retries
+	robots     *robots.Cache
+	hosts      *ratelimiter.HostLimiter // Paces each host, slowed by its Crawl-delay
+	scope      *Scope                   // Where redirects may lead, nil for anywhere
+	rules      *extract.Rules           // Structured data to extract, nil for none
+	archive    bool                     // Keep raw responses for WARC export
 }
 
-// NewScraper creates a new scraper instance
-func NewScraper(timeout time.Duration, userAgent string, maxRetries int, followRedirects bool) *Scraper {
+// NewScraper creates a new scraper instance. robots.txt is obeyed on every
+// host except those in ignoreRobots, such as sites we own; the Crawl-delay it
+// asks for is applied to hosts. Redirects are checked like the URLs they lead
+// to: each hop must be allowed by robots.txt and by scope, which may be nil,
+// and waits for its host when that changes. Pages matching a site in rules get its fields
+// extracted; rules may be nil. With archive set, results carry the raw
+// response, whatever its status. Pages failing with a retryable error get up
+// to maxRetries more attempts, after an exponential backoff from retryDelay.
+func NewScraper(timeout time.Duration, userAgent string, maxRetries int, retryDelay time.Duration, followRedirects bool, scope *Scope, ignoreRobots []string, hosts *ratelimiter.HostLimiter, rules *extract.Rules, archive bool) *Scraper {
 	client := &http.Client{
 		Timeout: timeout,
 	}
 
-	// Configure redirect policy
-	if !followRedirects {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}
-	}
+	// robots.txt redirects are always followed, as RFC 9309 asks
+	robotsClient := &http.Client{Timeout: timeout}
 
-	return &Scraper{
+	s := &Scraper{
 		client:     client,
 		parser:     NewParser(),
 		userAgent:  userAgent,
 		maxRetries: maxRetries,
+		retryDelay: retryDelay,
+		robots:     robots.NewCache(robotsClient, userAgent, ignoreRobots),
+		hosts:      hosts,
+		scope:      scope,
+		rules:      rules,
+		archive:    archive,
 	}
+
+	// Configure redirect policy
+	if followRedirects {
+		client.CheckRedirect = s.checkRedirect
+	} else {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return s
 }
 
-// ScrapeURL scrapes a single URL and returns the result
-func (s *Scraper) ScrapeURL(ctx context.Context, url string) *models.ScrapeResult {
+// ScrapeURL scrapes a single URL and returns the result. With the validators
+// of a stored copy the request is conditional, and a 304 answer is reported
+// as NotModified without a body.
+func (s *Scraper) ScrapeURL(ctx context.Context, url string, since *models.Validators) *models.ScrapeResult {
 	startTime := time.Now()
 
 	result := &models.ScrapeResult{
@@ -48,10 +117,28 @@ func (s *Scraper) ScrapeURL(ctx context.Context, url string) *models.ScrapeResul
 		ScrapedAt: startTime,
 	}
 
+	// Respect robots.txt: blocked URLs are skipped, not failed
+	reason, err := s.checkRobots(ctx, url)
+	if err != nil {
+		result.Error = fmt.Errorf("robots.txt check failed: %w", err)
+		result.ErrorClass = models.ErrorInvalid
+		if errors.Is(err, context.Canceled) {
+			result.ErrorClass = models.ErrorCanceled
+		}
+		result.Duration = time.Since(startTime)
+		return result
+	}
+	if reason != "" {
+		result.SkipReason = reason
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to create request: %w", err)
+		result.ErrorClass = models.ErrorInvalid
 		result.Duration = time.Since(startTime)
 		return result
 	}
@@ -60,49 +147,272 @@ func (s *Scraper) ScrapeURL(ctx context.Context, url string) *models.ScrapeResul
 	req.Header.Set("User-Agent", s.userAgent)
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	if since != nil {
+		if since.ETag != "" {
+			req.Header.Set("If-None-Match", since.ETag)
+		}
+		if since.LastModified != "" {
+			req.Header.Set("If-Modified-Since", since.LastModified)
+		}
+	}
+
+	// Perform request; redirects to other hosts hold their slot until done
+	redirects := &redirectState{}
+	req = req.WithContext(context.WithValue(ctx, redirectKey{}, redirects))
+	defer func() {
+		for _, host := range redirects.hosts {
+			s.hosts.Release(host)
+		}
+	}()
 
-	// Perform request
 	resp, err := s.client.Do(req)
 	if err != nil {
+		// A redirect that fails its checks returns the last response with the error
+		result.ErrorClass = classifyError(err)
+		if resp != nil {
+			result.Redirects = redirectChain(resp)
+		}
 		result.Error = fmt.Errorf("request failed: %w", err)
 		result.Duration = time.Since(startTime)
 		return result
 	}
 	defer resp.Body.Close()
 
+	result.Redirects = redirectChain(resp)
+
+	// A redirect not allowed to be followed is skipped like the URL it leads to
+	if redirects.skipReason != "" {
+		result.SkipReason = redirects.skipReason
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
 	result.StatusCode = resp.StatusCode
+	result.RetryAfter = retryAfter(resp, time.Now())
+	result.ETag = resp.Header.Get("ETag")
+	result.LastModified = resp.Header.Get("Last-Modified")
+
+	// Links on redirected pages are relative to the final URL
+	finalURL := resp.Request.URL.String()
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
+	// The stored copy is still current
+	if resp.StatusCode == http.StatusNotModified && since != nil {
+		result.NotModified = true
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
+	// Check status code; error pages are still read when archiving
+	if resp.StatusCode != http.StatusOK && !s.archive {
 		result.Error = fmt.Errorf("non-OK status code: %d", resp.StatusCode)
+		result.ErrorClass = classifyStatus(resp.StatusCode)
 		result.Duration = time.Since(startTime)
 		return result
 	}
 
 	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
 	if err != nil {
 		result.Error = fmt.Errorf("failed to read response: %w", err)
+		result.ErrorClass = classifyError(err)
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
+	if s.archive {
+		result.Response = rawResponse(url, finalURL, resp, body, startTime)
+		if resp.StatusCode != http.StatusOK {
+			result.Error = fmt.Errorf("non-OK status code: %d", resp.StatusCode)
+			result.ErrorClass = classifyStatus(resp.StatusCode)
+			result.Duration = time.Since(startTime)
+			return result
+		}
+	}
+
+	hash := sha256.Sum256(body)
+	result.ContentHash = hex.EncodeToString(hash[:])
+
+	// Only HTML pages have a title, description and links to follow
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !isHTML(contentType) {
 		result.Duration = time.Since(startTime)
 		return result
 	}
 
 	// Parse HTML
-	title, description, links, err := s.parser.ParseHTML(string(body), url)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		result.Error = fmt.Errorf("failed to parse HTML: %w", err)
+		result.ErrorClass = models.ErrorInvalid
 		result.Duration = time.Since(startTime)
 		return result
 	}
+	title, description, links := s.parser.ParseDocument(doc, finalURL)
 
 	result.Title = title
 	result.Description = description
 	result.Links = links
+	result.Metadata = s.parser.ParseMetadata(doc, finalURL)
+
+	// Extract structured data with the rules of the page's site
+	if site := s.rules.For(finalURL); site != nil {
+		extracted := site.Extract(doc)
+		result.ExtractSite = extracted.Site
+		result.Extracted = extracted.Fields
+		result.MissingFields = extracted.Missing
+	}
+
+	// Visible text, which versions of the page are compared by
+	result.Text = s.parser.ExtractText(doc)
+
 	result.Duration = time.Since(startTime)
 
 	return result
 }
 
+// checkRobots returns why robots.txt forbids fetching a URL, or an empty
+// reason. The Crawl-delay of the host paces its later requests.
+func (s *Scraper) checkRobots(ctx context.Context, rawURL string) (string, error) {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	rules, err := s.robots.Rules(ctx, u)
+	if err != nil {
+		return "", err
+	}
+	if allowed, reason := rules.Allowed(u); !allowed {
+		return reason, nil
+	}
+
+	if delay := rules.CrawlDelay(); delay > 0 {
+		if delay > maxCrawlDelay {
+			delay = maxCrawlDelay
+		}
+		s.hosts.SetCrawlDelay(u.Host, delay)
+	}
+	return "", nil
+}
+
+// checkRedirect decides whether a redirect is followed. Each hop gets the
+// checks of a URL in its own right: robots.txt and the scope must allow it,
+// and a new host must be ready for a request. A hop they refuse ends the
+// chain at its redirect response, recording why in the request's
+// redirectState.
+func (s *Scraper) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errTooManyRedirects
+	}
+	ctx := req.Context()
+	state, _ := ctx.Value(redirectKey{}).(*redirectState)
+	if state == nil {
+		state = &redirectState{}
+	}
+	target := req.URL.String()
+
+	if s.scope != nil && !s.scope.Allows(target) {
+		state.skipReason = fmt.Sprintf("redirect to %s is out of scope", target)
+		return http.ErrUseLastResponse
+	}
+
+	reason, err := s.checkRobots(ctx, target)
+	if err != nil {
+		return fmt.Errorf("robots.txt check of redirect failed: %w", err)
+	}
+	if reason != "" {
+		state.skipReason = fmt.Sprintf("redirect to %s: %s", target, reason)
+		return http.ErrUseLastResponse
+	}
+
+	host := hostOf(target)
+	if host == hostOf(via[len(via)-1].URL.String()) {
+		return nil
+	}
+	for _, held := range state.hosts {
+		if held == host {
+			return nil
+		}
+	}
+	if err := s.acquireHost(ctx, host); err != nil {
+		return err
+	}
+	state.hosts = append(state.hosts, host)
+	return nil
+}
+
+// acquireHost waits until host allows another request and takes its slot,
+// for at most maxHopWait. A paused host is not waited for.
+func (s *Scraper) acquireHost(ctx context.Context, host string) error {
+	deadline := time.Now().Add(maxHopWait)
+	for {
+		if pause := s.hosts.PausedFor(host); pause > 0 {
+			return fmt.Errorf("%w: %s is paused for %s", errHostUnavailable, host, pause.Round(time.Second))
+		}
+		wait, ok := s.hosts.TryAcquire(host)
+		if ok {
+			return nil
+		}
+		if wait <= 0 {
+			wait = hostPollInterval // At its concurrency cap until a Release
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("%w: %s is busy", errHostUnavailable, host)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// redirectChain returns the redirects a response was reached through. When
+// redirects are not followed, a redirect response is a chain of one hop.
+func redirectChain(resp *http.Response) []models.Redirect {
+	var chain []models.Redirect
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		chain = append([]models.Redirect{{URL: req.URL.String(), StatusCode: req.Response.StatusCode}}, chain...)
+	}
+
+	if location, err := resp.Location(); err == nil && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		chain = append(chain, models.Redirect{URL: location.String(), StatusCode: resp.StatusCode})
+	}
+	return chain
+}
+
+// rawResponse records a response for the archive, under the URL that was
+// requested and with the URL it came from after redirects. The body is
+// stored as the client decoded it, so Content-Length is set to its length.
+func rawResponse(url, finalURL string, resp *http.Response, body []byte, fetchedAt time.Time) *models.RawResponse {
+	header := resp.Header.Clone()
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	var head bytes.Buffer
+	fmt.Fprintf(&head, "%s %s\r\n", resp.Proto, resp.Status)
+	header.Write(&head)
+
+	return &models.RawResponse{
+		URL:        url,
+		FinalURL:   finalURL,
+		StatusCode: resp.StatusCode,
+		Header:     head.String(),
+		Body:       body,
+		FetchedAt:  fetchedAt,
+	}
+}
+
+// isHTML reports whether a Content-Type header denotes an HTML document
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
 // Close closes the scraper and releases resources
 func (s *Scraper) Close() error {
 	// Close idle connections
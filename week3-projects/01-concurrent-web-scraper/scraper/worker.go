@@ -2,55 +2,90 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/user/web-scraper/models"
 	"github.com/user/web-scraper/ratelimiter"
 	"github.com/user/web-scraper/storage"
 )
 
+// leaseMargin is added to the request timeouts a job can take (robots.txt
+// and the page itself) to get how long a worker may hold it
+const leaseMargin = 30 * time.Second
+
+// maxRetryDelay caps the exponential backoff between retries, and
+// maxRetryAfter how long a Retry-After header can hold a URL back
+const (
+	maxRetryDelay = 5 * time.Minute
+	maxRetryAfter = 10 * time.Minute
+)
+
 // WorkerPool manages a pool of workers for concurrent scraping
 type WorkerPool struct {
 	workerCount int
-	jobs        chan models.ScrapeJob
+	frontier    *Frontier
 	results     chan *models.ScrapeResult
 	scraper     *Scraper
 	db          *storage.Database
 	rateLimiter *ratelimiter.RateLimiter
-	wg          sync.WaitGroup
+	hosts       *ratelimiter.HostLimiter
+	crawl       CrawlOptions
+	workers     sync.WaitGroup
+	processor   sync.WaitGroup
+	closeOnce   sync.Once
 	ctx         context.Context
 	cancel      context.CancelFunc
-	stats       *Statistics
+	statsMu     sync.Mutex
+	stats       Statistics
+}
+
+// CrawlOptions controls which links found on scraped pages are followed
+type CrawlOptions struct {
+	MaxDepth int    // Links followed from a seed URL; 0 scrapes only the seeds
+	MaxPages int    // Most URLs scraped in total, seeds included; 0 for no limit
+	Scope    *Scope // Required when MaxDepth is above 0
 }
 
 // Statistics tracks scraping progress
 type Statistics struct {
-	mu              sync.Mutex
-	TotalJobs       int
-	CompletedJobs   int
-	SuccessfulJobs  int
-	FailedJobs      int
-	InProgressJobs  int
-	TotalRetries    int
+	TotalJobs      int
+	CompletedJobs  int
+	SuccessfulJobs int
+	FailedJobs     int
+	SkippedJobs    int // URLs not fetched on purpose, such as those blocked by robots.txt
+	InProgressJobs int
+	TotalRetries   int
+	PermanentFails int // Failures not retried, such as a 404 or an unknown host
+	CircuitBreaks  int // Times a failing host was paused
+	OutOfScope     int // Links not followed because of the scope rules
+	ExtractedJobs  int // Pages with structured data extracted by the rules
+	NewPages       int // Pages seen for the first time
+	ChangedPages   int // Pages whose content changed since the last crawl
+	UnchangedPages int // Pages not modified since the last crawl
 }
 
-// NewWorkerPool creates a new worker pool
-func NewWorkerPool(ctx context.Context, workerCount int, scraper *Scraper, db *storage.Database, rateLimiter *ratelimiter.RateLimiter) *WorkerPool {
+// NewWorkerPool creates a new worker pool. hostLimiter paces each host and
+// rateLimiter caps the overall request rate on top of it.
+func NewWorkerPool(ctx context.Context, workerCount int, scraper *Scraper, db *storage.Database, rateLimiter *ratelimiter.RateLimiter, hostLimiter *ratelimiter.HostLimiter, crawl CrawlOptions) *WorkerPool {
 	workerCtx, cancel := context.WithCancel(ctx)
 
 	return &WorkerPool{
 		workerCount: workerCount,
-		jobs:        make(chan models.ScrapeJob, workerCount*2), // Buffer for efficiency
-		results:     make(chan *models.ScrapeResult, workerCount*2),
+		frontier:    NewFrontier(crawl.MaxPages, hostLimiter, db, 2*scraper.client.Timeout+leaseMargin),
+		results:     make(chan *models.ScrapeResult, workerCount*2), // Buffer for efficiency
 		scraper:     scraper,
 		db:          db,
 		rateLimiter: rateLimiter,
+		hosts:       hostLimiter,
+		crawl:       crawl,
 		ctx:         workerCtx,
 		cancel:      cancel,
-		stats:       &Statistics{},
 	}
 }
 
@@ -60,74 +95,83 @@ func (wp *WorkerPool) Start() {
 
 	// Start workers
 	for i := 0; i < wp.workerCount; i++ {
-		wp.wg.Add(1)
+		wp.workers.Add(1)
 		go wp.worker(i + 1)
 	}
 
 	// Start result processor
-	wp.wg.Add(1)
+	wp.processor.Add(1)
 	go wp.resultProcessor()
 }
 
 // worker is the worker goroutine that processes jobs
 func (wp *WorkerPool) worker(id int) {
-	defer wp.wg.Done()
+	defer wp.workers.Done()
 
 	log.Printf("Worker %d started", id)
 
 	for {
-		select {
-		case <-wp.ctx.Done():
+		job, ok := wp.frontier.Next(wp.ctx)
+		if !ok {
 			log.Printf("Worker %d stopped", id)
 			return
+		}
 
-		case job, ok := <-wp.jobs:
-			if !ok {
-				log.Printf("Worker %d: jobs channel closed", id)
-				return
-			}
+		// Update statistics
+		wp.statsMu.Lock()
+		wp.stats.InProgressJobs++
+		wp.statsMu.Unlock()
 
-			// Update statistics
-			wp.stats.mu.Lock()
-			wp.stats.InProgressJobs++
-			wp.stats.mu.Unlock()
-
-			// Wait for rate limiter
-			if err := wp.rateLimiter.Wait(wp.ctx); err != nil {
-				log.Printf("Worker %d: rate limiter cancelled", id)
-				wp.stats.mu.Lock()
-				wp.stats.InProgressJobs--
-				wp.stats.mu.Unlock()
-				return
-			}
+		// Wait for the overall rate limit; the job's host is already clear
+		if err := wp.rateLimiter.Wait(wp.ctx); err != nil {
+			log.Printf("Worker %d: rate limiter cancelled", id)
+			wp.frontier.Release(job)
+			wp.statsMu.Lock()
+			wp.stats.InProgressJobs--
+			wp.statsMu.Unlock()
+			return
+		}
 
-			// Process the job
-			log.Printf("Worker %d: scraping %s (attempt %d)", id, job.URL, job.RetryCount+1)
-			result := wp.scraper.ScrapeURL(wp.ctx, job.URL)
-			result.RetryCount = job.RetryCount
-
-			// Send result to processor
-			select {
-			case wp.results <- result:
-			case <-wp.ctx.Done():
-				wp.stats.mu.Lock()
-				wp.stats.InProgressJobs--
-				wp.stats.mu.Unlock()
-				return
-			}
+		// Process the job
+		log.Printf("Worker %d: scraping %s (depth %d, attempt %d)", id, job.URL, job.Depth, job.RetryCount+1)
+		// Re-crawls send the validators of the stored copy
+		since := wp.db.GetValidators(job.URL)
+		result := wp.scraper.ScrapeURL(wp.ctx, job.URL, since)
+		wp.frontier.Release(job)
+
+		// A request cut off by stopping is not a result: the job stays in
+		// flight, and Suspend queues it again
+		if wp.ctx.Err() != nil {
+			wp.statsMu.Lock()
+			wp.stats.InProgressJobs--
+			wp.statsMu.Unlock()
+			return
+		}
+		result.RetryCount = job.RetryCount
+		result.Depth = job.Depth
+		result.ParentURL = job.ParentURL
 
-			// Update statistics
-			wp.stats.mu.Lock()
+		// Send result to processor
+		select {
+		case wp.results <- result:
+		case <-wp.ctx.Done():
+			wp.statsMu.Lock()
 			wp.stats.InProgressJobs--
-			wp.stats.CompletedJobs++
-			wp.stats.mu.Unlock()
+			wp.statsMu.Unlock()
+			return
 		}
+
+		// Update statistics
+		wp.statsMu.Lock()
+		wp.stats.InProgressJobs--
+		wp.stats.CompletedJobs++
+		wp.statsMu.Unlock()
 	}
 }
 
 // resultProcessor processes scraping results
 func (wp *WorkerPool) resultProcessor() {
-	defer wp.wg.Done()
+	defer wp.processor.Done()
 
 	log.Println("Result processor started")
 
@@ -143,64 +187,236 @@ func (wp *WorkerPool) resultProcessor() {
 				return
 			}
 
-			// Save to database
-			if err := wp.db.SavePage(result); err != nil {
-				log.Printf("Failed to save page %s: %v", result.URL, err)
+			// Left in flight for Suspend, like the jobs workers drop
+			if result.ErrorClass == models.ErrorCanceled {
+				continue
 			}
 
-			// Update statistics
-			wp.stats.mu.Lock()
-			if result.Error != nil {
-				wp.stats.FailedJobs++
-				log.Printf("✗ Failed: %s - %v", result.URL, result.Error)
-
-				// Retry logic
-				if result.RetryCount < wp.scraper.maxRetries {
-					wp.stats.TotalRetries++
-					wp.stats.mu.Unlock()
-
-					// Re-queue with incremented retry count
-					retryJob := models.ScrapeJob{
-						URL:        result.URL,
-						RetryCount: result.RetryCount + 1,
-					}
-					select {
-					case wp.jobs <- retryJob:
-						log.Printf("↻ Retrying: %s (attempt %d)", result.URL, retryJob.RetryCount+1)
-					case <-wp.ctx.Done():
-						return
-					}
-					continue
-				} else {
-					log.Printf("✗ Max retries reached for: %s", result.URL)
-				}
-			} else {
-				wp.stats.SuccessfulJobs++
-				log.Printf("✓ Success: %s (status: %d, title: %s, links: %d, duration: %s)",
-					result.URL, result.StatusCode, truncate(result.Title, 50),
-					len(result.Links), result.Duration)
-			}
-			wp.stats.mu.Unlock()
+			state := wp.processResult(result)
+
+			// Retries and discovered links are queued by now
+			wp.frontier.Done(result.URL, state)
 		}
 	}
 }
 
-// AddJob adds a job to the queue
-func (wp *WorkerPool) AddJob(url string) {
-	wp.stats.mu.Lock()
-	wp.stats.TotalJobs++
-	wp.stats.mu.Unlock()
+// processResult saves a result and queues its retry or the links it found.
+// It returns the frontier state the URL ends up in.
+func (wp *WorkerPool) processResult(result *models.ScrapeResult) string {
+	// Save to database
+	change, err := wp.db.SavePage(result)
+	if err != nil {
+		log.Printf("Failed to save page %s: %v", result.URL, err)
+	}
+
+	if result.SkipReason != "" {
+		wp.statsMu.Lock()
+		wp.stats.SkippedJobs++
+		wp.statsMu.Unlock()
+		log.Printf("⊘ Skipped: %s - %s", result.URL, result.SkipReason)
+		return models.FrontierDone
+	}
+
+	wp.trackHost(result)
+
+	if result.Error != nil {
+		retryable := models.IsRetryable(result.ErrorClass)
+		retry := retryable && result.RetryCount < wp.scraper.maxRetries
+		wp.statsMu.Lock()
+		wp.stats.FailedJobs++
+		if retry {
+			wp.stats.TotalRetries++
+		}
+		if !retryable {
+			wp.stats.PermanentFails++
+		}
+		wp.statsMu.Unlock()
+		log.Printf("✗ Failed: %s - %v (%s)", result.URL, result.Error, result.ErrorClass)
+
+		if !retryable {
+			log.Printf("✗ Not retrying %s: %s errors are permanent", result.URL, result.ErrorClass)
+			return models.FrontierFailed
+		}
+		if !retry {
+			log.Printf("✗ Max retries reached for: %s", result.URL)
+			return models.FrontierFailed
+		}
+
+		// Re-queue with incremented retry count, after a backoff
+		delay := wp.retryDelay(result)
+		wp.frontier.Retry(models.ScrapeJob{
+			URL:        result.URL,
+			RetryCount: result.RetryCount + 1,
+			Depth:      result.Depth,
+			ParentURL:  result.ParentURL,
+			Priority:   priority(result.Depth),
+			NotBefore:  time.Now().Add(delay),
+		})
+		log.Printf("↻ Retrying: %s (attempt %d) in %s", result.URL, result.RetryCount+2, delay.Round(time.Millisecond))
+		return models.FrontierQueued
+	}
+
+	wp.statsMu.Lock()
+	wp.stats.SuccessfulJobs++
+	switch change {
+	case models.ChangeNew:
+		wp.stats.NewPages++
+	case models.ChangeChanged:
+		wp.stats.ChangedPages++
+	case models.ChangeUnchanged:
+		wp.stats.UnchangedPages++
+	}
+	wp.statsMu.Unlock()
+
+	// An unchanged page still leads to the links stored with it
+	if result.NotModified {
+		links, err := wp.db.GetLinksByURL(result.URL)
+		if err != nil {
+			log.Printf("Failed to load links of %s: %v", result.URL, err)
+		}
+		result.Links = links
+		log.Printf("✓ Not modified: %s (status: %d, links: %d, duration: %s)",
+			result.URL, result.StatusCode, len(result.Links), result.Duration)
+	} else {
+		log.Printf("✓ Success: %s (status: %d, title: %s, links: %d, duration: %s)",
+			result.URL, result.StatusCode, truncate(result.Title, 50),
+			len(result.Links), result.Duration)
+	}
+	if change == models.ChangeChanged {
+		log.Printf("✎ Changed since the last crawl: %s", result.URL)
+	}
+
+	if result.ExtractSite != "" {
+		wp.statsMu.Lock()
+		wp.stats.ExtractedJobs++
+		wp.statsMu.Unlock()
+		log.Printf("⛏ Extracted %d fields from %s (%s rules)", len(result.Extracted), result.URL, result.ExtractSite)
+		if len(result.MissingFields) > 0 {
+			log.Printf("⚠ Missing required fields on %s: %s", result.URL, strings.Join(result.MissingFields, ", "))
+		}
+	}
+
+	wp.followLinks(result)
+	return models.FrontierDone
+}
+
+// retryDelay returns how long to wait before retrying a failed result: an
+// exponential backoff with jitter, or longer if the server asked for it
+func (wp *WorkerPool) retryDelay(result *models.ScrapeResult) time.Duration {
+	backoff := wp.scraper.retryDelay << result.RetryCount
+	if backoff > maxRetryDelay || backoff <= 0 {
+		backoff = maxRetryDelay
+	}
+	// Half fixed, half random, so failed URLs do not all come back at once
+	delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+	return max(delay, min(result.RetryAfter, maxRetryAfter))
+}
+
+// trackHost feeds the outcome of a request to its host's circuit breaker,
+// and pauses the host when a response asks for it with Retry-After
+func (wp *WorkerPool) trackHost(result *models.ScrapeResult) {
+	host := hostOf(result.URL)
+
+	if result.RetryAfter > 0 {
+		wp.hosts.Pause(host, min(result.RetryAfter, maxRetryAfter))
+	}
+
+	switch {
+	case result.SkipReason != "":
+		// Not fetched, so nothing is known about the host
+	case errors.Is(result.Error, errHostUnavailable):
+		// The host answered with a redirect to another host that is not ready
+		wp.hosts.Success(host)
+	case result.Error != nil && models.IsRetryable(result.ErrorClass):
+		if pause := wp.hosts.Failure(host); pause > 0 {
+			wp.statsMu.Lock()
+			wp.stats.CircuitBreaks++
+			wp.statsMu.Unlock()
+			log.Printf("⚠ Too many failures on %s: pausing the host for %s", host, pause)
+		}
+	case result.StatusCode != 0 || result.Error == nil:
+		wp.hosts.Success(host) // The host answered
+	}
+}
+
+// followLinks queues the in-scope links of a scraped page one level deeper
+func (wp *WorkerPool) followLinks(result *models.ScrapeResult) {
+	if result.Depth >= wp.crawl.MaxDepth {
+		return
+	}
+
+	queued, outOfScope := 0, 0
+	for _, link := range result.Links {
+		if !wp.crawl.Scope.Allows(link.URL) {
+			outOfScope++
+			continue
+		}
+		job := models.ScrapeJob{
+			URL:       link.URL,
+			Depth:     result.Depth + 1,
+			ParentURL: result.URL,
+			Priority:  priority(result.Depth + 1),
+		}
+		if wp.frontier.Add(job) {
+			queued++
+		}
+	}
+
+	wp.statsMu.Lock()
+	wp.stats.TotalJobs += queued
+	wp.stats.OutOfScope += outOfScope
+	wp.statsMu.Unlock()
 
-	job := models.ScrapeJob{
-		URL:        url,
-		RetryCount: 0,
+	if queued > 0 {
+		log.Printf("↳ Queued %d new links from %s (depth %d)", queued, result.URL, result.Depth+1)
 	}
+}
+
+// priority returns the frontier priority of a URL at a depth: shallower pages
+// go first, so a page budget is spent on the top of each site
+func priority(depth int) int {
+	return -depth
+}
+
+// Reset forgets the frontier of the previous run. Call it, or Resume, before
+// Start.
+func (wp *WorkerPool) Reset() error {
+	return wp.frontier.Reset()
+}
 
-	select {
-	case wp.jobs <- job:
-	case <-wp.ctx.Done():
-		log.Printf("Cannot add job for %s: context cancelled", url)
+// Resume restores the frontier of an interrupted run and returns the number
+// of URLs it had left to scrape
+func (wp *WorkerPool) Resume() (int, error) {
+	left, err := wp.frontier.Resume(wp.ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resume frontier: %w", err)
 	}
+
+	wp.statsMu.Lock()
+	wp.stats.TotalJobs += left
+	wp.statsMu.Unlock()
+
+	return left, nil
+}
+
+// Visited returns the number of URLs admitted to the frontier, including
+// those of a resumed run
+func (wp *WorkerPool) Visited() int {
+	return wp.frontier.Visited()
+}
+
+// AddJob adds a seed URL to the queue. URLs that are invalid, duplicates or
+// over the page budget are skipped.
+func (wp *WorkerPool) AddJob(url string) {
+	if !wp.frontier.Add(models.ScrapeJob{URL: url}) {
+		log.Printf("Skipping %s: invalid, duplicate or over the page budget", url)
+		return
+	}
+
+	wp.statsMu.Lock()
+	wp.stats.TotalJobs++
+	wp.statsMu.Unlock()
 }
 
 // AddJobs adds multiple jobs to the queue
@@ -210,16 +426,23 @@ func (wp *WorkerPool) AddJobs(urls []string) {
 	}
 }
 
-// Wait waits for all jobs to complete
+// Wait waits until all jobs, and the links they lead to, are done or the pool
+// is cancelled. No seed URLs can be added afterwards.
 func (wp *WorkerPool) Wait() {
-	// Close jobs channel to signal workers to finish
-	close(wp.jobs)
+	// Signal workers to finish once the frontier runs dry
+	wp.frontier.Close()
 
 	// Wait for all workers to finish
-	wp.wg.Wait()
+	wp.workers.Wait()
+
+	// Close results channel and let the processor drain it
+	wp.closeOnce.Do(func() { close(wp.results) })
+	wp.processor.Wait()
 
-	// Close results channel
-	close(wp.results)
+	// Unfinished jobs of a cancelled run are left for -resume
+	if wp.ctx.Err() != nil {
+		wp.frontier.Suspend()
+	}
 }
 
 // Stop stops the worker pool
@@ -231,9 +454,9 @@ func (wp *WorkerPool) Stop() {
 
 // GetStatistics returns current statistics
 func (wp *WorkerPool) GetStatistics() Statistics {
-	wp.stats.mu.Lock()
-	defer wp.stats.mu.Unlock()
-	return *wp.stats
+	wp.statsMu.Lock()
+	defer wp.statsMu.Unlock()
+	return wp.stats
 }
 
 // PrintStatistics prints current statistics
@@ -246,8 +469,24 @@ func (wp *WorkerPool) PrintStatistics() {
 	fmt.Printf("Completed:        %d\n", stats.CompletedJobs)
 	fmt.Printf("Successful:       %d\n", stats.SuccessfulJobs)
 	fmt.Printf("Failed:           %d\n", stats.FailedJobs)
+	fmt.Printf("Skipped:          %d\n", stats.SkippedJobs)
 	fmt.Printf("In Progress:      %d\n", stats.InProgressJobs)
 	fmt.Printf("Total Retries:    %d\n", stats.TotalRetries)
+	fmt.Printf("Not Retried:      %d\n", stats.PermanentFails)
+	if stats.CircuitBreaks > 0 {
+		fmt.Printf("Circuit Breaks:   %d\n", stats.CircuitBreaks)
+	}
+	if wp.crawl.MaxDepth > 0 {
+		fmt.Printf("Out of Scope:     %d\n", stats.OutOfScope)
+	}
+	if wp.scraper.rules != nil {
+		fmt.Printf("Extracted:        %d\n", stats.ExtractedJobs)
+	}
+	if stats.ChangedPages+stats.UnchangedPages > 0 {
+		fmt.Printf("New Pages:        %d\n", stats.NewPages)
+		fmt.Printf("Changed:          %d\n", stats.ChangedPages)
+		fmt.Printf("Unchanged:        %d\n", stats.UnchangedPages)
+	}
 	fmt.Println(strings.Repeat("=", 60))
 }
 
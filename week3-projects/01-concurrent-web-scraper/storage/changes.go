@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/user/web-scraper/models"
+	"gorm.io/gorm"
+)
+
+// StartRun starts a new run, or continues the last one when resuming, and
+// tags the pages saved from now on with it
+func (d *Database) StartRun(resume bool) (*models.CrawlRun, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var run models.CrawlRun
+	if resume {
+		err := d.db.Order("id DESC").First(&run).Error
+		if err == nil {
+			d.runID = run.ID
+			return &run, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to load last run: %w", err)
+		}
+	}
+
+	run = models.CrawlRun{StartedAt: time.Now()}
+	if err := d.db.Create(&run).Error; err != nil {
+		return nil, fmt.Errorf("failed to create run: %w", err)
+	}
+
+	d.runID = run.ID
+	return &run, nil
+}
+
+// FinishRun marks the current run as complete
+func (d *Database) FinishRun() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.db.Model(&models.CrawlRun{}).Where("id = ?", d.runID).Update("finished_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to finish run: %w", err)
+	}
+	return nil
+}
+
+// GetValidators retrieves the cache validators of a stored page, or nil if
+// there is no stored copy to fall back on. After a failed fetch the page's
+// content is gone from its row, so it is fetched in full again.
+func (d *Database) GetValidators(url string) *models.Validators {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var page models.ScrapedPage
+	err := d.db.Select("etag", "last_modified").
+		Where("url = ? AND version > 0 AND error = '' AND skip_reason = '' AND (etag != '' OR last_modified != '')", url).
+		First(&page).Error
+	if err != nil {
+		return nil
+	}
+
+	return &models.Validators{ETag: page.ETag, LastModified: page.LastModified}
+}
+
+// GetLinksByURL retrieves the links stored for a page
+func (d *Database) GetLinksByURL(url string) ([]models.LinkData, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var links []models.LinkData
+	err := d.db.Model(&models.Link{}).
+		Select("links.url AS url, links.text AS text").
+		Joins("JOIN scraped_pages ON scraped_pages.id = links.page_id").
+		Where("scraped_pages.url = ? AND scraped_pages.deleted_at IS NULL", url).
+		Order("links.id ASC").
+		Scan(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links of %s: %w", url, err)
+	}
+
+	return links, nil
+}
+
+// GetChangeReport lists what changed in the last run: pages seen for the
+// first time, pages with a new version and known pages that failed
+func (d *Database) GetChangeReport() (*models.ChangeReport, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	report := &models.ChangeReport{}
+	if err := d.db.Order("id DESC").First(&report.Run).Error; err != nil {
+		return nil, fmt.Errorf("no runs recorded yet: %w", err)
+	}
+	runID := report.Run.ID
+
+	if err := d.db.Where("run_id = ? AND version = 1", runID).Order("url ASC").Find(&report.New).Error; err != nil {
+		return nil, fmt.Errorf("failed to get new pages: %w", err)
+	}
+	if err := d.db.Where("run_id = ? AND version > 1", runID).Order("url ASC").Find(&report.Changed).Error; err != nil {
+		return nil, fmt.Errorf("failed to get changed pages: %w", err)
+	}
+
+	var unchanged int64
+	err := d.db.Model(&models.ScrapedPage{}).
+		Where("run_id = ? AND change_status = ? AND error = ''", runID, models.ChangeUnchanged).
+		Count(&unchanged).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unchanged pages: %w", err)
+	}
+	report.Unchanged = int(unchanged)
+
+	err = d.db.Where("run_id = ? AND version > 0 AND error != ''", runID).Order("url ASC").Find(&report.Failing).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failing pages: %w", err)
+	}
+
+	return report, nil
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -8,16 +9,19 @@ import (
 	"sync"
 	"time"
 
+	"github.com/user/web-scraper/changes"
 	"github.com/user/web-scraper/models"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
 // Database handles all database operations
 type Database struct {
-	db *gorm.DB
-	mu sync.RWMutex
+	db    *gorm.DB
+	mu    sync.RWMutex
+	runID uint // Run the saved pages belong to
 }
 
 // NewDatabase creates a new database connection
@@ -37,7 +41,7 @@ func NewDatabase(dbPath string) (*Database, error) {
 	}
 
 	// Auto migrate the schema
-	if err := db.AutoMigrate(&models.ScrapedPage{}, &models.Link{}); err != nil {
+	if err := db.AutoMigrate(&models.ScrapedPage{}, &models.Link{}, &models.FrontierURL{}, &models.PageVersion{}, &models.CrawlRun{}, &models.RawResponse{}); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
@@ -46,64 +50,211 @@ func NewDatabase(dbPath string) (*Database, error) {
 	return &Database{db: db}, nil
 }
 
-// SavePage saves a scraped page to the database
-func (d *Database) SavePage(result *models.ScrapeResult) error {
+// SavePage saves a scraped page to the database and returns how its content
+// changed since the last version: new, changed or unchanged. A changed page
+// gets a new version with a summary of the differences. Failed and skipped
+// pages keep the content, validators and history of their last success, and
+// return an empty change status.
+func (d *Database) SavePage(result *models.ScrapeResult) (string, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	// Create the page record
-	page := models.ScrapedPage{
-		URL:         result.URL,
-		Title:       result.Title,
-		Description: result.Description,
-		LinkCount:   len(result.Links),
-		StatusCode:  result.StatusCode,
-		RetryCount:  result.RetryCount,
-		ScrapedAt:   result.ScrapedAt,
-		Duration:    result.Duration.Milliseconds(),
-	}
+	var change string
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.ScrapedPage
+		found := tx.Where("url = ?", result.URL).First(&existing).Error == nil
 
-	if result.Error != nil {
-		page.Error = result.Error.Error()
-	}
+		// A 304 confirms the stored copy: only the crawl details change
+		if result.NotModified && found {
+			change = models.ChangeUnchanged
+			return d.saveUnchanged(tx, &existing, result)
+		}
 
-	// Use FirstOrCreate to avoid duplicates
-	var existingPage models.ScrapedPage
-	if err := d.db.Where("url = ?", page.URL).First(&existingPage).Error; err == nil {
-		// Update existing page
-		page.ID = existingPage.ID
-		if err := d.db.Save(&page).Error; err != nil {
-			return fmt.Errorf("failed to update page: %w", err)
+		// Create the page record
+		page := models.ScrapedPage{
+			URL:         result.URL,
+			Title:       result.Title,
+			Description: result.Description,
+			LinkCount:   len(result.Links),
+			StatusCode:  result.StatusCode,
+			SkipReason:  result.SkipReason,
+			RetryCount:  result.RetryCount,
+			Depth:       result.Depth,
+			ParentURL:   result.ParentURL,
+			ExtractSite: result.ExtractSite,
+			RunID:       d.runID,
+			ScrapedAt:   result.ScrapedAt,
+			Duration:    result.Duration.Milliseconds(),
 		}
 
-		// Delete old links
-		d.db.Where("page_id = ?", page.ID).Delete(&models.Link{})
-	} else {
-		// Create new page
-		if err := d.db.Create(&page).Error; err != nil {
-			return fmt.Errorf("failed to create page: %w", err)
+		if result.Error != nil {
+			page.Error = result.Error.Error()
+			page.ErrorClass = result.ErrorClass
 		}
-	}
 
-	// Save links
-	if len(result.Links) > 0 {
-		links := make([]models.Link, len(result.Links))
-		for i, linkData := range result.Links {
-			links[i] = models.Link{
-				PageID: page.ID,
-				URL:    linkData.URL,
-				Text:   linkData.Text,
+		if len(result.Redirects) > 0 {
+			redirects, err := json.Marshal(result.Redirects)
+			if err != nil {
+				return fmt.Errorf("failed to encode redirects: %w", err)
 			}
+			page.Redirects = string(redirects)
 		}
 
-		if err := d.db.Create(&links).Error; err != nil {
-			return fmt.Errorf("failed to create links: %w", err)
+		if result.Metadata != nil && !result.Metadata.IsEmpty() {
+			metadata, err := json.Marshal(result.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to encode metadata: %w", err)
+			}
+			page.Canonical = result.Metadata.Canonical
+			page.Metadata = string(metadata)
+		}
+
+		if result.ExtractSite != "" {
+			extracted, err := json.Marshal(result.Extracted)
+			if err != nil {
+				return fmt.Errorf("failed to encode extracted fields: %w", err)
+			}
+			page.Extracted = string(extracted)
 		}
+
+		if found {
+			page.ID = existing.ID
+			page.CreatedAt = existing.CreatedAt
+			page.ETag, page.LastModified = existing.ETag, existing.LastModified
+			page.ContentHash, page.Version = existing.ContentHash, existing.Version
+			page.ChangeStatus, page.ChangedAt = existing.ChangeStatus, existing.ChangedAt
+		}
+
+		if result.Error == nil && result.SkipReason == "" {
+			var err error
+			if change, err = d.saveVersion(tx, &page, result); err != nil {
+				return err
+			}
+		}
+
+		// Use FirstOrCreate to avoid duplicates
+		if found {
+			// Update existing page
+			if err := tx.Save(&page).Error; err != nil {
+				return fmt.Errorf("failed to update page: %w", err)
+			}
+
+			// Delete old links
+			if err := tx.Where("page_id = ?", page.ID).Delete(&models.Link{}).Error; err != nil {
+				return fmt.Errorf("failed to delete links: %w", err)
+			}
+		} else {
+			// Create new page
+			if err := tx.Create(&page).Error; err != nil {
+				return fmt.Errorf("failed to create page: %w", err)
+			}
+		}
+
+		// Keep the latest raw response of the URL for the archive
+		if result.Response != nil {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "url"}},
+				DoUpdates: clause.AssignmentColumns([]string{"final_url", "status_code", "header", "body", "fetched_at"}),
+			}).Create(result.Response).Error
+			if err != nil {
+				return fmt.Errorf("failed to save response: %w", err)
+			}
+		}
+
+		// Save links
+		if len(result.Links) > 0 {
+			links := make([]models.Link, len(result.Links))
+			for i, linkData := range result.Links {
+				links[i] = models.Link{
+					PageID: page.ID,
+					URL:    linkData.URL,
+					Text:   linkData.Text,
+				}
+			}
+
+			if err := tx.Create(&links).Error; err != nil {
+				return fmt.Errorf("failed to create links: %w", err)
+			}
+		}
+
+		return nil
+	})
+
+	return change, err
+}
+
+// saveUnchanged records a 304 answer for a stored page, keeping its content
+// and links
+func (d *Database) saveUnchanged(tx *gorm.DB, page *models.ScrapedPage, result *models.ScrapeResult) error {
+	// A 304 may carry updated validators
+	if result.ETag != "" {
+		page.ETag = result.ETag
+	}
+	if result.LastModified != "" {
+		page.LastModified = result.LastModified
 	}
 
+	page.Error = ""
+	page.ErrorClass = ""
+	page.SkipReason = ""
+	page.RetryCount = result.RetryCount
+	page.Depth = result.Depth
+	page.ParentURL = result.ParentURL
+	page.ChangeStatus = models.ChangeUnchanged
+	page.RunID = d.runID
+	page.ScrapedAt = result.ScrapedAt
+	page.Duration = result.Duration.Milliseconds()
+
+	if err := tx.Save(page).Error; err != nil {
+		return fmt.Errorf("failed to update page: %w", err)
+	}
 	return nil
 }
 
+// saveVersion compares a successful fetch with the last version of the page
+// and stores a new version if the content changed. It updates the change
+// detection fields of page and returns the change status.
+func (d *Database) saveVersion(tx *gorm.DB, page *models.ScrapedPage, result *models.ScrapeResult) (string, error) {
+	page.ETag = result.ETag
+	page.LastModified = result.LastModified
+	page.ContentHash = result.ContentHash
+
+	var last models.PageVersion
+	hasLast := tx.Where("url = ?", result.URL).Order("version DESC").First(&last).Error == nil
+
+	if hasLast && last.ContentHash == result.ContentHash {
+		page.Version = last.Version
+		page.ChangeStatus = models.ChangeUnchanged
+		return models.ChangeUnchanged, nil
+	}
+
+	version := models.PageVersion{
+		URL:         result.URL,
+		Version:     1,
+		RunID:       d.runID,
+		ContentHash: result.ContentHash,
+		Title:       result.Title,
+		Text:        result.Text,
+		ScrapedAt:   result.ScrapedAt,
+	}
+	change := models.ChangeNew
+	if hasLast {
+		version.Version = last.Version + 1
+		version.DiffSummary = changes.Compare(last.Title, last.Text, result.Title, result.Text).String()
+		change = models.ChangeChanged
+	}
+
+	if err := tx.Create(&version).Error; err != nil {
+		return "", fmt.Errorf("failed to create page version: %w", err)
+	}
+
+	changedAt := result.ScrapedAt
+	page.Version = version.Version
+	page.ChangeStatus = change
+	page.ChangedAt = &changedAt
+	return change, nil
+}
+
 // GetPage retrieves a page by URL
 func (d *Database) GetPage(url string) (*models.ScrapedPage, error) {
 	d.mu.RLock()
@@ -162,9 +313,44 @@ func (d *Database) GetStatistics() (*models.Statistics, error) {
 
 	// Count failed pages
 	var failedPages int64
-	d.db.Model(&models.ScrapedPage{}).Where("status_code != ? OR error != ?", 200, "").Count(&failedPages)
+	d.db.Model(&models.ScrapedPage{}).Where("(status_code != ? OR error != ?) AND skip_reason = ?", 200, "", "").Count(&failedPages)
 	stats.FailedPages = int(failedPages)
 
+	// Count failed pages by error class
+	var classes []struct {
+		ErrorClass string
+		Pages      int
+	}
+	err := d.db.Model(&models.ScrapedPage{}).Select("error_class, COUNT(*) AS pages").
+		Where("error != ? AND error_class != ?", "", "").Group("error_class").Scan(&classes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count error classes: %w", err)
+	}
+	stats.ErrorClasses = make(map[string]int, len(classes))
+	for _, class := range classes {
+		stats.ErrorClasses[class.ErrorClass] = class.Pages
+	}
+
+	// Count pages skipped on purpose, such as those blocked by robots.txt
+	var skippedPages int64
+	d.db.Model(&models.ScrapedPage{}).Where("skip_reason != ?", "").Count(&skippedPages)
+	stats.SkippedPages = int(skippedPages)
+
+	// Count pages with structured data extracted by the rules
+	var extractedPages int64
+	d.db.Model(&models.ScrapedPage{}).Where("extract_site != ?", "").Count(&extractedPages)
+	stats.ExtractedPages = int(extractedPages)
+
+	// Count pages declaring each kind of metadata
+	if err := d.metadataStatistics(&stats.Metadata); err != nil {
+		return nil, err
+	}
+
+	// Count URLs an interrupted run left to scrape
+	var queuedURLs int64
+	d.db.Model(&models.FrontierURL{}).Where("state IN ?", []string{models.FrontierQueued, models.FrontierInFlight}).Count(&queuedURLs)
+	stats.QueuedURLs = int(queuedURLs)
+
 	// Count total links
 	var totalLinks int64
 	d.db.Model(&models.Link{}).Count(&totalLinks)
@@ -195,7 +381,62 @@ func (d *Database) GetStatistics() (*models.Statistics, error) {
 	return stats, nil
 }
 
-// DeleteAllPages deletes all scraped pages and their links
+// metadataStatistics counts the pages declaring each kind of metadata and
+// each schema.org type. Pages without metadata store an empty string, which
+// is not JSON, so they are left out before any JSON function sees them.
+func (d *Database) metadataStatistics(stats *models.MetadataStats) error {
+	var counts struct {
+		Canonical int
+		Hreflang  int
+		OpenGraph int
+		Twitter   int
+		JSONLD    int
+		Microdata int
+	}
+	has := func(path string) string {
+		return fmt.Sprintf("COALESCE(SUM(CASE WHEN metadata = '' THEN 0 WHEN json_type(metadata, '%s') IS NOT NULL THEN 1 ELSE 0 END), 0)", path)
+	}
+	err := d.db.Model(&models.ScrapedPage{}).Select(
+		has("$.canonical") + " AS canonical, " +
+			has("$.hreflang") + " AS hreflang, " +
+			has("$.open_graph") + " AS open_graph, " +
+			has("$.twitter") + " AS twitter, " +
+			has("$.json_ld") + " AS json_ld, " +
+			has("$.microdata") + " AS microdata",
+	).Scan(&counts).Error
+	if err != nil {
+		return fmt.Errorf("failed to count metadata: %w", err)
+	}
+
+	stats.Canonical = counts.Canonical
+	stats.Hreflang = counts.Hreflang
+	stats.OpenGraph = counts.OpenGraph
+	stats.Twitter = counts.Twitter
+	stats.JSONLD = counts.JSONLD
+	stats.Microdata = counts.Microdata
+
+	var types []struct {
+		Type  string
+		Pages int
+	}
+	err = d.db.Raw(`SELECT types.value AS type, COUNT(*) AS pages
+		FROM (SELECT metadata FROM scraped_pages WHERE metadata != '' AND deleted_at IS NULL) AS pages,
+			json_each(pages.metadata, '$.types') AS types
+		GROUP BY types.value`).Scan(&types).Error
+	if err != nil {
+		return fmt.Errorf("failed to count schema types: %w", err)
+	}
+
+	stats.SchemaTypes = make(map[string]int, len(types))
+	for _, t := range types {
+		stats.SchemaTypes[t.Type] = t.Pages
+	}
+
+	return nil
+}
+
+// DeleteAllPages deletes all scraped pages, their links, history and
+// archived responses, and the frontier
 func (d *Database) DeleteAllPages() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -210,6 +451,24 @@ func (d *Database) DeleteAllPages() error {
 		return fmt.Errorf("failed to delete pages: %w", err)
 	}
 
+	// Delete the frontier of the last run
+	if err := d.db.Exec("DELETE FROM frontier_urls").Error; err != nil {
+		return fmt.Errorf("failed to delete frontier: %w", err)
+	}
+
+	// Delete the version history and runs
+	if err := d.db.Exec("DELETE FROM page_versions").Error; err != nil {
+		return fmt.Errorf("failed to delete page versions: %w", err)
+	}
+	if err := d.db.Exec("DELETE FROM crawl_runs").Error; err != nil {
+		return fmt.Errorf("failed to delete runs: %w", err)
+	}
+
+	// Delete the archived responses
+	if err := d.db.Exec("DELETE FROM raw_responses").Error; err != nil {
+		return fmt.Errorf("failed to delete responses: %w", err)
+	}
+
 	log.Println("All data deleted from database")
 	return nil
 }
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/user/web-scraper/models"
+	"gorm.io/gorm"
+)
+
+// EachPage calls fn with every page the filter selects, in ID order. Pages
+// are read one row at a time, so exports of any size run in constant memory.
+func (d *Database) EachPage(filter *models.PageFilter, fn func(*models.ScrapedPage) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query, err := filterPages(d.db.Model(&models.ScrapedPage{}), filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := query.Order("scraped_pages.id").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query pages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var page models.ScrapedPage
+		if err := d.db.ScanRows(rows, &page); err != nil {
+			return fmt.Errorf("failed to read page: %w", err)
+		}
+		if err := fn(&page); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachLink calls fn with every link found on the pages the filter selects,
+// grouped by page
+func (d *Database) EachLink(filter *models.PageFilter, fn func(*models.PageLink) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query := d.db.Table("links").
+		Select("scraped_pages.url AS page_url, links.url, links.text").
+		Joins("JOIN scraped_pages ON scraped_pages.id = links.page_id AND scraped_pages.deleted_at IS NULL")
+	query, err := filterPages(query, filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := query.Order("links.page_id, links.id").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link models.PageLink
+		if err := rows.Scan(&link.PageURL, &link.URL, &link.Text); err != nil {
+			return fmt.Errorf("failed to read link: %w", err)
+		}
+		if err := fn(&link); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachResponse calls fn with the archived response of every page the filter
+// selects, in the order they were fetched
+func (d *Database) EachResponse(filter *models.PageFilter, fn func(*models.RawResponse) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query := d.db.Table("raw_responses").
+		Select("raw_responses.*").
+		Joins("JOIN scraped_pages ON scraped_pages.url = raw_responses.url AND scraped_pages.deleted_at IS NULL")
+	query, err := filterPages(query, filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := query.Order("raw_responses.fetched_at, raw_responses.id").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var response models.RawResponse
+		if err := d.db.ScanRows(rows, &response); err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		if err := fn(&response); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// filterPages adds the conditions of a filter on the scraped_pages table to
+// a query
+func filterPages(query *gorm.DB, filter *models.PageFilter) (*gorm.DB, error) {
+	if filter == nil {
+		return query, nil
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch filter.Status {
+	case "":
+	case "success":
+		query = query.Where("scraped_pages.status_code = ? AND scraped_pages.error = ?", 200, "")
+	case "failed":
+		query = query.Where("(scraped_pages.status_code != ? OR scraped_pages.error != ?) AND scraped_pages.skip_reason = ?", 200, "", "")
+	case "skipped":
+		query = query.Where("scraped_pages.skip_reason != ?", "")
+	default:
+		code, _ := strconv.Atoi(filter.Status)
+		query = query.Where("scraped_pages.status_code = ?", code)
+	}
+
+	// The host ends at the first "/", "?" or "#" after the scheme
+	if filter.Host != "" {
+		host := escapeLike(strings.ToLower(filter.Host))
+		var conditions []string
+		var args []interface{}
+		for _, scheme := range []string{"http://", "https://"} {
+			for _, end := range []string{"", "/%", "?%", "#%"} {
+				conditions = append(conditions, `LOWER(scraped_pages.url) LIKE ? ESCAPE '\'`)
+				args = append(args, scheme+host+end)
+			}
+		}
+		query = query.Where(strings.Join(conditions, " OR "), args...)
+	}
+
+	if !filter.Since.IsZero() {
+		query = query.Where("scraped_pages.scraped_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("scraped_pages.scraped_at < ?", filter.Until)
+	}
+
+	return query, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
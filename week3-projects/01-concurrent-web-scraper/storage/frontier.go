@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/user/web-scraper/models"
+	"gorm.io/gorm/clause"
+)
+
+// LoadFrontier retrieves every URL of the persisted frontier in the order
+// they were queued
+func (d *Database) LoadFrontier() ([]models.FrontierURL, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var urls []models.FrontierURL
+	if err := d.db.Order("id ASC").Find(&urls).Error; err != nil {
+		return nil, fmt.Errorf("failed to load frontier: %w", err)
+	}
+
+	return urls, nil
+}
+
+// ResetFrontier forgets the frontier of the previous run
+func (d *Database) ResetFrontier() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.db.Exec("DELETE FROM frontier_urls").Error; err != nil {
+		return fmt.Errorf("failed to reset frontier: %w", err)
+	}
+
+	return nil
+}
+
+// EnqueueURL adds a URL to the frontier as queued. URLs already in the
+// frontier are left as they are.
+func (d *Database) EnqueueURL(job models.ScrapeJob) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry := models.FrontierURL{
+		URL:        job.URL,
+		State:      models.FrontierQueued,
+		Priority:   job.Priority,
+		RetryCount: job.RetryCount,
+		Depth:      job.Depth,
+		ParentURL:  job.ParentURL,
+	}
+
+	if err := d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", job.URL, err)
+	}
+
+	return nil
+}
+
+// LeaseURL marks a URL as in flight until the lease expires
+func (d *Database) LeaseURL(url string, expires time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := d.db.Model(&models.FrontierURL{}).Where("url = ?", url).Updates(map[string]interface{}{
+		"state":         models.FrontierInFlight,
+		"lease_expires": expires,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to lease %s: %w", url, err)
+	}
+
+	return nil
+}
+
+// RequeueURL puts a URL back in the queue for another attempt, not before
+// the job's NotBefore time if it has one
+func (d *Database) RequeueURL(job models.ScrapeJob) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var notBefore *time.Time
+	if !job.NotBefore.IsZero() {
+		notBefore = &job.NotBefore
+	}
+
+	err := d.db.Model(&models.FrontierURL{}).Where("url = ?", job.URL).Updates(map[string]interface{}{
+		"state":         models.FrontierQueued,
+		"retry_count":   job.RetryCount,
+		"lease_expires": nil,
+		"not_before":    notBefore,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to requeue %s: %w", job.URL, err)
+	}
+
+	return nil
+}
+
+// FinishURL records the final state of a URL, done or failed
+func (d *Database) FinishURL(url, state string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := d.db.Model(&models.FrontierURL{}).Where("url = ?", url).Updates(map[string]interface{}{
+		"state":         state,
+		"lease_expires": nil,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to finish %s: %w", url, err)
+	}
+
+	return nil
+}
+
+// ReleaseLeases puts every in-flight URL back in the queue and returns how
+// many there were
+func (d *Database) ReleaseLeases() (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := d.db.Model(&models.FrontierURL{}).Where("state = ?", models.FrontierInFlight).Updates(map[string]interface{}{
+		"state":         models.FrontierQueued,
+		"lease_expires": nil,
+	})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to release leases: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}